@@ -0,0 +1,79 @@
+package applicationJob
+
+import (
+	"DDD/src/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kind is a type of job whose payload is a T, stored as JSON. It is declared
+// once, next to the code enqueueing the jobs, and handled by the workers:
+//
+//	var Reindex = applicationJob.Kind[ReindexPayload]{Type: "search.reindex", Queue: "search"}
+type Kind[T any] struct {
+	Type string
+	// Queue defaults to DefaultQueue.
+	Queue string
+	// MaxAttempts defaults to those of the retry policy of the service.
+	MaxAttempts int
+}
+
+// Option changes a job before it is enqueued.
+type Option func(job *domain.Job)
+
+// At schedules the job for a given time.
+func At(runAt time.Time) Option {
+	return func(job *domain.Job) {
+		job.RunAt = runAt
+	}
+}
+
+// After delays the job by the given duration.
+func After(delay time.Duration) Option {
+	return func(job *domain.Job) {
+		job.RunAt = time.Now().Add(delay)
+	}
+}
+
+// Enqueue stores a job of the kind, due now unless an option schedules it.
+func (k Kind[T]) Enqueue(ctx context.Context, s *JobService, payload T, options ...Option) (*domain.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the payload of job %s: %w", k.Type, err)
+	}
+
+	queue := k.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	maxAttempts := k.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = s.Retry.MaxAttempts
+	}
+
+	job, err := domain.NewJob(queue, k.Type, data, time.Now(), maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		option(job)
+	}
+
+	if err := s.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Decode reads the payload of a job of the kind.
+func (k Kind[T]) Decode(job *domain.Job) (T, error) {
+	var payload T
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return payload, fmt.Errorf("failed to decode the payload of job %s: %w", k.Type, err)
+	}
+
+	return payload, nil
+}
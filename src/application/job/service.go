@@ -0,0 +1,144 @@
+package applicationJob
+
+import (
+	applicationTracing "DDD/src/application/tracing"
+	"DDD/src/domain"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"slices"
+	"time"
+)
+
+var tracer = otel.Tracer("DDD/src/application/job")
+
+// DefaultQueue runs the jobs of kinds that do not name a queue.
+const DefaultQueue = "default"
+
+type JobService struct {
+	JobRepo domain.JobRepository
+	// Retry spaces out the runs of failing jobs, and bounds the attempts of
+	// kinds that do not bound them.
+	Retry domain.RetryPolicy
+}
+
+type PaginatedJobs struct {
+	Jobs       []domain.Job `json:"jobs"`
+	Page       int          `json:"page"`
+	PerPage    int          `json:"per_page"`
+	TotalCount int64        `json:"total_count"`
+}
+
+// Enqueue stores a job for the workers of its queue. Inside a unit of work,
+// the job is only queued once the unit of work commits.
+func (s *JobService) Enqueue(ctx context.Context, job *domain.Job) (err error) {
+	ctx, span := tracer.Start(ctx, "JobService.Enqueue", trace.WithAttributes(
+		attribute.String("job.queue", job.Queue),
+		attribute.String("job.type", job.Type),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	if err := s.JobRepo.Create(ctx, job); err != nil {
+		return err
+	}
+
+	span.SetAttributes(attribute.Int("job.id", int(job.Id)))
+
+	return nil
+}
+
+func (s *JobService) FindById(ctx context.Context, jobId int) (_ *domain.Job, err error) {
+	ctx, span := tracer.Start(ctx, "JobService.FindById", trace.WithAttributes(attribute.Int("job.id", jobId)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.JobRepo.FindById(ctx, jobId)
+}
+
+func (s *JobService) FindPaginatedJobs(ctx context.Context, filter domain.JobFilter, page, perPage int) (_ *PaginatedJobs, err error) {
+	ctx, span := tracer.Start(ctx, "JobService.FindPaginatedJobs", trace.WithAttributes(
+		attribute.String("job.queue", filter.Queue),
+		attribute.String("job.status", filter.Status),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	if filter.Status != "" && !slices.Contains(domain.JobStatuses, filter.Status) {
+		return nil, domain.NewValidationError(fmt.Errorf("unknown status %q, must be one of %v", filter.Status, domain.JobStatuses))
+	}
+
+	jobs, total, err := s.JobRepo.Paginate(ctx, filter, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedJobs{
+		Jobs:       jobs,
+		Page:       page,
+		PerPage:    perPage,
+		TotalCount: total,
+	}, nil
+}
+
+// RetryJob queues a failed job again with a fresh set of attempts, typically
+// once what made it fail is fixed. Other jobs are queued or done already.
+func (s *JobService) RetryJob(ctx context.Context, jobId int) (_ *domain.Job, err error) {
+	ctx, span := tracer.Start(ctx, "JobService.RetryJob", trace.WithAttributes(attribute.Int("job.id", jobId)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	job, err := s.JobRepo.FindById(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.JobFailed {
+		return nil, domain.NewConflictError("job with id %d is %s, only failed jobs are retried", jobId, job.Status)
+	}
+
+	previous := *job
+	job.Retry(time.Now())
+	if err := s.JobRepo.Update(ctx, job, previous); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ClaimDue takes up to limit jobs of the queue that are due, for lease. Jobs
+// whose last worker was lost with their final attempt are failed by the claim
+// instead of run again.
+func (s *JobService) ClaimDue(ctx context.Context, queue string, lease time.Duration, limit int) ([]domain.Job, error) {
+	now := time.Now()
+	jobs, err := s.JobRepo.Claim(ctx, queue, now, lease, limit)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	_, span := applicationTracing.StartClaim(ctx, tracer, "JobService.ClaimDue", now,
+		attribute.String("job.queue", queue),
+		attribute.Int("jobs", len(jobs)),
+	)
+	span.End()
+
+	return jobs, nil
+}
+
+// RecordSuccess marks the job as done. A run that outlived its lease gets a
+// conflict instead, the job belonging to the worker that claimed it since.
+func (s *JobService) RecordSuccess(ctx context.Context, job *domain.Job) error {
+	previous := *job
+	job.Succeeded(time.Now())
+
+	return s.JobRepo.Update(ctx, job, previous)
+}
+
+// RecordFailure schedules the next run of the job, or fails it once it runs
+// out of attempts. The failure of a run past its lease is dropped with a
+// conflict, as its attempt was counted again by the claim that took over.
+func (s *JobService) RecordFailure(ctx context.Context, job *domain.Job, reason string) error {
+	previous := *job
+	job.Failed(reason, time.Now(), s.Retry)
+
+	return s.JobRepo.Update(ctx, job, previous)
+}
@@ -0,0 +1,326 @@
+package applicationNotification
+
+import (
+	applicationJob "DDD/src/application/job"
+	applicationTracing "DDD/src/application/tracing"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"log/slog"
+	"time"
+)
+
+var tracer = otel.Tracer("DDD/src/application/notification")
+
+// dailyWindow is how long a daily digest gathers comments.
+const dailyWindow = 24 * time.Hour
+
+// DigestJob sends the digests that are due. One is queued for when every
+// digest is due, and handled by the notifier.
+var DigestJob = applicationJob.Kind[DigestJobPayload]{Type: "notification.digest"}
+
+// DigestJobPayload names the recipient whose digest the job was queued for.
+type DigestJobPayload struct {
+	Email value_object.Email `json:"email"`
+}
+
+type NotificationService struct {
+	NotificationRepo domain.NotificationRepository
+	PreferenceRepo   domain.NotificationPreferenceRepository
+	PostRepo         domain.PostRepository
+	CommentRepo      domain.PostCommentRepository
+	// Jobs queues the DigestJob of every digest. Without it, notifications
+	// are disabled and comments are not queued at all.
+	Jobs  *applicationJob.JobService
+	Retry domain.RetryPolicy
+	// Delay is how long a batched digest waits for more comments after the first one.
+	Delay time.Duration
+}
+
+// Digest is what one email tells a recipient about: the comments on their
+// posts since the last one, oldest first. A recipient who did not confirm
+// their address yet is asked to instead, with no comments.
+type Digest struct {
+	Preference    domain.NotificationPreference
+	Items         []DigestItem
+	Notifications []domain.Notification
+	// Confirmation is set when the email asks the recipient to confirm their address.
+	Confirmation bool
+}
+
+type DigestItem struct {
+	Post    domain.Post
+	Comment domain.PostComment
+}
+
+// HandleEvent queues a notification of a new comment for the author of its
+// post. A notification that cannot be queued is logged with its comment, and
+// the author is not told about that comment.
+func (s *NotificationService) HandleEvent(ctx context.Context, event domain.Event) {
+	created, ok := event.(domain.CommentCreated)
+	if !ok {
+		return
+	}
+
+	if err := s.enqueue(ctx, created.Comment); err != nil {
+		slog.ErrorContext(ctx, "failed to queue comment notification",
+			slog.Uint64("comment", uint64(created.Comment.Id)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *NotificationService) enqueue(ctx context.Context, comment domain.PostComment) (err error) {
+	if s.Jobs == nil {
+		return nil
+	}
+
+	post, err := s.PostRepo.FindById(ctx, int(comment.PostId))
+	if err != nil || post.AuthorEmail == "" {
+		return err
+	}
+
+	ctx, span := tracer.Start(ctx, "NotificationService.HandleEvent")
+	defer func() { applicationTracing.End(span, err) }()
+
+	preference, err := s.preferenceOf(ctx, post)
+	if err != nil || preference.OptedOut {
+		return err
+	}
+	// Asked to confirm already, the address is not written to again until it is.
+	if !preference.Confirmed() && preference.ConfirmationSentAt != nil {
+		return nil
+	}
+
+	// Join the digest pending for the address, or start one.
+	dueAt, err := s.NotificationRepo.NextDue(ctx, post.AuthorEmail)
+	if err != nil {
+		return err
+	}
+	if dueAt == nil {
+		next := time.Now().Add(s.windowOf(preference))
+		dueAt = &next
+
+		// Queued first, so that no notification waits without a job to send it.
+		if _, err := DigestJob.Enqueue(ctx, s.Jobs, DigestJobPayload{Email: post.AuthorEmail}, applicationJob.At(next)); err != nil {
+			return err
+		}
+	}
+
+	return s.NotificationRepo.Create(ctx, &domain.Notification{
+		Email:     post.AuthorEmail,
+		PostId:    post.Id,
+		CommentId: comment.Id,
+		Status:    domain.NotificationPending,
+		DueAt:     dueAt,
+	})
+}
+
+// preferenceOf loads the preferences of the author, starting them on their first notification.
+func (s *NotificationService) preferenceOf(ctx context.Context, post *domain.Post) (*domain.NotificationPreference, error) {
+	preference, err := s.PreferenceRepo.FindByEmail(ctx, post.AuthorEmail)
+	if !errors.Is(err, domain.ErrNotFound) {
+		return preference, err
+	}
+
+	preference, err = domain.NewNotificationPreference(post.AuthorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.PreferenceRepo.Create(ctx, preference); err != nil {
+		// Started meanwhile by another comment.
+		if existing, findErr := s.PreferenceRepo.FindByEmail(ctx, post.AuthorEmail); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (s *NotificationService) windowOf(preference *domain.NotificationPreference) time.Duration {
+	if preference.Digest == domain.DigestDaily {
+		return dailyWindow
+	}
+
+	return s.Delay
+}
+
+// ClaimDue takes, for lease, the digests of up to limit recipients that are
+// due. Notifications of recipients who opted out or did not confirm their
+// address, or of comments and posts deleted meanwhile, are skipped. A
+// recipient who was never asked to confirm their address is asked instead.
+func (s *NotificationService) ClaimDue(ctx context.Context, lease time.Duration, limit int) (_ []Digest, err error) {
+	now := time.Now()
+	notifications, err := s.NotificationRepo.Claim(ctx, now, lease, limit)
+	if err != nil || len(notifications) == 0 {
+		return nil, err
+	}
+
+	ctx, span := applicationTracing.StartClaim(ctx, tracer, "NotificationService.ClaimDue", now, attribute.Int("notifications", len(notifications)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	var digests []Digest
+	byEmail := make(map[string]int)
+	var skipped []domain.Notification
+	for _, notification := range notifications {
+		i, ok := byEmail[notification.Email.String()]
+		if !ok {
+			preference, err := s.PreferenceRepo.FindByEmail(ctx, notification.Email)
+			if err != nil {
+				return nil, err
+			}
+			i = len(digests)
+			byEmail[notification.Email.String()] = i
+			digests = append(digests, Digest{
+				Preference:   *preference,
+				Confirmation: !preference.OptedOut && !preference.Confirmed() && preference.ConfirmationSentAt == nil,
+			})
+		}
+
+		item, reason, err := s.itemOf(ctx, &digests[i].Preference, notification)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			notification.Skip(reason)
+			skipped = append(skipped, notification)
+			continue
+		}
+
+		digests[i].Items = append(digests[i].Items, *item)
+		digests[i].Notifications = append(digests[i].Notifications, notification)
+	}
+
+	if err := s.NotificationRepo.Update(ctx, skipped); err != nil {
+		return nil, err
+	}
+
+	due := digests[:0]
+	for _, digest := range digests {
+		if len(digest.Items) > 0 || digest.Confirmation {
+			due = append(due, digest)
+		}
+	}
+	span.SetAttributes(attribute.Int("digests", len(due)))
+
+	return due, nil
+}
+
+// itemOf loads what a notification tells about, or why it is skipped.
+func (s *NotificationService) itemOf(ctx context.Context, preference *domain.NotificationPreference, notification domain.Notification) (*DigestItem, string, error) {
+	if preference.OptedOut {
+		return nil, "recipient opted out", nil
+	}
+	if !preference.Confirmed() {
+		return nil, "recipient not confirmed", nil
+	}
+
+	comment, err := s.CommentRepo.FindById(ctx, int(notification.CommentId))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, "comment deleted", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	post, err := s.PostRepo.FindById(ctx, int(notification.PostId))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, "post deleted", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	return &DigestItem{Post: *post, Comment: *comment}, "", nil
+}
+
+// RecordSent marks the notifications of the digest as sent, or the
+// confirmation request, which is never sent again.
+func (s *NotificationService) RecordSent(ctx context.Context, digest *Digest) error {
+	now := time.Now()
+	if digest.Confirmation {
+		digest.Preference.ConfirmationSent(now)
+		return s.PreferenceRepo.Update(ctx, &digest.Preference)
+	}
+
+	for i := range digest.Notifications {
+		digest.Notifications[i].Sent(now)
+	}
+
+	return s.NotificationRepo.Update(ctx, digest.Notifications)
+}
+
+// RecordFailure schedules the digest again, or gives up on it once the retry
+// policy runs out of attempts. A failed confirmation request is not recorded,
+// the next comment asks again.
+func (s *NotificationService) RecordFailure(ctx context.Context, digest *Digest, reason string) error {
+	now := time.Now()
+	for i := range digest.Notifications {
+		digest.Notifications[i].Failed(reason, now, s.Retry)
+	}
+
+	return s.NotificationRepo.Update(ctx, digest.Notifications)
+}
+
+// FollowUp queues the DigestJob again for the notifications of the address
+// still pending once its digest was attempted: those retried after a failure,
+// or claimed by a worker that may be lost before sending them.
+func (s *NotificationService) FollowUp(ctx context.Context, email value_object.Email) error {
+	dueAt, err := s.NotificationRepo.NextDue(ctx, email)
+	if err != nil || dueAt == nil {
+		return err
+	}
+
+	_, err = DigestJob.Enqueue(ctx, s.Jobs, DigestJobPayload{Email: email}, applicationJob.At(*dueAt))
+	return err
+}
+
+// FindPreference loads the preferences a token in an email stands for.
+func (s *NotificationService) FindPreference(ctx context.Context, token string) (_ *domain.NotificationPreference, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.FindPreference")
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.PreferenceRepo.FindByToken(ctx, token)
+}
+
+// ConfirmPreference records that the recipient of the token confirmed their
+// address, so that they are notified of the comments that follow.
+func (s *NotificationService) ConfirmPreference(ctx context.Context, token string) (_ *domain.NotificationPreference, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.ConfirmPreference")
+	defer func() { applicationTracing.End(span, err) }()
+
+	preference, err := s.PreferenceRepo.FindByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	preference.Confirm(time.Now())
+	if err := s.PreferenceRepo.Update(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+// UpdatePreference applies the choices that are given. Opting out also stops
+// the notifications already pending, which are skipped when due.
+func (s *NotificationService) UpdatePreference(ctx context.Context, token string, optedOut *bool, digest *string) (_ *domain.NotificationPreference, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.UpdatePreference")
+	defer func() { applicationTracing.End(span, err) }()
+
+	preference, err := s.PreferenceRepo.FindByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := preference.Change(optedOut, digest); err != nil {
+		return nil, err
+	}
+	if err := s.PreferenceRepo.Update(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
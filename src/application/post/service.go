@@ -1,12 +1,20 @@
 package applicationPost
 
 import (
+	applicationTracing "DDD/src/application/tracing"
 	"DDD/src/domain"
 	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("DDD/src/application/post")
+
 type PostService struct {
-	PostRepo domain.PostRepository
+	PostRepo   domain.PostRepository
+	UnitOfWork domain.UnitOfWork
+	Events     domain.EventPublisher
 }
 
 type PaginatedPosts struct {
@@ -16,7 +24,10 @@ type PaginatedPosts struct {
 	TotalCount int64         `json:"total_count"`
 }
 
-func (s *PostService) FindById(ctx context.Context, postID int) (*domain.Post, error) {
+func (s *PostService) FindById(ctx context.Context, postID int) (_ *domain.Post, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.FindById", trace.WithAttributes(attribute.Int("post.id", postID)))
+	defer func() { applicationTracing.End(span, err) }()
+
 	post, err := s.PostRepo.FindById(ctx, postID)
 	if err != nil {
 		return nil, err
@@ -25,7 +36,21 @@ func (s *PostService) FindById(ctx context.Context, postID int) (*domain.Post, e
 	return post, nil
 }
 
-func (s *PostService) FindPaginatedPosts(ctx context.Context, page, perPage int) (*PaginatedPosts, error) {
+// FindRevision tells which version of a post is current without loading it.
+func (s *PostService) FindRevision(ctx context.Context, postID int) (_ *domain.Revision, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.FindRevision", trace.WithAttributes(attribute.Int("post.id", postID)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.PostRepo.FindRevision(ctx, postID)
+}
+
+func (s *PostService) FindPaginatedPosts(ctx context.Context, page, perPage int) (_ *PaginatedPosts, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.FindPaginatedPosts", trace.WithAttributes(
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
 	posts, total, err := s.PostRepo.Paginate(ctx, page, perPage)
 	if err != nil {
 		return nil, err
@@ -39,29 +64,85 @@ func (s *PostService) FindPaginatedPosts(ctx context.Context, page, perPage int)
 	}, nil
 }
 
-func (s *PostService) CreatePost(ctx context.Context, post domain.Post) (*domain.Post, error) {
-	err := s.PostRepo.Create(ctx, &post)
+// FindPaginatedRevisions tells which posts, in which versions, a page holds without loading them.
+func (s *PostService) FindPaginatedRevisions(ctx context.Context, page, perPage int) (_ []domain.Revision, _ int64, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.FindPaginatedRevisions", trace.WithAttributes(
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.PostRepo.PaginateRevisions(ctx, page, perPage)
+}
+
+func (s *PostService) CreatePost(ctx context.Context, post domain.Post) (_ *domain.Post, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.CreatePost")
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.PostRepo.Create(ctx, &post)
 	if err != nil {
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.Int("post.id", int(post.Id)))
+	s.publish(ctx, domain.PostCreated{Post: post})
+
 	return &post, nil
 }
 
-func (s *PostService) UpdatePost(ctx context.Context, post domain.Post) (*domain.Post, error) {
-	err := s.PostRepo.Update(ctx, &post)
+// CreatePostWithComment creates a post together with its first comment, or neither of them.
+func (s *PostService) CreatePostWithComment(ctx context.Context, post domain.Post, comment domain.PostComment) (_ *domain.Post, _ *domain.PostComment, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.CreatePostWithComment")
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		if err := repos.Posts().Create(ctx, &post); err != nil {
+			return err
+		}
+
+		comment.PostId = post.Id
+		return repos.Comments().Create(ctx, &comment)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.publish(ctx, domain.PostCreated{Post: post}, domain.CommentCreated{Comment: comment})
+
+	return &post, &comment, nil
+}
+
+func (s *PostService) UpdatePost(ctx context.Context, post domain.Post) (_ *domain.Post, err error) {
+	ctx, span := tracer.Start(ctx, "PostService.UpdatePost", trace.WithAttributes(attribute.Int("post.id", int(post.Id))))
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.PostRepo.Update(ctx, &post)
 	if err != nil {
 		return nil, err
 	}
 
+	s.publish(ctx, domain.PostUpdated{Post: post})
+
 	return &post, nil
 }
 
-func (s *PostService) DeletePost(ctx context.Context, postID int) error {
-	err := s.PostRepo.Delete(ctx, postID)
+func (s *PostService) DeletePost(ctx context.Context, postID int) (err error) {
+	ctx, span := tracer.Start(ctx, "PostService.DeletePost", trace.WithAttributes(attribute.Int("post.id", postID)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.PostRepo.Delete(ctx, postID)
 	if err != nil {
 		return err
 	}
 
+	s.publish(ctx, domain.PostDeleted{PostId: uint(postID)})
+
 	return nil
 }
+
+// publish announces committed changes; the publisher is optional.
+func (s *PostService) publish(ctx context.Context, events ...domain.Event) {
+	if s.Events != nil {
+		s.Events.Publish(ctx, events...)
+	}
+}
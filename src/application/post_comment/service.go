@@ -1,13 +1,20 @@
 package applicationPostComment
 
 import (
+	applicationTracing "DDD/src/application/tracing"
 	"DDD/src/domain"
 	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("DDD/src/application/post_comment")
+
 type PostCommentService struct {
 	PostRepo        domain.PostRepository
 	PostCommentRepo domain.PostCommentRepository
+	Events          domain.EventPublisher
 }
 
 type PaginatedComments struct {
@@ -17,7 +24,10 @@ type PaginatedComments struct {
 	TotalCount int64                `json:"total_count"`
 }
 
-func (s *PostCommentService) FindById(ctx context.Context, commentId int) (*domain.PostComment, error) {
+func (s *PostCommentService) FindById(ctx context.Context, commentId int) (_ *domain.PostComment, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.FindById", trace.WithAttributes(attribute.Int("comment.id", commentId)))
+	defer func() { applicationTracing.End(span, err) }()
+
 	comment, err := s.PostCommentRepo.FindById(ctx, commentId)
 	if err != nil {
 		return nil, err
@@ -26,7 +36,46 @@ func (s *PostCommentService) FindById(ctx context.Context, commentId int) (*doma
 	return comment, nil
 }
 
-func (s *PostCommentService) FindPaginatedComments(ctx context.Context, postId int, page int, perPage int) (*PaginatedComments, error) {
+// FindPaginatedCommentsByPostIds loads the same page of the comments of several posts at once.
+// Every requested post gets a page, empty when it has no comments.
+func (s *PostCommentService) FindPaginatedCommentsByPostIds(ctx context.Context, postIds []uint, page int, perPage int) (_ map[uint]*PaginatedComments, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.FindPaginatedCommentsByPostIds", trace.WithAttributes(
+		attribute.Int("posts", len(postIds)),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	comments, totals, err := s.PostCommentRepo.PaginateByPostIds(ctx, postIds, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	byPost := make(map[uint]*PaginatedComments, len(postIds))
+	for _, postId := range postIds {
+		byPost[postId] = &PaginatedComments{
+			Comments:   []domain.PostComment{},
+			Page:       page,
+			PerPage:    perPage,
+			TotalCount: totals[postId],
+		}
+	}
+	for _, comment := range comments {
+		paginated := byPost[comment.PostId]
+		paginated.Comments = append(paginated.Comments, comment)
+	}
+
+	return byPost, nil
+}
+
+func (s *PostCommentService) FindPaginatedComments(ctx context.Context, postId int, page int, perPage int) (_ *PaginatedComments, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.FindPaginatedComments", trace.WithAttributes(
+		attribute.Int("post.id", postId),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
 	comments, total, err := s.PostCommentRepo.Paginate(ctx, postId, page, perPage)
 	if err != nil {
 		return nil, err
@@ -40,11 +89,70 @@ func (s *PostCommentService) FindPaginatedComments(ctx context.Context, postId i
 	}, nil
 }
 
-func (s *PostCommentService) CreatePostComment(ctx context.Context, comment domain.PostComment) (*domain.PostComment, error) {
-	err := s.PostCommentRepo.Create(ctx, &comment)
+// FindPaginatedRevisions tells which comments a page holds without loading them.
+func (s *PostCommentService) FindPaginatedRevisions(ctx context.Context, postId int, page int, perPage int) (_ []domain.Revision, _ int64, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.FindPaginatedRevisions", trace.WithAttributes(
+		attribute.Int("post.id", postId),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.PostCommentRepo.PaginateRevisions(ctx, postId, page, perPage)
+}
+
+func (s *PostCommentService) CreatePostComment(ctx context.Context, comment domain.PostComment) (_ *domain.PostComment, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.CreatePostComment", trace.WithAttributes(attribute.Int("post.id", int(comment.PostId))))
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.PostCommentRepo.Create(ctx, &comment)
 	if err != nil {
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.Int("comment.id", int(comment.Id)))
+	s.publish(ctx, domain.CommentCreated{Comment: comment})
+
 	return &comment, nil
 }
+
+func (s *PostCommentService) UpdatePostComment(ctx context.Context, comment domain.PostComment) (_ *domain.PostComment, err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.UpdatePostComment", trace.WithAttributes(attribute.Int("comment.id", int(comment.Id))))
+	defer func() { applicationTracing.End(span, err) }()
+
+	err = s.PostCommentRepo.Update(ctx, &comment)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, domain.CommentUpdated{Comment: comment})
+
+	return &comment, nil
+}
+
+func (s *PostCommentService) DeletePostComment(ctx context.Context, commentId int) (err error) {
+	ctx, span := tracer.Start(ctx, "PostCommentService.DeletePostComment", trace.WithAttributes(attribute.Int("comment.id", commentId)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	// Loaded first, so that the event tells which post lost a comment.
+	comment, err := s.PostCommentRepo.FindById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+
+	err = s.PostCommentRepo.Delete(ctx, commentId)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, domain.CommentDeleted{Comment: *comment})
+
+	return nil
+}
+
+// publish announces committed changes; the publisher is optional.
+func (s *PostCommentService) publish(ctx context.Context, events ...domain.Event) {
+	if s.Events != nil {
+		s.Events.Publish(ctx, events...)
+	}
+}
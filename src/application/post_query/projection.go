@@ -0,0 +1,57 @@
+package applicationPostQuery
+
+import (
+	applicationTracing "DDD/src/application/tracing"
+	"DDD/src/domain"
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+)
+
+// Projection applies the events of posts and comments to the read model.
+type Projection struct {
+	SummaryRepo domain.PostSummaryRepository
+}
+
+// HandleEvent applies an event of a post or comment to its summary. A summary
+// that fails to update stays stale until the next rebuild, the error is logged.
+func (p *Projection) HandleEvent(ctx context.Context, event domain.Event) {
+	if err := p.apply(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to project event on post summaries",
+			slog.String("event", event.EventName()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (p *Projection) apply(ctx context.Context, event domain.Event) (err error) {
+	switch event.(type) {
+	case domain.PostCreated, domain.PostUpdated, domain.PostDeleted,
+		domain.CommentCreated, domain.CommentUpdated, domain.CommentDeleted:
+	default:
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "Projection.HandleEvent", trace.WithAttributes(attribute.String("event", event.EventName())))
+	defer func() { applicationTracing.End(span, err) }()
+
+	switch e := event.(type) {
+	case domain.PostCreated:
+		summary := domain.NewPostSummary(e.Post)
+		return p.SummaryRepo.Create(ctx, &summary)
+	case domain.PostUpdated:
+		return p.SummaryRepo.UpdatePost(ctx, e.Post)
+	case domain.PostDeleted:
+		return p.SummaryRepo.Delete(ctx, e.PostId)
+	case domain.CommentCreated:
+		return p.SummaryRepo.AddActivity(ctx, e.Comment.PostId, 1, e.Comment.CreatedAt)
+	case domain.CommentUpdated:
+		return p.SummaryRepo.AddActivity(ctx, e.Comment.PostId, 0, e.Comment.UpdatedAt)
+	case domain.CommentDeleted:
+		// The last activity may have been the deleted comment's, which only the comments table tells.
+		return p.SummaryRepo.Refresh(ctx, e.Comment.PostId)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package applicationPostQuery
+
+import (
+	applicationTracing "DDD/src/application/tracing"
+	"DDD/src/domain"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"slices"
+)
+
+var tracer = otel.Tracer("DDD/src/application/post_query")
+
+// PostQueryService serves post listings from the read model, which the
+// projection keeps up to date, instead of the posts and comments tables.
+type PostQueryService struct {
+	SummaryRepo domain.PostSummaryRepository
+}
+
+type PaginatedSummaries struct {
+	Summaries  []domain.PostSummary `json:"summaries"`
+	Page       int                  `json:"page"`
+	PerPage    int                  `json:"per_page"`
+	TotalCount int64                `json:"total_count"`
+}
+
+func (s *PostQueryService) FindPaginatedSummaries(ctx context.Context, order string, page, perPage int) (_ *PaginatedSummaries, err error) {
+	ctx, span := tracer.Start(ctx, "PostQueryService.FindPaginatedSummaries", trace.WithAttributes(
+		attribute.String("order", order),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	if !slices.Contains(domain.SummaryOrders, order) {
+		return nil, domain.NewValidationError(fmt.Errorf("unknown order %q, must be one of %v", order, domain.SummaryOrders))
+	}
+
+	summaries, total, err := s.SummaryRepo.Paginate(ctx, order, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedSummaries{
+		Summaries:  summaries,
+		Page:       page,
+		PerPage:    perPage,
+		TotalCount: total,
+	}, nil
+}
+
+// Rebuild regenerates the read model from the posts and comments tables, for
+// when events were missed, and tells how many posts it summarized.
+func (s *PostQueryService) Rebuild(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "PostQueryService.Rebuild")
+	defer func() { applicationTracing.End(span, err) }()
+
+	summarized, err := s.SummaryRepo.Rebuild(ctx)
+	span.SetAttributes(attribute.Int64("posts", summarized))
+
+	return summarized, err
+}
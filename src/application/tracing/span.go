@@ -0,0 +1,36 @@
+package applicationTracing
+
+import (
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// StartClaim starts the span of a claim made by a polling worker, dated back
+// to when the claim began. Workers claim on every poll and mostly find
+// nothing, so services call it once a claim took something, and claims that
+// took nothing leave no trace.
+func StartClaim(ctx context.Context, tracer trace.Tracer, name string, started time.Time, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithTimestamp(started), trace.WithAttributes(attrs...))
+}
+
+// End records err on span and ends it. Domain errors are expected outcomes,
+// such as a missing post, and do not mark the span as failed.
+func End(span trace.Span, err error) {
+	defer span.End()
+
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,281 @@
+package applicationWebhook
+
+import (
+	applicationTracing "DDD/src/application/tracing"
+	"DDD/src/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
+)
+
+var tracer = otel.Tracer("DDD/src/application/webhook")
+
+type WebhookService struct {
+	WebhookRepo  domain.WebhookRepository
+	DeliveryRepo domain.WebhookDeliveryRepository
+	Retry        domain.RetryPolicy
+}
+
+type PaginatedWebhooks struct {
+	Webhooks   []domain.Webhook `json:"webhooks"`
+	Page       int              `json:"page"`
+	PerPage    int              `json:"per_page"`
+	TotalCount int64            `json:"total_count"`
+}
+
+type PaginatedDeliveries struct {
+	Deliveries []domain.WebhookDelivery `json:"deliveries"`
+	Page       int                      `json:"page"`
+	PerPage    int                      `json:"per_page"`
+	TotalCount int64                    `json:"total_count"`
+}
+
+// DueDelivery is a claimed delivery with the webhook it goes to.
+type DueDelivery struct {
+	Delivery domain.WebhookDelivery
+	Webhook  domain.Webhook
+}
+
+// Envelope is the body posted to webhooks. Id is the same for every webhook
+// receiving the event, so that receivers can drop repeated deliveries.
+type Envelope struct {
+	Id        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+func (s *WebhookService) FindById(ctx context.Context, webhookId int) (_ *domain.Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.FindById", trace.WithAttributes(attribute.Int("webhook.id", webhookId)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.WebhookRepo.FindById(ctx, webhookId)
+}
+
+func (s *WebhookService) FindPaginatedWebhooks(ctx context.Context, page, perPage int) (_ *PaginatedWebhooks, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.FindPaginatedWebhooks", trace.WithAttributes(
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	webhooks, total, err := s.WebhookRepo.Paginate(ctx, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedWebhooks{
+		Webhooks:   webhooks,
+		Page:       page,
+		PerPage:    perPage,
+		TotalCount: total,
+	}, nil
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook domain.Webhook) (_ *domain.Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.CreateWebhook")
+	defer func() { applicationTracing.End(span, err) }()
+
+	if err := s.WebhookRepo.Create(ctx, &webhook); err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("webhook.id", int(webhook.Id)))
+
+	return &webhook, nil
+}
+
+// DeleteWebhook removes the webhook together with its delivery log.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookId int) (err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.DeleteWebhook", trace.WithAttributes(attribute.Int("webhook.id", webhookId)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	return s.WebhookRepo.Delete(ctx, webhookId)
+}
+
+func (s *WebhookService) FindPaginatedDeliveries(ctx context.Context, webhookId int, page, perPage int) (_ *PaginatedDeliveries, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.FindPaginatedDeliveries", trace.WithAttributes(
+		attribute.Int("webhook.id", webhookId),
+		attribute.Int("page", page),
+		attribute.Int("per_page", perPage),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	if _, err := s.WebhookRepo.FindById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := s.DeliveryRepo.Paginate(ctx, webhookId, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedDeliveries{
+		Deliveries: deliveries,
+		Page:       page,
+		PerPage:    perPage,
+		TotalCount: total,
+	}, nil
+}
+
+// Redeliver queues a delivery of the webhook again, typically a dead one once
+// the receiver is fixed. Pending deliveries are already queued.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookId int, deliveryId int) (_ *domain.WebhookDelivery, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Redeliver", trace.WithAttributes(
+		attribute.Int("webhook.id", webhookId),
+		attribute.Int("delivery.id", deliveryId),
+	))
+	defer func() { applicationTracing.End(span, err) }()
+
+	delivery, err := s.DeliveryRepo.FindById(ctx, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookId != uint(webhookId) {
+		return nil, domain.NewNotFoundError("delivery with id %d not found", deliveryId)
+	}
+	if delivery.Status == domain.DeliveryPending {
+		return nil, domain.NewConflictError("delivery with id %d is already pending", deliveryId)
+	}
+
+	previous := *delivery
+	delivery.Redeliver(time.Now())
+	if err := s.DeliveryRepo.Update(ctx, delivery, previous); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery of the event to every webhook subscribed to
+// it. When queueing fails no webhook hears of the event, which is logged.
+func (s *WebhookService) HandleEvent(ctx context.Context, event domain.Event) {
+	if err := s.enqueue(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to queue webhook deliveries",
+			slog.String("event", event.EventName()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *WebhookService) enqueue(ctx context.Context, event domain.Event) (err error) {
+	data, ok := dataOf(event)
+	if !ok {
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "WebhookService.HandleEvent", trace.WithAttributes(attribute.String("event", event.EventName())))
+	defer func() { applicationTracing.End(span, err) }()
+
+	webhooks, err := s.WebhookRepo.FindByEvent(ctx, event.EventName())
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	envelope := Envelope{
+		Id:        uuid.NewString(),
+		Type:      event.EventName(),
+		CreatedAt: now,
+		Data:      data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			WebhookId:     webhook.Id,
+			EventId:       envelope.Id,
+			Event:         envelope.Type,
+			Payload:       string(payload),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	span.SetAttributes(attribute.Int("deliveries", len(deliveries)))
+
+	return s.DeliveryRepo.Create(ctx, deliveries)
+}
+
+// ClaimDue takes up to limit deliveries that are due, for lease, with their
+// webhooks. Deliveries of webhooks deleted meanwhile are left out.
+func (s *WebhookService) ClaimDue(ctx context.Context, lease time.Duration, limit int) (_ []DueDelivery, err error) {
+	now := time.Now()
+	deliveries, err := s.DeliveryRepo.Claim(ctx, now, lease, limit)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	ctx, span := applicationTracing.StartClaim(ctx, tracer, "WebhookService.ClaimDue", now, attribute.Int("deliveries", len(deliveries)))
+	defer func() { applicationTracing.End(span, err) }()
+
+	webhooks := make(map[uint]*domain.Webhook)
+	due := make([]DueDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok {
+			webhook, err = s.WebhookRepo.FindById(ctx, int(delivery.WebhookId))
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return nil, err
+			}
+			webhooks[delivery.WebhookId] = webhook
+		}
+		// Deleted meanwhile, its deliveries go with it.
+		if webhook == nil {
+			continue
+		}
+
+		due = append(due, DueDelivery{Delivery: delivery, Webhook: *webhook})
+	}
+
+	return due, nil
+}
+
+// RecordSuccess marks the delivery as accepted by the receiver. It fails with
+// a conflict when the lease of the claim ran out and the delivery was taken
+// over, whose new owner records the outcome instead.
+func (s *WebhookService) RecordSuccess(ctx context.Context, delivery *domain.WebhookDelivery, status int) error {
+	previous := *delivery
+	delivery.Succeeded(status, time.Now())
+
+	return s.DeliveryRepo.Update(ctx, delivery, previous)
+}
+
+// RecordFailure schedules the next attempt of the delivery, or marks it dead
+// once the retry policy runs out of attempts. status is 0 without a response.
+// Like RecordSuccess, it fails with a conflict once the lease is lost.
+func (s *WebhookService) RecordFailure(ctx context.Context, delivery *domain.WebhookDelivery, status int, reason string) error {
+	previous := *delivery
+	delivery.Failed(status, reason, time.Now(), s.Retry)
+
+	return s.DeliveryRepo.Update(ctx, delivery, previous)
+}
+
+// dataOf is what the envelope of an event carries, and whether webhooks receive the event at all.
+func dataOf(event domain.Event) (any, bool) {
+	switch e := event.(type) {
+	case domain.PostCreated:
+		return e.Post, true
+	case domain.PostUpdated:
+		return e.Post, true
+	case domain.PostDeleted:
+		return map[string]uint{"id": e.PostId}, true
+	case domain.CommentCreated:
+		return e.Comment, true
+	case domain.CommentUpdated:
+		return e.Comment, true
+	case domain.CommentDeleted:
+		return e.Comment, true
+	default:
+		return nil, false
+	}
+}
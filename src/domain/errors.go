@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds. Compare against them with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+)
+
+// Error is a domain error of a given kind with a human-readable message.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Err}
+}
+
+func NewNotFoundError(format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewConflictError(format string, args ...any) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewValidationError(err error) error {
+	return &Error{Kind: ErrValidation, Message: err.Error(), Err: err}
+}
+
+func NewForbiddenError(format string, args ...any) error {
+	return &Error{Kind: ErrForbidden, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "context"
+
+// Event is something that happened in the domain.
+type Event interface {
+	EventName() string
+}
+
+// EventPublisher delivers events to whoever is interested in them. Services
+// publish only after the change has been committed.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...Event)
+}
+
+type PostCreated struct {
+	Post Post
+}
+
+func (e PostCreated) EventName() string {
+	return "post.created"
+}
+
+type PostUpdated struct {
+	Post Post
+}
+
+func (e PostUpdated) EventName() string {
+	return "post.updated"
+}
+
+type PostDeleted struct {
+	PostId uint
+}
+
+func (e PostDeleted) EventName() string {
+	return "post.deleted"
+}
+
+type CommentCreated struct {
+	Comment PostComment
+}
+
+func (e CommentCreated) EventName() string {
+	return "comment.created"
+}
+
+type CommentUpdated struct {
+	Comment PostComment
+}
+
+func (e CommentUpdated) EventName() string {
+	return "comment.updated"
+}
+
+// CommentDeleted carries the deleted comment, so that subscribers know its post.
+type CommentDeleted struct {
+	Comment PostComment
+}
+
+func (e CommentDeleted) EventName() string {
+	return "comment.deleted"
+}
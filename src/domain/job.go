@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job statuses. A pending job runs once due, and is running while a worker
+// holds its lease. It is retried until it succeeds or runs out of attempts,
+// and is then failed until it is retried by hand.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+var JobStatuses = []string{JobPending, JobRunning, JobSucceeded, JobFailed}
+
+// Job is work done in the background by the workers of its queue, by the
+// handler registered for its type.
+type Job struct {
+	Id      uint   `gorm:"primarykey" json:"id"`
+	Queue   string `gorm:"size:64;not null" json:"queue"`
+	Type    string `gorm:"size:128;not null" json:"type"`
+	Payload string `gorm:"type:text;not null" json:"-"`
+	Status  string `gorm:"size:16;not null" json:"status"`
+	// Attempts counts the runs started, MaxAttempts bounds them.
+	Attempts    int `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int `gorm:"not null" json:"maxAttempts"`
+	// RunAt is when a pending job is due.
+	RunAt time.Time `gorm:"not null" json:"runAt"`
+	// LockedUntil is when the lease of a running job runs out, and another
+	// worker may take it over. nil once it is not running.
+	LockedUntil *time.Time `json:"lockedUntil"`
+	LastError   string     `gorm:"type:text" json:"lastError"`
+	FinishedAt  *time.Time `json:"finishedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func NewJob(queue string, jobType string, payload []byte, runAt time.Time, maxAttempts int) (*Job, error) {
+	if queue == "" || len(queue) > 64 {
+		return nil, NewValidationError(errors.New("queue must be between 1 and 64 characters"))
+	}
+	if jobType == "" || len(jobType) > 128 {
+		return nil, NewValidationError(errors.New("job type must be between 1 and 128 characters"))
+	}
+	if maxAttempts < 1 {
+		return nil, NewValidationError(fmt.Errorf("max attempts must be at least 1, got %d", maxAttempts))
+	}
+
+	return &Job{
+		Queue:       queue,
+		Type:        jobType,
+		Payload:     string(payload),
+		Status:      JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	}, nil
+}
+
+// Succeeded records that the last run of the job went through.
+func (j *Job) Succeeded(at time.Time) {
+	j.Status = JobSucceeded
+	j.LastError = ""
+	j.LockedUntil = nil
+	j.FinishedAt = &at
+}
+
+// Failed records a failed run, and schedules the next one or gives up once
+// the job runs out of attempts.
+func (j *Job) Failed(reason string, at time.Time, policy RetryPolicy) {
+	j.LastError = reason
+	j.LockedUntil = nil
+
+	if j.Attempts >= j.MaxAttempts {
+		j.Status = JobFailed
+		j.FinishedAt = &at
+		return
+	}
+
+	j.Status = JobPending
+	j.RunAt = at.Add(policy.Delay(j.Attempts))
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (j *Job) Retry(at time.Time) {
+	j.Status = JobPending
+	j.Attempts = 0
+	j.LastError = ""
+	j.RunAt = at
+	j.FinishedAt = nil
+}
+
+// JobFilter narrows a job listing, empty fields match every job.
+type JobFilter struct {
+	Queue  string
+	Status string
+}
+
+type JobRepository interface {
+	FindById(ctx context.Context, id int) (*Job, error)
+	// Paginate lists the jobs matching the filter, latest first.
+	Paginate(ctx context.Context, filter JobFilter, page int, perPage int) ([]Job, int64, error)
+	Create(ctx context.Context, job *Job) error
+	// Update saves the job if its status, attempts and lease are still those of
+	// previous. Otherwise, say a claim took the job over or it was retried by
+	// hand, nothing is written and a conflict error is returned.
+	Update(ctx context.Context, job *Job, previous Job) error
+	// Claim takes, for lease, up to limit jobs of the queue that are due or
+	// whose lease ran out, counting an attempt for each. Jobs whose lease ran
+	// out on their last attempt are failed instead.
+	Claim(ctx context.Context, queue string, now time.Time, lease time.Duration, limit int) ([]Job, error)
+}
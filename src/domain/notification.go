@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"DDD/src/domain/value_object"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Notification statuses. A pending notification is sent with the others
+// pending for the same address, retried until it is sent or runs out of
+// attempts, and skipped when its recipient opted out or its comment is gone.
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+	NotificationSkipped = "skipped"
+)
+
+// Digest frequencies. A batched digest gathers the comments arriving within a
+// few minutes of the first one, a daily digest those of a day.
+const (
+	DigestBatched = "batched"
+	DigestDaily   = "daily"
+)
+
+var DigestFrequencies = []string{DigestBatched, DigestDaily}
+
+// Notification is a comment to tell the author of its post about.
+type Notification struct {
+	Id        uint               `gorm:"primarykey" json:"id"`
+	Email     value_object.Email `gorm:"size:254;not null" json:"email"`
+	PostId    uint               `gorm:"not null" json:"postId"`
+	CommentId uint               `gorm:"not null" json:"commentId"`
+	Status    string             `gorm:"size:16;not null" json:"status"`
+	Attempts  int                `gorm:"not null;default:0" json:"attempts"`
+	// DueAt is when a pending notification is sent, the same for every one
+	// pending for an address, nil once it is not pending.
+	DueAt     *time.Time `gorm:"index" json:"dueAt"`
+	LastError string     `gorm:"type:text" json:"lastError"`
+	SentAt    *time.Time `json:"sentAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Sent records that the email telling about the notification went out.
+func (n *Notification) Sent(at time.Time) {
+	n.Attempts++
+	n.Status = NotificationSent
+	n.LastError = ""
+	n.SentAt = &at
+	n.DueAt = nil
+}
+
+// Failed records a failed attempt, and schedules the next one or gives up
+// once the policy runs out of attempts.
+func (n *Notification) Failed(reason string, at time.Time, policy RetryPolicy) {
+	n.Attempts++
+	n.LastError = reason
+
+	if n.Attempts >= policy.MaxAttempts {
+		n.Status = NotificationFailed
+		n.DueAt = nil
+		return
+	}
+
+	next := at.Add(policy.Delay(n.Attempts))
+	n.DueAt = &next
+}
+
+// Skip drops the notification without sending it.
+func (n *Notification) Skip(reason string) {
+	n.Status = NotificationSkipped
+	n.LastError = reason
+	n.DueAt = nil
+}
+
+// NotificationPreference holds the choices of a recipient, who is only known
+// by address. The token in the links of the emails authorizes changing them.
+//
+// Anyone may give any address when creating a post, so a recipient is only
+// emailed about comments once they confirmed it is theirs, by following the
+// link of the one confirmation request they were sent.
+type NotificationPreference struct {
+	Email    value_object.Email `gorm:"primarykey;size:254" json:"email"`
+	Token    string             `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	OptedOut bool               `gorm:"not null;default:false" json:"optedOut"`
+	Digest   string             `gorm:"size:16;not null" json:"digest"`
+	// ConfirmedAt is when the recipient confirmed their address, nil until then.
+	ConfirmedAt *time.Time `json:"confirmedAt"`
+	// ConfirmationSentAt is when the confirmation request was sent, nil until then.
+	ConfirmationSentAt *time.Time `json:"-"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
+// NewNotificationPreference starts a recipient with batched digests, and a token for its links.
+func NewNotificationPreference(email value_object.Email) (*NotificationPreference, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	return &NotificationPreference{Email: email, Token: hex.EncodeToString(token), Digest: DigestBatched}, nil
+}
+
+// Change applies the choices that are given.
+func (p *NotificationPreference) Change(optedOut *bool, digest *string) error {
+	if digest != nil {
+		if !slices.Contains(DigestFrequencies, *digest) {
+			return NewValidationError(fmt.Errorf("unknown digest %q, must be one of %v", *digest, DigestFrequencies))
+		}
+		p.Digest = *digest
+	}
+	if optedOut != nil {
+		p.OptedOut = *optedOut
+	}
+
+	return nil
+}
+
+// Confirmed tells whether the recipient confirmed their address.
+func (p *NotificationPreference) Confirmed() bool {
+	return p.ConfirmedAt != nil
+}
+
+// Confirm records that the recipient confirmed their address, once.
+func (p *NotificationPreference) Confirm(at time.Time) {
+	if p.ConfirmedAt == nil {
+		p.ConfirmedAt = &at
+	}
+}
+
+// ConfirmationSent records that the confirmation request went out.
+func (p *NotificationPreference) ConfirmationSent(at time.Time) {
+	p.ConfirmationSentAt = &at
+}
+
+type NotificationRepository interface {
+	// NextDue is when the notifications pending for the address are due, nil when none is.
+	NextDue(ctx context.Context, email value_object.Email) (*time.Time, error)
+	Create(ctx context.Context, notification *Notification) error
+	// Update saves the outcome of the notifications sent together.
+	Update(ctx context.Context, notifications []Notification) error
+	// Claim takes, for lease, every pending notification of up to limit addresses that are due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error)
+}
+
+type NotificationPreferenceRepository interface {
+	FindByEmail(ctx context.Context, email value_object.Email) (*NotificationPreference, error)
+	FindByToken(ctx context.Context, token string) (*NotificationPreference, error)
+	Create(ctx context.Context, preference *NotificationPreference) error
+	Update(ctx context.Context, preference *NotificationPreference) error
+}
@@ -11,14 +11,25 @@ type Post struct {
 	Title     value_object.Title   `gorm:"size:255;not null" json:"title"`
 	Content   value_object.Content `gorm:"type:text" json:"content"`
 	Comments  []PostComment        `gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE" json:"-"`
+	Version   uint                 `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time            `json:"createdAt"`
 	UpdatedAt time.Time            `json:"updatedAt"`
 	DeletedAt *time.Time           `gorm:"index" json:"deletedAt"`
+
+	// AuthorEmail is where comments on the post are notified, none when empty.
+	// It is private to the author, so never marshalled.
+	AuthorEmail value_object.Email `gorm:"size:254" json:"-"`
+}
+
+func (p Post) Revision() Revision {
+	return Revision{Id: p.Id, Version: p.Version, UpdatedAt: p.UpdatedAt}
 }
 
 type PostRepository interface {
 	FindById(ctx context.Context, id int) (*Post, error)
+	FindRevision(ctx context.Context, id int) (*Revision, error)
 	Paginate(ctx context.Context, page int, perPage int) ([]Post, int64, error)
+	PaginateRevisions(ctx context.Context, page int, perPage int) ([]Revision, int64, error)
 	Create(ctx context.Context, post *Post) error
 	Update(ctx context.Context, post *Post) error
 	Delete(ctx context.Context, id int) error
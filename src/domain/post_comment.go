@@ -10,14 +10,25 @@ type PostComment struct {
 	Id        uint              `gorm:"primarykey" json:"id"`
 	PostId    uint              `gorm:"index;not null" json:"postId"`
 	Text      value_object.Text `gorm:"type:text;not null" json:"text"`
+	Version   uint              `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time         `json:"createdAt"`
 	UpdatedAt time.Time         `json:"updatedAt"`
 	DeletedAt *time.Time        `gorm:"index" json:"deletedAt"`
 }
 
+func (c PostComment) Revision() Revision {
+	return Revision{Id: c.Id, Version: c.Version, UpdatedAt: c.UpdatedAt}
+}
+
 type PostCommentRepository interface {
 	FindById(ctx context.Context, id int) (*PostComment, error)
 	FindByPostId(ctx context.Context, postID int) ([]PostComment, error)
 	Paginate(ctx context.Context, postId int, page int, perPage int) ([]PostComment, int64, error)
+	// PaginateByPostIds loads the same page of the comments of several posts, newest first like Paginate,
+	// ordered by post, together with the number of comments of every post that has any.
+	PaginateByPostIds(ctx context.Context, postIds []uint, page int, perPage int) ([]PostComment, map[uint]int64, error)
+	PaginateRevisions(ctx context.Context, postId int, page int, perPage int) ([]Revision, int64, error)
 	Create(ctx context.Context, comment *PostComment) error
+	Update(ctx context.Context, comment *PostComment) error
+	Delete(ctx context.Context, id int) error
 }
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// ExcerptLength is how many characters of its content a post summary keeps.
+const ExcerptLength = 200
+
+// Orders of post summary listings, latest post, latest activity or most comments first.
+const (
+	SummariesByLatest   = "latest"
+	SummariesByActivity = "activity"
+	SummariesByComments = "comments"
+)
+
+var SummaryOrders = []string{SummariesByLatest, SummariesByActivity, SummariesByComments}
+
+// PostSummary is the read model of a post in listings. It is kept up to date
+// from the events of posts and comments, and can be rebuilt from the posts and
+// comments tables at any time.
+type PostSummary struct {
+	PostId       uint   `gorm:"primarykey" json:"id"`
+	Title        string `gorm:"size:255;not null" json:"title"`
+	Excerpt      string `gorm:"type:text;not null" json:"excerpt"`
+	Version      uint   `gorm:"not null" json:"version"`
+	CommentCount int    `gorm:"not null;default:0" json:"commentCount"`
+	// LastActivityAt is when the post or one of its comments was last created or updated.
+	LastActivityAt time.Time `gorm:"not null" json:"lastActivityAt"`
+	CreatedAt      time.Time `gorm:"autoCreateTime:false" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime:false" json:"updatedAt"`
+}
+
+// NewPostSummary summarizes a post without comments.
+func NewPostSummary(post Post) PostSummary {
+	return PostSummary{
+		PostId:         post.Id,
+		Title:          post.Title.String(),
+		Excerpt:        Excerpt(post.Content.String()),
+		Version:        post.Version,
+		LastActivityAt: post.UpdatedAt,
+		CreatedAt:      post.CreatedAt,
+		UpdatedAt:      post.UpdatedAt,
+	}
+}
+
+// Excerpt is the start of a content, up to ExcerptLength characters.
+func Excerpt(content string) string {
+	if utf8.RuneCountInString(content) <= ExcerptLength {
+		return content
+	}
+
+	return string([]rune(content)[:ExcerptLength])
+}
+
+type PostSummaryRepository interface {
+	Paginate(ctx context.Context, order string, page int, perPage int) ([]PostSummary, int64, error)
+	// Create adds the summary of a new post, unless it is there already.
+	Create(ctx context.Context, summary *PostSummary) error
+	// UpdatePost applies a version of a post, unless a later one is applied already.
+	UpdatePost(ctx context.Context, post Post) error
+	// AddActivity adds comments, or removes them when negative, and records activity at the given time.
+	AddActivity(ctx context.Context, postId uint, comments int, at time.Time) error
+	Delete(ctx context.Context, postId uint) error
+	// Refresh summarizes a post again from the posts and comments tables.
+	Refresh(ctx context.Context, postId uint) error
+	// Rebuild summarizes every post again from the posts and comments tables, and tells how many there are.
+	Rebuild(ctx context.Context) (int64, error)
+}
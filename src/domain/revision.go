@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Revision identifies the state of a record without loading it, so that
+// clients holding a current copy can be answered cheaply.
+type Revision struct {
+	Id uint
+	// Version increments with every update, so that it changes within one timestamp tick.
+	Version   uint
+	UpdatedAt time.Time
+}
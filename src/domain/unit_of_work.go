@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// Repositories gives access to repositories bound to one unit of work.
+type Repositories interface {
+	Posts() PostRepository
+	Comments() PostCommentRepository
+}
+
+// UnitOfWork runs fn atomically: all changes made through repos are committed
+// when fn returns nil and rolled back otherwise. Calling Do again with the
+// context passed to fn nests the work, so only the inner part is rolled back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
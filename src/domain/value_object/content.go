@@ -1,14 +1,10 @@
 package value_object
 
-import (
-	"errors"
-)
-
 type Content string
 
 func NewContent(content string) (Content, error) {
 	if err := isValidContent(content); err != nil {
-		return "", errors.New(err.Error())
+		return "", err
 	}
 
 	return Content(content), nil
@@ -20,11 +16,11 @@ func (e Content) String() string {
 
 func isValidContent(content string) error {
 	if len(content) == 0 {
-		return errors.New("content is required")
+		return newValidationError("content", "content is required")
 	}
 
 	if len(content) > 500 {
-		return errors.New("content is too long")
+		return newValidationError("content", "content is too long")
 	}
 
 	return nil
@@ -0,0 +1,38 @@
+package value_object
+
+import (
+	"net/mail"
+	"strings"
+)
+
+type Email string
+
+func NewEmail(email string) (Email, error) {
+	if err := isValidEmail(email); err != nil {
+		return "", err
+	}
+
+	// Addresses are compared case-insensitively, and stored in one case.
+	return Email(strings.ToLower(email)), nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+
+func isValidEmail(email string) error {
+	if len(email) == 0 {
+		return newValidationError("email", "email is required")
+	}
+
+	if len(email) > 254 {
+		return newValidationError("email", "email is too long")
+	}
+
+	// A bare address only, without a display name.
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return newValidationError("email", "email is invalid")
+	}
+	return nil
+}
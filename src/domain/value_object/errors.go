@@ -0,0 +1,15 @@
+package value_object
+
+// ValidationError tells which value object rejected a value.
+type ValidationError struct {
+	ValueObject string
+	Message     string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func newValidationError(valueObject string, message string) error {
+	return &ValidationError{ValueObject: valueObject, Message: message}
+}
@@ -1,9 +1,5 @@
 package value_object
 
-import (
-	"fmt"
-)
-
 type Text string
 
 func NewText(text string) (Text, error) {
@@ -19,15 +15,15 @@ func (e Text) String() string {
 
 func isValidText(text string) error {
 	if len(text) == 0 {
-		return fmt.Errorf("text is required")
+		return newValidationError("text", "text is required")
 	}
 
 	if len(text) < 3 {
-		return fmt.Errorf("text is too short")
+		return newValidationError("text", "text is too short")
 	}
 
 	if len(text) > 100 {
-		return fmt.Errorf("text is too long")
+		return newValidationError("text", "text is too long")
 	}
 	return nil
 }
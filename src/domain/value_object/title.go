@@ -1,14 +1,10 @@
 package value_object
 
-import (
-	"errors"
-)
-
 type Title string
 
 func NewTitle(title string) (Title, error) {
 	if err := isValidTitle(title); err != nil {
-		return "", errors.New(err.Error())
+		return "", err
 	}
 
 	return Title(title), nil
@@ -20,15 +16,15 @@ func (e Title) String() string {
 
 func isValidTitle(title string) error {
 	if len(title) == 0 {
-		return errors.New("title is required")
+		return newValidationError("title", "title is required")
 	}
 
 	if len(title) < 3 {
-		return errors.New("title is too short")
+		return newValidationError("title", "title is too short")
 	}
 
 	if len(title) > 100 {
-		return errors.New("title is too long")
+		return newValidationError("title", "title is too long")
 	}
 	return nil
 }
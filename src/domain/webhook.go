@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// WebhookEvents are the events partners can subscribe to.
+var WebhookEvents = []string{
+	PostCreated{}.EventName(),
+	PostUpdated{}.EventName(),
+	PostDeleted{}.EventName(),
+	CommentCreated{}.EventName(),
+	CommentUpdated{}.EventName(),
+	CommentDeleted{}.EventName(),
+}
+
+// Webhook is a subscription: every event it lists is posted to its URL, signed with its secret.
+type Webhook struct {
+	Id        uint      `gorm:"primarykey" json:"id"`
+	Url       string    `gorm:"size:2048;not null" json:"url"`
+	Secret    string    `gorm:"size:255;not null" json:"-"`
+	Events    []string  `gorm:"type:text;serializer:json;not null" json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewWebhook validates a subscription, and generates its secret when none is given.
+func NewWebhook(rawUrl string, secret string, events []string) (*Webhook, error) {
+	target, err := url.Parse(rawUrl)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, NewValidationError(errors.New("url must be an absolute http or https URL"))
+	}
+	if len(rawUrl) > 2048 {
+		return nil, NewValidationError(errors.New("url is too long"))
+	}
+
+	if secret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(key)
+	} else if len(secret) < 16 || len(secret) > 255 {
+		return nil, NewValidationError(errors.New("secret must be between 16 and 255 characters"))
+	}
+
+	if len(events) == 0 {
+		return nil, NewValidationError(errors.New("events are required"))
+	}
+	for _, event := range events {
+		if !slices.Contains(WebhookEvents, event) {
+			return nil, NewValidationError(fmt.Errorf("unknown event %q, must be one of %v", event, WebhookEvents))
+		}
+	}
+	events = slices.Clone(events)
+	slices.Sort(events)
+
+	return &Webhook{Url: rawUrl, Secret: secret, Events: slices.Compact(events)}, nil
+}
+
+// Subscribes tells whether the webhook wants the event.
+func (w Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// Delivery statuses. A pending delivery is retried until it succeeds or runs
+// out of attempts, and is then dead until it is redelivered by hand.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is one event to post to one webhook, and the outcome of its last attempt.
+type WebhookDelivery struct {
+	Id        uint   `gorm:"primarykey" json:"id"`
+	WebhookId uint   `gorm:"index;not null" json:"webhookId"`
+	EventId   string `gorm:"size:36;not null" json:"eventId"`
+	Event     string `gorm:"size:64;not null" json:"event"`
+	Payload   string `gorm:"type:text;not null" json:"-"`
+	Status    string `gorm:"size:16;not null" json:"status"`
+	Attempts  int    `gorm:"not null;default:0" json:"attempts"`
+	// NextAttemptAt is when a pending delivery is due, nil once it is not pending.
+	NextAttemptAt  *time.Time `gorm:"index" json:"nextAttemptAt"`
+	ResponseStatus int        `json:"responseStatus"`
+	LastError      string     `gorm:"type:text" json:"lastError"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// RetryPolicy spaces out the attempts of a delivery exponentially.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Delay is how long to wait after the given failed attempt, counting from 1.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxBackoff)
+}
+
+// Succeeded records an attempt the receiver accepted.
+func (d *WebhookDelivery) Succeeded(status int, at time.Time) {
+	d.Attempts++
+	d.Status = DeliverySucceeded
+	d.ResponseStatus = status
+	d.LastError = ""
+	d.DeliveredAt = &at
+	d.NextAttemptAt = nil
+}
+
+// Failed records a failed attempt, and schedules the next one or gives up
+// once the policy runs out of attempts. status is 0 when there was no response.
+func (d *WebhookDelivery) Failed(status int, reason string, at time.Time, policy RetryPolicy) {
+	d.Attempts++
+	d.ResponseStatus = status
+	d.LastError = reason
+
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		d.NextAttemptAt = nil
+		return
+	}
+
+	next := at.Add(policy.Delay(d.Attempts))
+	d.NextAttemptAt = &next
+}
+
+// Redeliver queues the delivery again with a fresh set of attempts.
+func (d *WebhookDelivery) Redeliver(at time.Time) {
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.LastError = ""
+	d.NextAttemptAt = &at
+}
+
+type WebhookRepository interface {
+	FindById(ctx context.Context, id int) (*Webhook, error)
+	// FindByEvent loads the webhooks subscribed to the event.
+	FindByEvent(ctx context.Context, event string) ([]Webhook, error)
+	Paginate(ctx context.Context, page int, perPage int) ([]Webhook, int64, error)
+	Create(ctx context.Context, webhook *Webhook) error
+	Delete(ctx context.Context, id int) error
+}
+
+type WebhookDeliveryRepository interface {
+	FindById(ctx context.Context, id int) (*WebhookDelivery, error)
+	Paginate(ctx context.Context, webhookId int, page int, perPage int) ([]WebhookDelivery, int64, error)
+	Create(ctx context.Context, deliveries []WebhookDelivery) error
+	// Update saves the delivery unless it changed since previous was read, as
+	// when the lease of a worker ran out and another one claimed it, or it was
+	// redelivered meanwhile: the change is lost then, with a conflict error.
+	Update(ctx context.Context, delivery *WebhookDelivery, previous WebhookDelivery) error
+	// Claim takes up to limit pending deliveries due at now, and pushes their
+	// next attempt back by lease, so that no other worker takes them meanwhile.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+}
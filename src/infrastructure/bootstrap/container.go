@@ -0,0 +1,300 @@
+package bootstrap
+
+import (
+	appJob "DDD/src/application/job"
+	appNotification "DDD/src/application/notification"
+	appPost "DDD/src/application/post"
+	appComment "DDD/src/application/post_comment"
+	appPostQuery "DDD/src/application/post_query"
+	appWebhook "DDD/src/application/webhook"
+	"DDD/src/domain"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/events"
+	"DDD/src/infrastructure/health"
+	"DDD/src/infrastructure/idempotency"
+	"DDD/src/infrastructure/jobs"
+	"DDD/src/infrastructure/logging"
+	"DDD/src/infrastructure/mail"
+	"DDD/src/infrastructure/metrics"
+	"DDD/src/infrastructure/notification"
+	"DDD/src/infrastructure/persistence/cache"
+	"DDD/src/infrastructure/persistence/gorm"
+	"DDD/src/infrastructure/persistence/gorm/migrations"
+	"DDD/src/infrastructure/persistence/gorm/repository"
+	"DDD/src/infrastructure/ratelimit"
+	"DDD/src/infrastructure/stream"
+	"DDD/src/infrastructure/tracing"
+	"DDD/src/infrastructure/webhook"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	gormio "gorm.io/gorm"
+	"log/slog"
+)
+
+// Container is the composition root shared by every entry point.
+type Container struct {
+	Config         *config.Config
+	Logger         *slog.Logger
+	DB             *gormio.DB
+	Redis          redis.UniversalClient
+	PostRepo       domain.PostRepository
+	CommentRepo    domain.PostCommentRepository
+	UnitOfWork     domain.UnitOfWork
+	Events         *events.Bus
+	Metrics        *metrics.Metrics
+	Health         *health.Checker
+	Limiter        *ratelimit.Limiter
+	Idempotency    idempotency.Store
+	PostService    *appPost.PostService
+	CommentService *appComment.PostCommentService
+	PostQueries    *appPostQuery.PostQueryService
+	WebhookService *appWebhook.WebhookService
+	Dispatcher     *webhook.Dispatcher
+	Streams        *stream.Hub
+	StreamRelay    *stream.RedisRelay
+	Mailer         mail.Transport
+	Notifications  *appNotification.NotificationService
+	Notifier       *notification.Notifier
+	JobService     *appJob.JobService
+	Jobs           *jobs.Worker
+	Workers        *Workers
+}
+
+func NewContainer(cfg *config.Config, logger *slog.Logger) (*Container, error) {
+	var redisClient redis.UniversalClient
+	if cfg.Redis.Url != "" {
+		options, err := redis.ParseURL(cfg.Redis.Url.Reveal())
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+
+		redisClient = redis.NewClient(options)
+	}
+
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	gormLogger := logging.NewGormLogger(logger, logging.Level(cfg.Log.Level), cfg.Database.SlowThreshold)
+
+	db, err := gorm.NewGormConnection(cfg.Database, gormLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, err
+	}
+
+	c := newContainer(cfg, logger, db, redisClient)
+
+	// Only entry points with a database send the notifications.
+	c.Mailer = mailer
+	c.Notifier = notification.NewNotifier(c.Notifications, c.Mailer, notification.Renderer{
+		AppName: cfg.App.Name,
+		From:    cfg.Mail.From,
+		BaseUrl: cfg.Notifications.BaseUrl,
+	}, logger, notification.Options{
+		Timeout:   cfg.Mail.Timeout,
+		BatchSize: 20,
+	})
+	jobs.Handle(c.Jobs, appNotification.DigestJob, c.Notifier.SendDigest)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Metrics.RegisterDB(sqlDB, "main"); err != nil {
+		return nil, err
+	}
+
+	c.Health.Add("database", health.Database(sqlDB))
+	c.Health.Add("migrations", health.Migrations(c.Migrator().Pending))
+	if redisClient != nil {
+		c.Health.Add("redis", health.Redis(redisClient))
+	}
+
+	return c, nil
+}
+
+// NewOfflineContainer wires the services without a database, for commands
+// that only inspect the application, such as printing the route table.
+func NewOfflineContainer(cfg *config.Config, logger *slog.Logger) *Container {
+	return newContainer(cfg, logger, nil, nil)
+}
+
+func newContainer(cfg *config.Config, logger *slog.Logger, db *gormio.DB, redisClient redis.UniversalClient) *Container {
+	newPostRepository := repository.NewPostRepository
+	if cfg.Database.PostStore == "events" {
+		newPostRepository = func(tx *gormio.DB) domain.PostRepository {
+			return repository.NewEventSourcedPostRepository(tx, cfg.Database.SnapshotEvery)
+		}
+	}
+
+	c := &Container{
+		Config:      cfg,
+		Logger:      logger,
+		DB:          db,
+		Redis:       redisClient,
+		PostRepo:    newPostRepository(db),
+		CommentRepo: repository.NewCommentRepository(db),
+		UnitOfWork:  repository.NewUnitOfWorkWithPosts(db, newPostRepository),
+		Events:      events.NewBus(),
+		Metrics:     metrics.New(),
+		Health:      health.NewChecker(cfg.Health.Timeout),
+		Workers:     NewWorkers(),
+	}
+
+	c.Events.Subscribe(c.Metrics.HandleEvent)
+
+	var limits ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "redis" && redisClient != nil {
+		limits = ratelimit.NewRedisStore(redisClient, cfg.App.Name+":ratelimit:")
+	}
+	c.Limiter = ratelimit.NewLimiter(limits, ratelimit.NewKeyFunc(cfg.RateLimit.Key))
+
+	c.Idempotency = idempotency.NewMemoryStore()
+	if cfg.Idempotency.Store == "redis" && redisClient != nil {
+		c.Idempotency = idempotency.NewRedisStore(redisClient, cfg.App.Name+":idempotency:")
+	}
+
+	if redisClient != nil {
+		cached := cache.NewPostRepository(c.PostRepo, redisClient, cache.Options{
+			Prefix:  cfg.App.Name + ":",
+			PostTTL: cfg.Cache.PostTTL,
+			PageTTL: cfg.Cache.PageTTL,
+			Pages:   cfg.Cache.Pages,
+		})
+		// The only invalidation: writes through the cache do not drop entries themselves.
+		c.Events.Subscribe(cached.HandleEvent)
+		c.PostRepo = cached
+	}
+
+	c.PostService = &appPost.PostService{
+		PostRepo:   c.PostRepo,
+		UnitOfWork: c.UnitOfWork,
+		Events:     c.Events,
+	}
+	c.CommentService = &appComment.PostCommentService{
+		PostCommentRepo: c.CommentRepo,
+		PostRepo:        c.PostRepo,
+		Events:          c.Events,
+	}
+
+	// Read model of the post listings, projected from the events of the services above
+	summaries := repository.NewPostSummaryRepository(db)
+	c.PostQueries = &appPostQuery.PostQueryService{SummaryRepo: summaries}
+	projection := &appPostQuery.Projection{SummaryRepo: summaries}
+	c.Events.Subscribe(projection.HandleEvent)
+
+	c.WebhookService = &appWebhook.WebhookService{
+		WebhookRepo:  repository.NewWebhookRepository(db),
+		DeliveryRepo: repository.NewWebhookDeliveryRepository(db),
+		Retry: domain.RetryPolicy{
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Backoff:     cfg.Webhooks.Backoff,
+			MaxBackoff:  cfg.Webhooks.MaxBackoff,
+		},
+	}
+	c.Events.Subscribe(c.WebhookService.HandleEvent)
+	c.Dispatcher = webhook.NewDispatcher(c.WebhookService, logger, webhook.Options{
+		Interval:             cfg.Webhooks.PollInterval,
+		Timeout:              cfg.Webhooks.Timeout,
+		Concurrency:          cfg.Webhooks.Concurrency,
+		AllowPrivateNetworks: cfg.Webhooks.AllowPrivateNetworks,
+		UserAgent:            cfg.App.Name + "-webhooks",
+	})
+
+	c.Streams = stream.NewHub(stream.Options{
+		Buffer:         cfg.Stream.Buffer,
+		History:        cfg.Stream.History,
+		Retention:      cfg.Stream.Retention,
+		MaxSubscribers: cfg.Stream.MaxSubscribers,
+	})
+	if cfg.Stream.Relay == "redis" && redisClient != nil {
+		c.StreamRelay = stream.NewRedisRelay(c.Streams, redisClient, cfg.App.Name+":stream:")
+		c.Events.Subscribe(c.StreamRelay.HandleEvent)
+	} else {
+		c.Events.Subscribe(c.Streams.HandleEvent)
+	}
+
+	c.JobService = &appJob.JobService{
+		JobRepo: repository.NewJobRepository(db),
+		Retry: domain.RetryPolicy{
+			MaxAttempts: cfg.Jobs.MaxAttempts,
+			Backoff:     cfg.Jobs.Backoff,
+			MaxBackoff:  cfg.Jobs.MaxBackoff,
+		},
+	}
+	// Kinds of jobs register their handlers on the worker with jobs.Handle.
+	c.Jobs = jobs.NewWorker(c.JobService, logger, jobs.Options{
+		Queues:       cfg.Jobs.Queues,
+		PollInterval: cfg.Jobs.PollInterval,
+		Lease:        cfg.Jobs.Lease,
+	})
+
+	c.Notifications = &appNotification.NotificationService{
+		NotificationRepo: repository.NewNotificationRepository(db),
+		PreferenceRepo:   repository.NewNotificationPreferenceRepository(db),
+		PostRepo:         c.PostRepo,
+		CommentRepo:      c.CommentRepo,
+		Retry: domain.RetryPolicy{
+			MaxAttempts: cfg.Notifications.MaxAttempts,
+			Backoff:     cfg.Notifications.Backoff,
+			MaxBackoff:  cfg.Notifications.MaxBackoff,
+		},
+		Delay: cfg.Notifications.Delay,
+	}
+	if cfg.Notifications.Enabled {
+		c.Notifications.Jobs = c.JobService
+	}
+	c.Events.Subscribe(c.Notifications.HandleEvent)
+
+	return c
+}
+
+func newMailer(cfg config.Mail) (mail.Transport, error) {
+	switch cfg.Transport {
+	case "file":
+		return mail.NewFileTransport(cfg.Dir), nil
+	case "memory":
+		return mail.NewMemoryTransport(), nil
+	default:
+		return mail.NewSMTPTransport(cfg.SmtpUrl.Reveal(), cfg.Timeout)
+	}
+}
+
+func (c *Container) Migrator() *migrations.GormMigrator {
+	return migrations.NewGormMigrator(c.DB)
+}
+
+// Close ends the streams, stops the workers and releases the database and Redis connections.
+func (c *Container) Close(ctx context.Context) error {
+	c.Streams.Close()
+
+	var errs []error
+	if err := c.Workers.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop workers: %w", err))
+	}
+
+	if c.Redis != nil {
+		if err := c.Redis.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close redis: %w", err))
+		}
+	}
+
+	if c.DB != nil {
+		sqlDB, err := c.DB.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
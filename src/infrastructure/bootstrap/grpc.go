@@ -0,0 +1,11 @@
+package bootstrap
+
+import (
+	"DDD/src/infrastructure/grpc"
+)
+
+func NewGrpcServer(c *Container) *grpc.Server {
+	return grpc.NewServer(c.PostService, c.CommentService, c.Logger, grpc.Options{
+		Reflection: c.Config.Grpc.Reflection,
+	})
+}
@@ -0,0 +1,125 @@
+package bootstrap
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/graphql"
+	"DDD/src/infrastructure/health"
+	"DDD/src/infrastructure/http"
+	"DDD/src/infrastructure/http/v1/comment"
+	"DDD/src/infrastructure/http/v1/job"
+	"DDD/src/infrastructure/http/v1/notification"
+	"DDD/src/infrastructure/http/v1/post"
+	"DDD/src/infrastructure/http/v1/webhook"
+	"DDD/src/infrastructure/http/v2/comment"
+	"DDD/src/infrastructure/http/v2/post"
+	"DDD/src/infrastructure/idempotency"
+	"DDD/src/infrastructure/ratelimit"
+	"DDD/src/infrastructure/tracing"
+	"github.com/gofiber/contrib/swagger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/gofiber/fiber/v2/middleware/monitor"
+)
+
+func NewHttpApp(c *Container) *fiber.App {
+	app := fiber.New(fiber.Config{
+		AppName:      c.Config.App.Name,
+		ReadTimeout:  c.Config.Server.ReadTimeout,
+		WriteTimeout: c.Config.Server.WriteTimeout,
+		IdleTimeout:  c.Config.Server.IdleTimeout,
+		ErrorHandler: http.ErrorHandler,
+		// The client IP, which rate limits and idempotency keys are scoped by,
+		// is only taken from the proxy header of requests from trusted proxies.
+		ProxyHeader:             c.Config.Server.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          c.Config.Server.TrustedProxies,
+		EnableIPValidation:      true,
+	})
+
+	// Request id, tracing and access log
+	app.Use(http.RequestId())
+	app.Use(tracing.Middleware())
+	app.Use(http.AccessLog(c.Logger))
+
+	// Metrics
+	if c.Config.Metrics.Enabled {
+		app.Use(c.Metrics.Middleware())
+		app.Get(c.Config.Metrics.Path, c.Metrics.Handler(c.Config.Metrics.Token))
+	}
+
+	// Compress, with the ETags of compressed responses made weak
+	app.Use(http.EncodedETags())
+	app.Use(compress.New(compress.Config{
+		Next: http.Streaming,
+	}))
+
+	// E-tag of the routes that do not derive validators from revisions, nor stream
+	app.Use(etag.New(etag.Config{
+		Next: http.Streaming,
+		Weak: true,
+	}))
+
+	// Cache-Control
+	app.Use(http.CacheControl(map[string]string{
+		"/api/v1/posts/:id":              c.Config.HttpCache.Post,
+		"/api/v1/posts/":                 c.Config.HttpCache.Posts,
+		"/api/v1/posts/:postId/comments": c.Config.HttpCache.Comments,
+	}))
+
+	// Rate limits, the strict comment limit before the broad groups
+	app.Use("/api/v1/posts/:postId/comments", c.Limiter.Limit("comments", c.Config.RateLimit.Comments, fiber.MethodPost))
+	app.Use("/api/v2/comments", c.Limiter.Limit("comments", c.Config.RateLimit.Comments, fiber.MethodPost))
+	app.Use("/api", c.Limiter.ByMethod(c.Config.RateLimit.Reads, c.Config.RateLimit.Writes))
+	app.Use("/graphql", c.Limiter.Limit("graphql", c.Config.RateLimit.GraphQL))
+
+	// Idempotency-Key for POST requests, scoped by the client the way rate limits count them
+	app.Use("/api", idempotency.Middleware(c.Idempotency, ratelimit.NewKeyFunc(c.Config.RateLimit.Key), c.Config.Idempotency.TTL, c.Config.Idempotency.LockTimeout))
+
+	// Liveness and readiness probes
+	app.Get("/livez", health.Livez())
+	app.Get("/readyz", c.Health.Readyz())
+
+	// V1: Routes
+	httpPostV1.SetupRoutes(app, c.PostService, c.PostQueries)
+	httpCommentV1.SetupRoutes(app, c.CommentService, c.PostService, c.Streams, c.Config.Stream.Heartbeat)
+	httpWebhookV1.SetupRoutes(app, c.WebhookService, c.Config.Webhooks.AdminToken)
+	httpNotificationV1.SetupRoutes(app, c.Notifications)
+	httpJobV1.SetupRoutes(app, c.JobService, c.Config.Jobs.AdminToken)
+
+	// V2: JSON:API routes
+	httpPostV2.SetupRoutes(app, c.PostService, c.CommentService)
+	httpCommentV2.SetupRoutes(app, c.CommentService, c.PostService)
+
+	// GraphQL over the same services
+	if c.Config.GraphQL.Enabled {
+		server := graphql.NewServer(c.PostService, c.CommentService, graphql.Limits{
+			MaxDepth:      c.Config.GraphQL.MaxDepth,
+			MaxComplexity: c.Config.GraphQL.MaxComplexity,
+		})
+		app.Get("/graphql", server.Handler())
+		app.Post("/graphql", server.Handler())
+	}
+
+	// Init dev tools
+	initDevTools(app, c.Config)
+
+	return app
+}
+
+func initDevTools(app *fiber.App, cfg *config.Config) {
+	if cfg.Features.Docs {
+		// Register Swagger route
+		app.Get("/docs/*", swagger.New(swagger.Config{
+			BasePath: "/",
+			FilePath: "./docs/swagger.json",
+			Path:     "/docs",
+			Title:    cfg.App.Name,
+		}))
+	}
+
+	if cfg.Features.Monitor {
+		// Runtime dashboard
+		app.Get("/monitor", monitor.New())
+	}
+}
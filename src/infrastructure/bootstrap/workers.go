@@ -0,0 +1,46 @@
+package bootstrap
+
+import (
+	"context"
+	"sync"
+)
+
+// Workers tracks background goroutines so that shutdown can wait for them.
+type Workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorkers() *Workers {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Workers{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in the background. fn must return once ctx is cancelled.
+func (w *Workers) Go(fn func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(w.ctx)
+	}()
+}
+
+// Stop cancels the workers and waits for them until ctx is done.
+func (w *Workers) Stop(ctx context.Context) error {
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
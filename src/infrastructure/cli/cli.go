@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/logging"
+	"DDD/src/infrastructure/tracing"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, env *Env, args []string) error
+}
+
+// commands is filled in init because the commands print their help from it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "serve", usage: "serve", summary: "Start the HTTP server (default)", run: serve},
+		{name: "worker", usage: "worker [-queues default=4,mail=2]", summary: "Run the background jobs without serving requests", run: worker},
+		{name: "migrate", usage: "migrate up|down|status|redo [-steps N]", summary: "Apply, roll back or inspect database migrations", run: migrate},
+		{name: "rebuild", usage: "rebuild", summary: "Regenerate the post summaries read model from the posts and comments", run: rebuild},
+		{name: "seed", usage: "seed [-posts N] [-comments M]", summary: "Create N posts with M comments each", run: seed},
+		{name: "export", usage: "export [-file path]", summary: "Write all posts and comments as JSON", run: export},
+		{name: "import", usage: "import [-file path]", summary: "Create posts and comments from an export", run: importPosts},
+		{name: "routes", usage: "routes", summary: "Print the HTTP route table", run: routes},
+		{name: "config", usage: "config", summary: "Print the effective configuration, secrets redacted", run: printConfig},
+	}
+}
+
+// Env is shared by all commands: configuration, output streams and the lazily built container.
+type Env struct {
+	Config *config.Config
+	Logger *slog.Logger
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	container   *bootstrap.Container
+	stopTracing func(context.Context) error
+}
+
+// close releases the container, if a command created one, and flushes traces.
+func (e *Env) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if e.container != nil {
+		errs = append(errs, e.container.Close(ctx))
+	}
+	if e.stopTracing != nil {
+		errs = append(errs, e.stopTracing(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Container connects to the database on first use.
+func (e *Env) Container() (*bootstrap.Container, error) {
+	if e.container != nil {
+		return e.container, nil
+	}
+
+	container, err := bootstrap.NewContainer(e.Config, e.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	e.container = container
+	return container, nil
+}
+
+// Run executes the command line and returns the process exit code.
+func Run(ctx context.Context, args []string) int {
+	return RunWith(ctx, args, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// RunWith executes the command line on the given streams instead of those of the process.
+func RunWith(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	env := &Env{Stdout: stdout, Stderr: stderr, Stdin: stdin}
+
+	global := flag.NewFlagSet(program(), flag.ContinueOnError)
+	global.SetOutput(env.Stderr)
+	configFile := global.String("config", "config.yaml", "YAML configuration file, optional unless set")
+	envFile := global.String("env-file", ".env", "file with environment variables, optional unless set")
+	dbConnStr := global.String("db", "", "database connection string, overrides DB_CONNECTION")
+	global.Usage = func() { usage(global) }
+
+	if err := parse(global, args); err != nil {
+		return exitCode(err)
+	}
+
+	name, rest := "serve", global.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+
+	if name == "help" {
+		usage(global)
+		return 0
+	}
+
+	explicit := make(map[string]bool)
+	global.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	cfg, err := config.Load(config.Sources{
+		YamlFile:         *configFile,
+		YamlFileRequired: explicit["config"],
+		EnvFile:          *envFile,
+		EnvFileRequired:  explicit["env-file"],
+		Override: func(cfg *config.Config) {
+			if *dbConnStr != "" {
+				cfg.Database.Connection = config.Secret(*dbConnStr)
+			}
+		},
+	})
+	if err != nil {
+		fmt.Fprintln(env.Stderr, err)
+		return 1
+	}
+
+	env.Config = cfg
+	env.Logger = logging.New(cfg.Log, env.Stdout)
+	slog.SetDefault(env.Logger)
+
+	env.stopTracing, err = tracing.Setup(ctx, cfg.Tracing, cfg.App.Name, env.Stdout)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, err)
+		return 1
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := errors.Join(cmd.run(ctx, env, rest), env.close())
+			if err != nil {
+				if exitCode(err) == 1 {
+					fmt.Fprintf(env.Stderr, "%s: %v\n", name, err)
+				}
+				return exitCode(err)
+			}
+
+			return 0
+		}
+	}
+
+	fmt.Fprintf(env.Stderr, "unknown command %q\n\n", name)
+	usage(global)
+	return 2
+}
+
+// newFlagSet creates the flag set of a command with consistent help output.
+func newFlagSet(env *Env, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	flags.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(env.Stderr, "Usage: %s %s\n\n%s\n", program(), cmd.usage, cmd.summary)
+			}
+		}
+
+		var hasFlags bool
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(env.Stderr, "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+// parse parses flags, reporting invalid ones as usage errors.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError(err.Error())
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// badUsage prints a usage error followed by the help of the command.
+func badUsage(flags *flag.FlagSet, format string, args ...any) error {
+	fmt.Fprintf(flags.Output(), format+"\n", args...)
+	flags.Usage()
+
+	return usageError(fmt.Sprintf(format, args...))
+}
+
+func usage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintf(out, "Usage: %s [global flags] <command> [flags]\n\nCommands:\n", program())
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	global.PrintDefaults()
+	fmt.Fprintf(out, "\nRun '%s <command> -h' for the flags of a command.\n", program())
+}
+
+func program() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		return 2
+	}
+
+	return 1
+}
+
+// usageError marks invalid command line input, already reported to the user.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
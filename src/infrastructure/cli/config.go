@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+)
+
+func printConfig(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "config")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprint(env.Stdout, env.Config)
+
+	return err
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func migrate(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "migrate")
+	steps := flags.Int("steps", 1, "number of migrations to roll back with down")
+
+	if len(args) == 0 {
+		return badUsage(flags, "missing migrate action")
+	}
+
+	action := args[0]
+	if err := parse(flags, args[1:]); err != nil {
+		return err
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+	migrator := c.Migrator()
+
+	switch action {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		if *steps < 1 {
+			return badUsage(flags, "steps must be at least 1")
+		}
+
+		return migrator.Down(ctx, *steps)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		table := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(table, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return table.Flush()
+	default:
+		return badUsage(flags, "unknown migrate action %q", action)
+	}
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// rebuild regenerates the read models from the write tables, for when the
+// projections missed events, such as after a failed write to the read model.
+func rebuild(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "rebuild")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	summarized, err := c.PostQueries.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.Stdout, "Summarized %d posts in %s\n", summarized, time.Since(started).Round(time.Millisecond))
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"text/tabwriter"
+)
+
+func routes(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "routes")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	printRoutes(env.Stdout, bootstrap.NewHttpApp(bootstrap.NewOfflineContainer(env.Config, env.Logger)))
+
+	return nil
+}
+
+func printRoutes(w io.Writer, app *fiber.App) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "METHOD\tPATH")
+	for _, route := range app.GetRoutes(true) {
+		fmt.Fprintf(table, "%s\t%s\n", route.Method, route.Path)
+	}
+	table.Flush()
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"fmt"
+	"time"
+)
+
+func seed(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "seed")
+	posts := flags.Int("posts", 10, "number of posts to create")
+	comments := flags.Int("comments", 3, "number of comments per post")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *posts < 0 || *comments < 0 {
+		return badUsage(flags, "posts and comments must not be negative")
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	// Titles must be unique, so every run gets its own suffix.
+	run := time.Now().Unix()
+
+	for i := 1; i <= *posts; i++ {
+		title, err := value_object.NewTitle(fmt.Sprintf("Seed post %d-%d", run, i))
+		if err != nil {
+			return err
+		}
+
+		content, err := value_object.NewContent(fmt.Sprintf("Seeded content of post %d.", i))
+		if err != nil {
+			return err
+		}
+
+		post, err := c.PostService.CreatePost(ctx, domain.Post{Title: title, Content: content})
+		if err != nil {
+			return err
+		}
+
+		for j := 1; j <= *comments; j++ {
+			text, err := value_object.NewText(fmt.Sprintf("Seeded comment %d", j))
+			if err != nil {
+				return err
+			}
+
+			if _, err := c.CommentService.CreatePostComment(ctx, domain.PostComment{PostId: post.Id, Text: text}); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(env.Stdout, "Created %d posts with %d comments each\n", *posts, *comments)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/grpc"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+)
+
+// serve runs the HTTP server, and the gRPC server on its own port when enabled, until
+// ctx is cancelled, usually by SIGINT or SIGTERM, then stops accepting connections
+// and drains in-flight requests. Background
+// workers and the database are released by the caller through Env.close.
+func serve(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "serve")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	// Migrations, disable with DB_MIGRATE_ON_START=false when they run as a separate deploy step
+	if env.Config.Database.MigrateOnStart {
+		if err := c.Migrator().Up(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Webhook deliveries, stopped with the other workers
+	if env.Config.Webhooks.Enabled {
+		c.Workers.Go(c.Dispatcher.Run)
+	}
+
+	// Background jobs, comment notification emails among them, disable with JOBS_ENABLED=false to run them with the worker command instead
+	if env.Config.Jobs.Enabled {
+		c.Workers.Go(c.Jobs.Run)
+	}
+
+	// Stream events of every replica, with STREAM_RELAY=redis
+	if c.StreamRelay != nil {
+		c.Workers.Go(c.StreamRelay.Run)
+	}
+
+	app := bootstrap.NewHttpApp(c)
+
+	if env.Config.IsDevelopment() {
+		printRoutes(env.Stdout, app)
+	}
+
+	// Both ports are bound before either server starts, so that a port in use
+	// fails the command without leaving the other server running.
+	httpListener, err := net.Listen("tcp", ":"+strconv.Itoa(env.Config.Server.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for HTTP: %w", err)
+	}
+
+	var grpcListener net.Listener
+	if env.Config.Grpc.Enabled {
+		grpcListener, err = net.Listen("tcp", ":"+strconv.Itoa(env.Config.Grpc.Port))
+		if err != nil {
+			httpListener.Close()
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listener(httpListener)
+	}()
+
+	var grpcServer *grpc.Server
+	grpcErr := make(chan error, 1)
+	if grpcListener != nil {
+		grpcServer = bootstrap.NewGrpcServer(c)
+		go func() {
+			grpcErr <- grpcServer.Serve(grpcListener)
+		}()
+		env.Logger.Info("grpc server started", slog.Int("port", env.Config.Grpc.Port))
+	}
+
+	// A server that stops by itself takes the other one down with it.
+	var failed error
+	select {
+	case err := <-listenErr:
+		failed = fmt.Errorf("http server failed: %w", err)
+		listenErr <- nil // read again once the servers are stopped
+	case err := <-grpcErr:
+		failed = fmt.Errorf("grpc server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Report not ready first, and keep serving for the delay, so that load
+	// balancers stop routing new requests here before the listener closes.
+	c.Health.Shutdown()
+	if grpcServer != nil {
+		grpcServer.Shutdown()
+	}
+	if delay := env.Config.Server.ShutdownDelay; delay > 0 && failed == nil {
+		env.Logger.Info("shutting down, reporting not ready", slog.Duration("delay", delay))
+		time.Sleep(delay)
+	}
+
+	// Streams never end by themselves, and would hold draining to its timeout.
+	// Clients reconnect to another instance and resume with Last-Event-ID.
+	c.Streams.Close()
+
+	timeout := env.Config.Server.ShutdownTimeout
+	env.Logger.Info("shutting down, draining requests", slog.Duration("timeout", timeout))
+	started := time.Now()
+
+	drained, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := app.ShutdownWithContext(drained); err != nil {
+		return errors.Join(failed, fmt.Errorf("failed to drain requests: %w", err))
+	}
+	if grpcServer != nil {
+		if err := grpcServer.Stop(drained); err != nil {
+			return errors.Join(failed, fmt.Errorf("failed to drain calls: %w", err))
+		}
+	}
+
+	if err := <-listenErr; err != nil && !errors.Is(err, context.Canceled) {
+		return errors.Join(failed, err)
+	}
+
+	env.Logger.Info("servers stopped", slog.Duration("elapsed", time.Since(started)))
+
+	return failed
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+const exportPageSize = 100
+
+type exportDocument struct {
+	Posts []exportPost `json:"posts"`
+}
+
+type exportPost struct {
+	Title     string          `json:"title"`
+	Content   string          `json:"content"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Comments  []exportComment `json:"comments"`
+}
+
+type exportComment struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func export(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "export")
+	file := flags.String("file", "-", "file to write, - for stdout")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	document := exportDocument{Posts: []exportPost{}}
+	for page := 1; ; page++ {
+		posts, total, err := c.PostRepo.Paginate(ctx, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			comments, err := c.CommentRepo.FindByPostId(ctx, int(post.Id))
+			if err != nil {
+				return err
+			}
+
+			exported := exportPost{
+				Title:     post.Title.String(),
+				Content:   post.Content.String(),
+				CreatedAt: post.CreatedAt,
+				Comments:  make([]exportComment, 0, len(comments)),
+			}
+			for _, comment := range comments {
+				exported.Comments = append(exported.Comments, exportComment{
+					Text:      comment.Text.String(),
+					CreatedAt: comment.CreatedAt,
+				})
+			}
+
+			document.Posts = append(document.Posts, exported)
+		}
+
+		if int64(page*exportPageSize) >= total {
+			break
+		}
+	}
+
+	// Posts are paginated newest first, the export lists them in creation order.
+	slices.Reverse(document.Posts)
+
+	out := env.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
+
+func importPosts(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "import")
+	file := flags.String("file", "-", "file to read, - for stdin")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	in := env.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	document, err := readExport(in)
+	if err != nil {
+		return err
+	}
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	// Either the whole export is imported or nothing is. What it created is
+	// announced once committed, as the services do, so that the read models,
+	// caches and webhooks follow.
+	var events []domain.Event
+	comments := 0
+	err = c.UnitOfWork.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		for i, exported := range document.Posts {
+			post, err := importedPost(exported)
+			if err != nil {
+				return fmt.Errorf("post %d: %w", i+1, err)
+			}
+
+			if err := repos.Posts().Create(ctx, post); err != nil {
+				return fmt.Errorf("post %d: %w", i+1, err)
+			}
+			events = append(events, domain.PostCreated{Post: *post})
+
+			for _, exportedComment := range exported.Comments {
+				text, err := value_object.NewText(exportedComment.Text)
+				if err != nil {
+					return fmt.Errorf("post %d: %w", i+1, err)
+				}
+
+				comment := &domain.PostComment{PostId: post.Id, Text: text, CreatedAt: exportedComment.CreatedAt}
+				if err := repos.Comments().Create(ctx, comment); err != nil {
+					return fmt.Errorf("post %d: %w", i+1, err)
+				}
+				events = append(events, domain.CommentCreated{Comment: *comment})
+				comments++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Events.Publish(ctx, events...)
+
+	fmt.Fprintf(env.Stdout, "Imported %d posts and %d comments\n", len(document.Posts), comments)
+
+	return nil
+}
+
+func readExport(in io.Reader) (*exportDocument, error) {
+	var document exportDocument
+	if err := json.NewDecoder(in).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+
+	return &document, nil
+}
+
+func importedPost(exported exportPost) (*domain.Post, error) {
+	title, err := value_object.NewTitle(exported.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := value_object.NewContent(exported.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Post{Title: title, Content: content, CreatedAt: exported.CreatedAt}, nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+)
+
+// worker runs the background workers without serving requests, until ctx is
+// cancelled, usually by SIGINT or SIGTERM. The running jobs are waited for by
+// the caller through Env.close, for the shutdown timeout at most.
+func worker(ctx context.Context, env *Env, args []string) error {
+	flags := newFlagSet(env, "worker")
+	queues := env.Config.Jobs.Queues
+	flags.TextVar(&queues, "queues", queues, "queues to work on, with how many of their jobs run at once")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if len(queues) == 0 {
+		return badUsage(flags, "at least one queue is required")
+	}
+	env.Config.Jobs.Queues = queues
+
+	c, err := env.Container()
+	if err != nil {
+		return err
+	}
+
+	c.Workers.Go(c.Jobs.Run)
+
+	// Webhook deliveries too, unless they are left to the servers
+	if env.Config.Webhooks.Enabled {
+		c.Workers.Go(c.Dispatcher.Run)
+	}
+
+	env.Logger.Info("worker started", slog.String("queues", queues.String()))
+	<-ctx.Done()
+	env.Logger.Info("shutting down, waiting for running jobs", slog.Duration("timeout", env.Config.Server.ShutdownTimeout))
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import "strings"
+
+// List is a list of values, written as "a,b" in the environment and as a
+// sequence or the same string in YAML.
+type List []string
+
+func (l List) String() string {
+	return strings.Join(l, ",")
+}
+
+func (l List) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *List) UnmarshalText(text []byte) error {
+	list := List{}
+	for _, value := range strings.Split(string(text), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+
+	*l = list
+	return nil
+}
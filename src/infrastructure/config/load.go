@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Sources lists where configuration is read from. Empty paths are skipped,
+// a missing file is only an error when Required is set for it.
+type Sources struct {
+	YamlFile         string
+	YamlFileRequired bool
+	EnvFile          string
+	EnvFileRequired  bool
+	// Override is applied last, for command line flags.
+	Override func(cfg *Config)
+}
+
+// Load reads the configuration from defaults, the YAML file, the env file
+// and the environment, each overriding the previous one, and validates it.
+// Variables from the env file do not leak into the process environment.
+func Load(sources Sources) (*Config, error) {
+	cfg := Default()
+
+	if err := loadYaml(&cfg, sources.YamlFile, sources.YamlFileRequired); err != nil {
+		return nil, err
+	}
+
+	dotenv, err := readEnvFile(sources.EnvFile, sources.EnvFileRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+
+		value, ok := dotenv[key]
+		return value, ok
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), lookup); err != nil {
+		return nil, err
+	}
+
+	if sources.Override != nil {
+		sources.Override(&cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func loadYaml(cfg *Config, path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func readEnvFile(path string, required bool) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	values, err := godotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return values, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets every field with an env tag whose variable is defined.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		tag := v.Type().Field(i).Tag.Get("env")
+
+		_, isText := field.Addr().Interface().(encoding.TextUnmarshaler)
+		if field.Kind() == reflect.Struct && !isText {
+			if err := applyEnv(field, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(tag)
+		if tag == "" || !ok {
+			continue
+		}
+
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if text, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return text.UnmarshalText([]byte(value))
+	}
+
+	switch {
+	case field.Type() == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(number))
+	case field.Kind() == reflect.Float64:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(number)
+	case field.Kind() == reflect.Bool:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(flag)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
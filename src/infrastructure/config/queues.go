@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Queues maps job queues to how many of their jobs run at once, written as "default=4,mail=2".
+type Queues map[string]int
+
+func ParseQueues(value string) (Queues, error) {
+	queues := make(Queues)
+	if strings.TrimSpace(value) == "" {
+		return queues, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		name, concurrency, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("queue %q must look like default=4", entry)
+		}
+
+		count, err := strconv.Atoi(concurrency)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("queue %q must end with a concurrency of at least 1", entry)
+		}
+		if _, ok := queues[name]; ok {
+			return nil, fmt.Errorf("queue %q is listed twice", name)
+		}
+
+		queues[name] = count
+	}
+
+	return queues, nil
+}
+
+func (q Queues) String() string {
+	entries := make([]string, 0, len(q))
+	for _, name := range slices.Sorted(maps.Keys(q)) {
+		entries = append(entries, name+"="+strconv.Itoa(q[name]))
+	}
+
+	return strings.Join(entries, ",")
+}
+
+func (q Queues) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+func (q *Queues) UnmarshalText(text []byte) error {
+	queues, err := ParseQueues(string(text))
+	if err != nil {
+		return err
+	}
+
+	*q = queues
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a number of requests per period, written as "60/1m". The zero Rate means unlimited.
+type Rate struct {
+	Requests int
+	Period   time.Duration
+}
+
+func ParseRate(value string) (Rate, error) {
+	if value == "" || value == "0" {
+		return Rate{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q must look like 60/1m", value)
+	}
+
+	count, err := strconv.Atoi(requests)
+	if err != nil || count < 0 {
+		return Rate{}, fmt.Errorf("rate %q must start with a number of requests", value)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return Rate{}, fmt.Errorf("rate %q must end with a positive period", value)
+	}
+
+	return Rate{Requests: count, Period: duration}, nil
+}
+
+func (r Rate) Unlimited() bool {
+	return r.Requests == 0
+}
+
+func (r Rate) String() string {
+	if r.Unlimited() {
+		return "0"
+	}
+
+	return strconv.Itoa(r.Requests) + "/" + r.Period.String()
+}
+
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalText(text []byte) error {
+	rate, err := ParseRate(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = rate
+	return nil
+}
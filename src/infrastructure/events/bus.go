@@ -0,0 +1,42 @@
+package events
+
+import (
+	"DDD/src/domain"
+	"context"
+	"sync"
+)
+
+// Handler reacts to a published event. It runs on the publishing goroutine,
+// so anything slow belongs in a worker of its own. Events are published once
+// their change is committed, so a handler has no one to return an error to,
+// and logs its failures itself.
+type Handler func(ctx context.Context, event domain.Event)
+
+// Bus is an in-process domain.EventPublisher that fans events out to its subscribers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, events ...domain.Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			handler(ctx, event)
+		}
+	}
+}
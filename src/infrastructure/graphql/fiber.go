@@ -0,0 +1,38 @@
+package graphql
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves GraphQL over HTTP: POST with a JSON body for every operation,
+// and GET with query, operationName and variables parameters for queries only.
+func (s *Server) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req Request
+
+		switch c.Method() {
+		case fiber.MethodPost:
+			if err := json.Unmarshal(c.Body(), &req); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid GraphQL request: "+err.Error())
+			}
+		case fiber.MethodGet:
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "invalid GraphQL variables: "+err.Error())
+				}
+			}
+		default:
+			return fiber.ErrMethodNotAllowed
+		}
+
+		if req.Query == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "the query is required")
+		}
+
+		result, status := s.execute(c.UserContext(), req, c.Method() == fiber.MethodGet)
+		return c.Status(status).JSON(result)
+	}
+}
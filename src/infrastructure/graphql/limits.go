@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+	"strings"
+)
+
+// Limits bound the queries a client may send, so that a single request cannot
+// walk the post-comment graph arbitrarily deep or ask for huge pages at every level.
+type Limits struct {
+	// MaxDepth is how deeply fields may be nested.
+	MaxDepth int
+	// MaxComplexity bounds the cost of a query: every field costs 1, and the
+	// fields below a paginated field count perPage times.
+	MaxComplexity int
+}
+
+// cost measures the depth and complexity of an operation. Introspection
+// fields are free, so that tools can read the schema whatever the limits.
+type cost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// check rejects an operation of a validated document when it exceeds the limits.
+func (l Limits) check(doc *ast.Document, operation *ast.OperationDefinition, variables map[string]any) error {
+	c := cost{fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	depth, complexity := c.selectionSet(operation.SelectionSet)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, l.MaxComplexity)
+	}
+
+	return nil
+}
+
+func (c cost) selectionSet(set *ast.SelectionSet) (depth int, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, n int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+
+			d, n = c.selectionSet(selection.SelectionSet)
+			d, n = d+1, 1+n*c.multiplier(selection)
+		case *ast.InlineFragment:
+			d, n = c.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			// Validation has already rejected unknown and cyclic fragments.
+			d, n = c.selectionSet(c.fragments[selection.Name.Value].SelectionSet)
+		}
+
+		depth, complexity = max(depth, d), complexity+n
+	}
+
+	return depth, complexity
+}
+
+// multiplier is the page size a field asks for, or 1 for fields that are not paginated.
+func (c cost) multiplier(field *ast.Field) int {
+	if field.SelectionSet == nil {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "perPage" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if perPage, err := strconv.Atoi(value.Value); err == nil {
+				return max(perPage, 1)
+			}
+		case *ast.Variable:
+			switch perPage := c.variables[value.Name.Value].(type) {
+			case float64:
+				return max(int(perPage), 1)
+			case int:
+				return max(perPage, 1)
+			case nil:
+				return defaultPerPage
+			}
+		}
+
+		return MaxPerPage
+	}
+
+	if paginated[field.Name.Value] {
+		return defaultPerPage
+	}
+
+	return 1
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"context"
+	"slices"
+	"sync"
+)
+
+type loadersKey struct{}
+
+// loaders batch and cache the lookups of a single request.
+type loaders struct {
+	comments *commentLoader
+	posts    *postLoader
+}
+
+func withLoaders(ctx context.Context, posts *applicationPost.PostService, comments *applicationPostComment.PostCommentService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		comments: &commentLoader{
+			service: comments,
+			pending: make(map[commentPage][]uint),
+			loaded:  make(map[commentKey]*applicationPostComment.PaginatedComments),
+		},
+		posts: &postLoader{service: posts, loaded: make(map[uint]*domain.Post)},
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// commentLoader collects the posts whose comments are asked for and loads one
+// page of the comments of all of them with one query when the first result is
+// needed. The executor resolves the fields of a list breadth first, so the
+// comments of every post of a page are requested before any of them is read.
+// Posts whose comments are asked for with other page arguments are batched
+// apart.
+type commentLoader struct {
+	service *applicationPostComment.PostCommentService
+
+	mu      sync.Mutex
+	pending map[commentPage][]uint
+	loaded  map[commentKey]*applicationPostComment.PaginatedComments
+}
+
+type commentPage struct {
+	page    int
+	perPage int
+}
+
+type commentKey struct {
+	postId uint
+	commentPage
+}
+
+// Load returns a thunk with a page of the comments of a post, newest first.
+func (l *commentLoader) Load(ctx context.Context, postId uint, page int, perPage int) func() (*applicationPostComment.PaginatedComments, error) {
+	window := commentPage{page: page, perPage: perPage}
+	key := commentKey{postId: postId, commentPage: window}
+
+	l.mu.Lock()
+	if _, ok := l.loaded[key]; !ok && !slices.Contains(l.pending[window], postId) {
+		l.pending[window] = append(l.pending[window], postId)
+	}
+	l.mu.Unlock()
+
+	return func() (*applicationPostComment.PaginatedComments, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if comments, ok := l.loaded[key]; ok {
+			return comments, nil
+		}
+
+		batch := l.pending[window]
+		delete(l.pending, window)
+
+		byPost, err := l.service.FindPaginatedCommentsByPostIds(ctx, batch, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range batch {
+			l.loaded[commentKey{postId: id, commentPage: window}] = byPost[id]
+		}
+
+		return l.loaded[key], nil
+	}
+}
+
+// postLoader caches posts by id, so that the comments of a post, or of a few
+// posts, do not load the same post again and again.
+type postLoader struct {
+	service *applicationPost.PostService
+
+	mu     sync.Mutex
+	loaded map[uint]*domain.Post
+}
+
+func (l *postLoader) Load(ctx context.Context, id uint) (*domain.Post, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if post, ok := l.loaded[id]; ok {
+		return post, nil
+	}
+
+	post, err := l.service.FindById(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+
+	l.loaded[id] = post
+	return post, nil
+}
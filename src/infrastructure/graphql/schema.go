@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"errors"
+	"fmt"
+	gql "github.com/graphql-go/graphql"
+	"math"
+	"strconv"
+)
+
+// Page sizes of every connection.
+const (
+	MaxPerPage     = 100
+	defaultPerPage = 10
+)
+
+// paginated names the fields that return connections, for the complexity limit.
+var paginated = map[string]bool{"posts": true, "comments": true}
+
+// connection is a page of nodes, the source of the PostConnection and CommentConnection types.
+type connection[T any] struct {
+	Nodes   []T
+	Page    int
+	PerPage int
+	Total   int64
+}
+
+func newSchema(posts *applicationPost.PostService, comments *applicationPostComment.PostCommentService) (gql.Schema, error) {
+	pageArgs := gql.FieldConfigArgument{
+		"page":    &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 1},
+		"perPage": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: defaultPerPage},
+	}
+
+	pageInfoType := gql.NewObject(gql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: gql.Fields{
+			"page":            &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"perPage":         &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"totalPages":      &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"hasNextPage":     &gql.Field{Type: gql.NewNonNull(gql.Boolean)},
+			"hasPreviousPage": &gql.Field{Type: gql.NewNonNull(gql.Boolean)},
+		},
+	})
+
+	var postType, commentType *gql.Object
+
+	commentType = gql.NewObject(gql.ObjectConfig{
+		Name: "Comment",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":     &gql.Field{Type: gql.NewNonNull(gql.ID), Resolve: commentField(func(c domain.PostComment) any { return c.Id })},
+				"text":   &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: commentField(func(c domain.PostComment) any { return c.Text.String() })},
+				"postId": &gql.Field{Type: gql.NewNonNull(gql.ID), Resolve: commentField(func(c domain.PostComment) any { return c.PostId })},
+				"post": &gql.Field{
+					Type: gql.NewNonNull(postType),
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						post, err := loadersFrom(p.Context).posts.Load(p.Context, p.Source.(domain.PostComment).PostId)
+						if err != nil {
+							return nil, err
+						}
+
+						return *post, nil
+					},
+				},
+				"createdAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: commentField(func(c domain.PostComment) any { return c.CreatedAt })},
+				"updatedAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: commentField(func(c domain.PostComment) any { return c.UpdatedAt })},
+			}
+		}),
+	})
+
+	commentConnectionType := connectionType[domain.PostComment]("CommentConnection", commentType, pageInfoType)
+
+	postType = gql.NewObject(gql.ObjectConfig{
+		Name: "Post",
+		Fields: gql.Fields{
+			"id":        &gql.Field{Type: gql.NewNonNull(gql.ID), Resolve: postField(func(p domain.Post) any { return p.Id })},
+			"title":     &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: postField(func(p domain.Post) any { return p.Title.String() })},
+			"content":   &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: postField(func(p domain.Post) any { return p.Content.String() })},
+			"version":   &gql.Field{Type: gql.NewNonNull(gql.Int), Resolve: postField(func(p domain.Post) any { return p.Version })},
+			"createdAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: postField(func(p domain.Post) any { return p.CreatedAt })},
+			"updatedAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: postField(func(p domain.Post) any { return p.UpdatedAt })},
+			"comments": &gql.Field{
+				Type:        gql.NewNonNull(commentConnectionType),
+				Description: "The comments of the post, newest first. The page is loaded for all posts of a response at once.",
+				Args:        pageArgs,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					page, perPage, err := pageOf(p.Args)
+					if err != nil {
+						return nil, err
+					}
+
+					load := loadersFrom(p.Context).comments.Load(p.Context, p.Source.(domain.Post).Id, page, perPage)
+					return func() (any, error) {
+						comments, err := load()
+						if err != nil {
+							return nil, err
+						}
+
+						return connection[domain.PostComment]{Nodes: comments.Comments, Page: page, PerPage: perPage, Total: comments.TotalCount}, nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	postConnectionType := connectionType[domain.Post]("PostConnection", postType, pageInfoType)
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"post": &gql.Field{
+				Type: postType,
+				Args: gql.FieldConfigArgument{"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)}},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					id, err := idOf(p.Args["id"], "post")
+					if err != nil {
+						return nil, err
+					}
+
+					post, err := loadersFrom(p.Context).posts.Load(p.Context, id)
+					if err != nil {
+						return nil, err
+					}
+
+					return *post, nil
+				},
+			},
+			"posts": &gql.Field{
+				Type: gql.NewNonNull(postConnectionType),
+				Args: pageArgs,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					page, perPage, err := pageOf(p.Args)
+					if err != nil {
+						return nil, err
+					}
+
+					result, err := posts.FindPaginatedPosts(p.Context, page, perPage)
+					if err != nil {
+						return nil, err
+					}
+
+					return connection[domain.Post]{Nodes: result.Posts, Page: page, PerPage: perPage, Total: result.TotalCount}, nil
+				},
+			},
+			"comment": &gql.Field{
+				Type: commentType,
+				Args: gql.FieldConfigArgument{"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)}},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					id, err := idOf(p.Args["id"], "comment")
+					if err != nil {
+						return nil, err
+					}
+
+					comment, err := comments.FindById(p.Context, int(id))
+					if err != nil {
+						return nil, err
+					}
+
+					return *comment, nil
+				},
+			},
+		},
+	})
+
+	mutation := gql.NewObject(gql.ObjectConfig{
+		Name: "Mutation",
+		Fields: gql.Fields{
+			"createPost": &gql.Field{
+				Type: gql.NewNonNull(postType),
+				Args: gql.FieldConfigArgument{
+					"input": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.NewInputObject(gql.InputObjectConfig{
+						Name: "CreatePostInput",
+						Fields: gql.InputObjectConfigFieldMap{
+							"title":   &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+							"content": &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+						},
+					}))},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					input := p.Args["input"].(map[string]any)
+
+					postTitle, err := value_object.NewTitle(input["title"].(string))
+					if err != nil {
+						return nil, domain.NewValidationError(err)
+					}
+
+					postContent, err := value_object.NewContent(input["content"].(string))
+					if err != nil {
+						return nil, domain.NewValidationError(err)
+					}
+
+					post, err := posts.CreatePost(p.Context, domain.Post{Title: postTitle, Content: postContent})
+					if err != nil {
+						return nil, err
+					}
+
+					return *post, nil
+				},
+			},
+			"updatePost": &gql.Field{
+				Type:        gql.NewNonNull(postType),
+				Description: "Updates the attributes given in the input and leaves the others as they are.",
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)},
+					"input": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.NewInputObject(gql.InputObjectConfig{
+						Name: "UpdatePostInput",
+						Fields: gql.InputObjectConfigFieldMap{
+							"title":   &gql.InputObjectFieldConfig{Type: gql.String},
+							"content": &gql.InputObjectFieldConfig{Type: gql.String},
+						},
+					}))},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					id, err := idOf(p.Args["id"], "post")
+					if err != nil {
+						return nil, err
+					}
+
+					post, err := posts.FindById(p.Context, int(id))
+					if err != nil {
+						return nil, err
+					}
+
+					input := p.Args["input"].(map[string]any)
+					if title, ok := input["title"].(string); ok {
+						postTitle, err := value_object.NewTitle(title)
+						if err != nil {
+							return nil, domain.NewValidationError(err)
+						}
+
+						post.Title = postTitle
+					}
+
+					if content, ok := input["content"].(string); ok {
+						postContent, err := value_object.NewContent(content)
+						if err != nil {
+							return nil, domain.NewValidationError(err)
+						}
+
+						post.Content = postContent
+					}
+
+					post, err = posts.UpdatePost(p.Context, *post)
+					if err != nil {
+						return nil, err
+					}
+
+					return *post, nil
+				},
+			},
+			"deletePost": &gql.Field{
+				Type: gql.NewNonNull(gql.Boolean),
+				Args: gql.FieldConfigArgument{"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)}},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					id, err := idOf(p.Args["id"], "post")
+					if err != nil {
+						return nil, err
+					}
+
+					if err := posts.DeletePost(p.Context, int(id)); err != nil {
+						return nil, err
+					}
+
+					return true, nil
+				},
+			},
+			"createComment": &gql.Field{
+				Type: gql.NewNonNull(commentType),
+				Args: gql.FieldConfigArgument{
+					"input": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.NewInputObject(gql.InputObjectConfig{
+						Name: "CreateCommentInput",
+						Fields: gql.InputObjectConfigFieldMap{
+							"postId": &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.ID)},
+							"text":   &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+						},
+					}))},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					input := p.Args["input"].(map[string]any)
+
+					postId, err := idOf(input["postId"], "post")
+					if err != nil {
+						return nil, err
+					}
+
+					postCommentText, err := value_object.NewText(input["text"].(string))
+					if err != nil {
+						return nil, domain.NewValidationError(err)
+					}
+
+					comment, err := comments.CreatePostComment(p.Context, domain.PostComment{Text: postCommentText, PostId: postId})
+					if err != nil {
+						return nil, err
+					}
+
+					return *comment, nil
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// connectionType defines the page of a node type together with its page info.
+func connectionType[T any](name string, nodeType *gql.Object, pageInfoType *gql.Object) *gql.Object {
+	return gql.NewObject(gql.ObjectConfig{
+		Name: name,
+		Fields: gql.Fields{
+			"nodes": &gql.Field{
+				Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(nodeType))),
+				Resolve: func(p gql.ResolveParams) (any, error) { return p.Source.(connection[T]).Nodes, nil },
+			},
+			"totalCount": &gql.Field{
+				Type:    gql.NewNonNull(gql.Int),
+				Resolve: func(p gql.ResolveParams) (any, error) { return p.Source.(connection[T]).Total, nil },
+			},
+			"pageInfo": &gql.Field{
+				Type: gql.NewNonNull(pageInfoType),
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					c := p.Source.(connection[T])
+					pages := int(math.Ceil(float64(c.Total) / float64(c.PerPage)))
+
+					return map[string]any{
+						"page":            c.Page,
+						"perPage":         c.PerPage,
+						"totalPages":      pages,
+						"hasNextPage":     c.Page < pages,
+						"hasPreviousPage": c.Page > 1,
+					}, nil
+				},
+			},
+		},
+	})
+}
+
+func postField(field func(domain.Post) any) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		return field(p.Source.(domain.Post)), nil
+	}
+}
+
+func commentField(field func(domain.PostComment) any) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		return field(p.Source.(domain.PostComment)), nil
+	}
+}
+
+func idOf(value any, resource string) (uint, error) {
+	id, err := strconv.ParseUint(fmt.Sprint(value), 10, 32)
+	if err != nil {
+		return 0, domain.NewValidationError(fmt.Errorf("invalid %s id", resource))
+	}
+
+	return uint(id), nil
+}
+
+func pageOf(args map[string]any) (int, int, error) {
+	page, perPage := args["page"].(int), args["perPage"].(int)
+	if page < 1 {
+		return 0, 0, domain.NewValidationError(errors.New("page must be positive"))
+	}
+	if perPage < 1 || perPage > MaxPerPage {
+		return 0, 0, domain.NewValidationError(fmt.Errorf("perPage must be between 1 and %d", MaxPerPage))
+	}
+
+	return page, perPage, nil
+}
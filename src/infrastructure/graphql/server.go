@@ -0,0 +1,185 @@
+// Package graphql serves the application services as a GraphQL API.
+package graphql
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"fmt"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"log/slog"
+	"net/http"
+)
+
+// Error codes in the extensions of the errors a response carries.
+const (
+	CodeNotFound   = "NOT_FOUND"
+	CodeConflict   = "CONFLICT"
+	CodeBadInput   = "BAD_USER_INPUT"
+	CodeForbidden  = "FORBIDDEN"
+	CodeTooComplex = "QUERY_TOO_COMPLEX"
+	CodeInternal   = "INTERNAL_SERVER_ERROR"
+)
+
+// Request is a GraphQL request, as sent in a POST body or in GET parameters.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Server struct {
+	schema   gql.Schema
+	posts    *applicationPost.PostService
+	comments *applicationPostComment.PostCommentService
+	limits   Limits
+}
+
+// NewServer builds the schema. It panics if the schema is invalid, which
+// cannot depend on input and is caught by any test that serves a request.
+func NewServer(posts *applicationPost.PostService, comments *applicationPostComment.PostCommentService, limits Limits) *Server {
+	schema, err := newSchema(posts, comments)
+	if err != nil {
+		panic(fmt.Errorf("invalid GraphQL schema: %w", err))
+	}
+
+	return &Server{schema: schema, posts: posts, comments: comments, limits: limits}
+}
+
+// execute runs a request and returns its result with the HTTP status to send:
+// 200 once the operation ran, even if some fields failed, and a client error
+// when the request was rejected before.
+func (s *Server) execute(ctx context.Context, req Request, queriesOnly bool) (*gql.Result, int) {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &gql.Result{Errors: gqlerrors.FormatErrors(err)}, http.StatusBadRequest
+	}
+
+	if validation := gql.ValidateDocument(&s.schema, doc, nil); !validation.IsValid {
+		return &gql.Result{Errors: validation.Errors}, http.StatusBadRequest
+	}
+
+	operation := findOperation(doc, req.OperationName)
+	if operation == nil {
+		return rejected(fmt.Sprintf("unknown operation %q", req.OperationName), CodeBadInput), http.StatusBadRequest
+	}
+	if queriesOnly && operation.Operation != ast.OperationTypeQuery {
+		return rejected(operation.Operation+" operations must be sent with POST", CodeBadInput), http.StatusMethodNotAllowed
+	}
+
+	if err := s.limits.check(doc, operation, req.Variables); err != nil {
+		return rejected(err.Error(), CodeTooComplex), http.StatusBadRequest
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, s.posts, s.comments),
+	})
+	for i := range result.Errors {
+		result.Errors[i] = formatError(ctx, result.Errors[i])
+	}
+
+	return result, http.StatusOK
+}
+
+// findOperation returns the operation to run: the named one, or the only one.
+func findOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if name == "" && found != nil {
+			return nil
+		}
+		if name == "" || (operation.Name != nil && operation.Name.Value == name) {
+			found = operation
+		}
+	}
+
+	return found
+}
+
+func rejected(message string, code string) *gql.Result {
+	err := gqlerrors.NewFormattedError(message)
+	err.Extensions = map[string]any{"code": code}
+
+	return &gql.Result{Errors: []gqlerrors.FormattedError{err}}
+}
+
+// formatError adds the code of domain errors and hides the message of
+// unexpected ones, which are logged instead, as http.ErrorHandler does.
+func formatError(ctx context.Context, err gqlerrors.FormattedError) gqlerrors.FormattedError {
+	var domainErr *domain.Error
+
+	switch cause := cause(err); {
+	case errors.As(cause, &domainErr):
+		err.Extensions = map[string]any{"code": code(domainErr)}
+	case isGraphqlError(cause):
+		// Raised by the executor, e.g. for an invalid variable: safe to show.
+	default:
+		slog.ErrorContext(ctx, "GraphQL field failed", slog.String("error", cause.Error()))
+		err.Message = "internal error"
+		err.Extensions = map[string]any{"code": CodeInternal}
+	}
+
+	return err
+}
+
+// cause unwraps the errors the executor wraps resolver errors in.
+func cause(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			if e.OriginalError() == nil {
+				return e
+			}
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			if e.OriginalError == nil {
+				return e
+			}
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+}
+
+func isGraphqlError(err error) bool {
+	switch err.(type) {
+	case gqlerrors.FormattedError, *gqlerrors.Error:
+		return true
+	default:
+		return false
+	}
+}
+
+func code(err *domain.Error) string {
+	switch err.Kind {
+	case domain.ErrNotFound:
+		return CodeNotFound
+	case domain.ErrConflict:
+		return CodeConflict
+	case domain.ErrValidation:
+		return CodeBadInput
+	case domain.ErrForbidden:
+		return CodeForbidden
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/grpc/pb"
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type commentsServer struct {
+	pb.UnimplementedCommentsServer
+	service *applicationPostComment.PostCommentService
+}
+
+func (s *commentsServer) GetComment(ctx context.Context, req *pb.GetCommentRequest) (*pb.Comment, error) {
+	comment, err := s.service.FindById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return toComment(comment), nil
+}
+
+func (s *commentsServer) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	page, perPage, err := pageOf(req.GetPage(), req.GetPerPage())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.FindPaginatedComments(ctx, int(req.GetPostId()), page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*pb.Comment, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		comments = append(comments, toComment(&comment))
+	}
+
+	return &pb.ListCommentsResponse{
+		Comments:   comments,
+		Pagination: toPagination(page, perPage, result.TotalCount),
+	}, nil
+}
+
+func (s *commentsServer) CreateComment(ctx context.Context, req *pb.CreateCommentRequest) (*pb.Comment, error) {
+	postCommentText, err := value_object.NewText(req.GetText())
+	if err != nil {
+		return nil, domain.NewValidationError(err)
+	}
+
+	comment, err := s.service.CreatePostComment(ctx, domain.PostComment{
+		Text:   postCommentText,
+		PostId: uint(req.GetPostId()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toComment(comment), nil
+}
+
+func toComment(comment *domain.PostComment) *pb.Comment {
+	return &pb.Comment{
+		Id:        uint64(comment.Id),
+		PostId:    uint64(comment.PostId),
+		Text:      comment.Text.String(),
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		UpdatedAt: timestamppb.New(comment.UpdatedAt),
+	}
+}
@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/logging"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	grpcio "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// headerRequestId carries the request id, as X-Request-ID does over HTTP.
+const headerRequestId = "x-request-id"
+
+// validRequestId keeps client supplied ids short and safe to log.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// accessLog puts the call into the context, so that services and queries log
+// with its id and method, and logs every finished call.
+func accessLog(logger *slog.Logger) grpcio.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpcio.UnaryServerInfo, handler grpcio.UnaryHandler) (any, error) {
+		started := time.Now()
+
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(headerRequestId)) > 0 {
+			id = md.Get(headerRequestId)[0]
+		}
+		if !validRequestId.MatchString(id) {
+			id = uuid.NewString()
+		}
+		_ = grpcio.SetHeader(ctx, metadata.Pairs(headerRequestId, id))
+
+		request := logging.NewRequest(id, func() string { return info.FullMethod })
+		request.Finish()
+		ctx = logging.WithRequest(ctx, request)
+
+		resp, err := handler(ctx, req)
+
+		var address string
+		if p, ok := peer.FromContext(ctx); ok {
+			address = p.Addr.String()
+		}
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "call",
+			slog.String("method", info.FullMethod),
+			slog.String("code", status.Code(err).String()),
+			slog.Duration("elapsed", time.Since(started)),
+			slog.String("peer", address),
+		)
+
+		return resp, err
+	}
+}
+
+// recovery turns a panicking handler into an internal error instead of a crashed process.
+func recovery() grpcio.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpcio.UnaryServerInfo, handler grpcio.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic in %s: %v", info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// statusErrors maps domain errors to status codes and hides the message of
+// unexpected errors, which are logged instead, as http.ErrorHandler does.
+func statusErrors() grpcio.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpcio.UnaryServerInfo, handler grpcio.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		return nil, toStatus(ctx, err).Err()
+	}
+}
+
+// toStatus maps an error to the status a client receives.
+func toStatus(ctx context.Context, err error) *status.Status {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return status.New(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrValidation):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return status.New(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	default:
+		slog.ErrorContext(ctx, "call failed", slog.String("error", err.Error()))
+		return status.New(codes.Internal, "internal error")
+	}
+}
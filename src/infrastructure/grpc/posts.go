@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/grpc/pb"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"math"
+)
+
+type postsServer struct {
+	pb.UnimplementedPostsServer
+	service *applicationPost.PostService
+}
+
+func (s *postsServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.Post, error) {
+	post, err := s.service.FindById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPost(post), nil
+}
+
+func (s *postsServer) ListPosts(ctx context.Context, req *pb.ListPostsRequest) (*pb.ListPostsResponse, error) {
+	page, perPage, err := pageOf(req.GetPage(), req.GetPerPage())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.FindPaginatedPosts(ctx, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*pb.Post, 0, len(result.Posts))
+	for _, post := range result.Posts {
+		posts = append(posts, toPost(&post))
+	}
+
+	return &pb.ListPostsResponse{
+		Posts:      posts,
+		Pagination: toPagination(page, perPage, result.TotalCount),
+	}, nil
+}
+
+func (s *postsServer) CreatePost(ctx context.Context, req *pb.CreatePostRequest) (*pb.Post, error) {
+	postTitle, err := value_object.NewTitle(req.GetTitle())
+	if err != nil {
+		return nil, domain.NewValidationError(err)
+	}
+
+	postContent, err := value_object.NewContent(req.GetContent())
+	if err != nil {
+		return nil, domain.NewValidationError(err)
+	}
+
+	post, err := s.service.CreatePost(ctx, domain.Post{
+		Title:   postTitle,
+		Content: postContent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPost(post), nil
+}
+
+func (s *postsServer) UpdatePost(ctx context.Context, req *pb.UpdatePostRequest) (*pb.Post, error) {
+	post, err := s.service.FindById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		postTitle, err := value_object.NewTitle(req.GetTitle())
+		if err != nil {
+			return nil, domain.NewValidationError(err)
+		}
+
+		post.Title = postTitle
+	}
+
+	if req.Content != nil {
+		postContent, err := value_object.NewContent(req.GetContent())
+		if err != nil {
+			return nil, domain.NewValidationError(err)
+		}
+
+		post.Content = postContent
+	}
+
+	post, err = s.service.UpdatePost(ctx, *post)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPost(post), nil
+}
+
+func (s *postsServer) DeletePost(ctx context.Context, req *pb.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeletePost(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toPost(post *domain.Post) *pb.Post {
+	return &pb.Post{
+		Id:        uint64(post.Id),
+		Title:     post.Title.String(),
+		Content:   post.Content.String(),
+		Version:   uint64(post.Version),
+		CreatedAt: timestamppb.New(post.CreatedAt),
+		UpdatedAt: timestamppb.New(post.UpdatedAt),
+	}
+}
+
+// maxPerPage bounds per_page, like the HTTP and GraphQL APIs do.
+const maxPerPage = 100
+
+// pageOf applies the defaults of v1, page 1 of 10 items, and refuses pages out of range.
+func pageOf(page int32, perPage int32) (int, int, error) {
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = 10
+	}
+
+	if page < 1 {
+		return 0, 0, domain.NewValidationError(errors.New("page must be positive"))
+	}
+	if perPage < 1 || perPage > maxPerPage {
+		return 0, 0, domain.NewValidationError(fmt.Errorf("per_page must be between 1 and %d", maxPerPage))
+	}
+
+	return int(page), int(perPage), nil
+}
+
+func toPagination(page int, perPage int, total int64) *pb.Pagination {
+	return &pb.Pagination{
+		Page:       int32(page),
+		PerPage:    int32(perPage),
+		TotalItems: total,
+		TotalPages: int32(math.Ceil(float64(total) / float64(perPage))),
+	}
+}
@@ -0,0 +1,80 @@
+// Package grpc serves the application services over gRPC, next to the HTTP API.
+package grpc
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/infrastructure/grpc/pb"
+	"DDD/src/infrastructure/tracing"
+	"context"
+	grpcio "google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"log/slog"
+	"net"
+)
+
+//go:generate protoc -I proto --go_out=../../.. --go_opt=module=DDD --go-grpc_out=../../.. --go-grpc_opt=module=DDD ddd/v1/posts.proto ddd/v1/comments.proto
+
+type Options struct {
+	// Reflection lets clients such as grpcurl discover the services.
+	Reflection bool
+}
+
+// Server is the gRPC server with the Posts, Comments and health services.
+type Server struct {
+	server *grpcio.Server
+	health *grpchealth.Server
+}
+
+func NewServer(posts *applicationPost.PostService, comments *applicationPostComment.PostCommentService, logger *slog.Logger, options Options) *Server {
+	server := grpcio.NewServer(grpcio.ChainUnaryInterceptor(
+		tracing.UnaryServerInterceptor(),
+		accessLog(logger),
+		statusErrors(),
+		recovery(),
+	))
+
+	pb.RegisterPostsServer(server, &postsServer{service: posts})
+	pb.RegisterCommentsServer(server, &commentsServer{service: comments})
+
+	health := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, health)
+	for name := range server.GetServiceInfo() {
+		health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	if options.Reflection {
+		reflection.Register(server)
+	}
+
+	return &Server{server: server, health: health}
+}
+
+// Serve accepts connections on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown reports every service as not serving, while calls are still accepted.
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+}
+
+// Stop waits for in-flight calls until ctx is done, then closes the remaining connections.
+func (s *Server) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
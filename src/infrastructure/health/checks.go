@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+)
+
+// Database pings the connection pool.
+func Database(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Migrations fails while migrations are pending, so that a replica does not
+// serve traffic against a schema it does not know.
+func Migrations(pending func(ctx context.Context) (int, error)) Check {
+	return func(ctx context.Context) error {
+		count, err := pending(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%d migrations pending", count)
+		}
+
+		return nil
+	}
+}
+
+// Redis pings the Redis server shared by the cache and the other Redis-backed features.
+func Redis(client redis.UniversalClient) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
@@ -0,0 +1,29 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Livez reports that the process is able to serve requests. It checks no
+// dependencies: restarting the process would not bring the database back.
+func Livez() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.JSON(fiber.Map{"status": StatusUp})
+	}
+}
+
+// Readyz reports whether the application can serve traffic, with the result of every check.
+func (c *Checker) Readyz() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		report := c.Ready(ctx.UserContext())
+
+		status := fiber.StatusOK
+		if report.Status != StatusUp {
+			status = fiber.StatusServiceUnavailable
+		}
+
+		ctx.Set(fiber.HeaderCacheControl, "no-store")
+		return ctx.Status(status).JSON(report)
+	}
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Check reports whether a dependency is usable. It must honour ctx.
+type Check func(ctx context.Context) error
+
+// Report is the readiness of the application and of each of its dependencies.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type CheckResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Elapsed string `json:"elapsed"`
+}
+
+// Checker runs the readiness checks of the dependencies it was given.
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add registers a dependency; adapters such as caches and queues add themselves when configured.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks[name] = check
+}
+
+// Shutdown makes the application report not ready from now on, so that load
+// balancers stop sending traffic while in-flight requests drain.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs every check concurrently, each bounded by the timeout.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusDown, Checks: map[string]CheckResult{
+			"shutdown": {Status: StatusDown, Error: ErrShuttingDown.Error(), Elapsed: "0s"},
+		}}
+	}
+
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: StatusUp, Elapsed: time.Since(started).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"DDD/src/infrastructure/logging"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"time"
+)
+
+// AccessLog puts the request into the user context, so that services and
+// queries log with its id and route, and logs every finished request.
+// It must be registered after RequestId.
+func AccessLog(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+
+		request := logging.NewRequest(TraceId(c), func() string { return c.Route().Path })
+		ctx := logging.WithRequest(c.UserContext(), request)
+		c.SetUserContext(ctx)
+
+		// Render errors here, so that the logged status is the one sent.
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		request.Finish()
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "request",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", c.Response().StatusCode()),
+			slog.Duration("elapsed", time.Since(started)),
+			slog.String("ip", c.IP()),
+		)
+
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"DDD/src/infrastructure/config"
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireBearer lets through the requests bearing the token. Without a token
+// configured, every request is refused: an endpoint guarded by it is never
+// left open by a missing setting.
+func RequireBearer(realm string, token config.Secret) fiber.Handler {
+	expected := []byte("Bearer " + token.Reveal())
+
+	return func(c *fiber.Ctx) error {
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), expected) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="`+realm+`"`)
+			return fiber.ErrUnauthorized
+		}
+
+		return c.Next()
+	}
+}
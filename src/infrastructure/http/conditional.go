@@ -0,0 +1,143 @@
+package http
+
+import (
+	"DDD/src/domain"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	nethttp "net/http"
+	"strings"
+	"time"
+)
+
+// Validators identify a representation for conditional requests. They are
+// derived from revisions, so a handler can answer 304 before loading anything.
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// EntityValidators are the validators of a single record: a strong ETag of
+// its revision, and its update time.
+func EntityValidators(kind string, revision domain.Revision) Validators {
+	return Validators{
+		ETag:         etag(kind, revision),
+		LastModified: revision.UpdatedAt,
+	}
+}
+
+// ListValidators are the validators of a page of records. The variant tells
+// apart pages of the same records, such as different page sizes. A list has no
+// Last-Modified: removing a record changes it without a newer update time.
+func ListValidators(variant string, total int64, revisions []domain.Revision) Validators {
+	return Validators{ETag: etag(fmt.Sprintf("%s:%d", variant, total), revisions...)}
+}
+
+// Revisions lists the revisions of the records a page is built from.
+func Revisions[T interface{ Revision() domain.Revision }](items []T) []domain.Revision {
+	revisions := make([]domain.Revision, len(items))
+	for i, item := range items {
+		revisions[i] = item.Revision()
+	}
+
+	return revisions
+}
+
+// NotModified sets the validators on the response and reports whether the
+// client's copy is current, following RFC 9110: If-None-Match, when sent,
+// takes precedence over If-Modified-Since.
+func NotModified(c *fiber.Ctx, validators Validators) bool {
+	SetValidators(c, validators)
+
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		return etagMatches(match, validators.ETag)
+	}
+
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !validators.LastModified.IsZero() {
+		t, err := nethttp.ParseTime(since)
+		return err == nil && !validators.LastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// SetValidators sets the validators of the representation being sent.
+func SetValidators(c *fiber.Ctx, validators Validators) {
+	c.Set(fiber.HeaderETag, validators.ETag)
+	if !validators.LastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, validators.LastModified.UTC().Format(nethttp.TimeFormat))
+	}
+}
+
+// CacheControl sets the Cache-Control policy of each route on its successful
+// GET and HEAD responses, including 304s. Routes without a policy send none.
+func CacheControl(policies map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil || (status != fiber.StatusOK && status != fiber.StatusNotModified) {
+			return err
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return nil
+		}
+
+		if policy := policies[c.Route().Path]; policy != "" {
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+
+		return nil
+	}
+}
+
+// EncodedETags runs around compression. The ETags of revisions identify the
+// representation before it is compressed, so they are made weak on responses
+// sent encoded: the bytes differ, what they mean does not. Every response
+// varies by Accept-Encoding, whether this one was compressed or not.
+func EncodedETags() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if Streaming(c) {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		c.Vary(fiber.HeaderAcceptEncoding)
+		etag := c.GetRespHeader(fiber.HeaderETag)
+		if etag != "" && !strings.HasPrefix(etag, "W/") && c.GetRespHeader(fiber.HeaderContentEncoding) != "" {
+			c.Set(fiber.HeaderETag, "W/"+etag)
+		}
+
+		return nil
+	}
+}
+
+func etag(variant string, revisions ...domain.Revision) string {
+	hash := sha256.New()
+	hash.Write([]byte(variant))
+	for _, revision := range revisions {
+		fmt.Fprintf(hash, "\n%d:%d:%d", revision.Id, revision.Version, revision.UpdatedAt.UnixNano())
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches compares weakly, as If-None-Match requires.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
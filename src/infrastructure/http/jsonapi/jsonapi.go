@@ -0,0 +1,230 @@
+// Package jsonapi renders JSON:API documents (https://jsonapi.org/format/1.1/).
+// Errors keep being rendered as problem details by http.ErrorHandler.
+package jsonapi
+
+import (
+	"DDD/src/domain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const ContentType = "application/vnd.api+json"
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// Links maps link names such as self, related or next to relative URLs.
+type Links map[string]string
+
+// Document is a top-level JSON:API document. Data is a *Resource, a []Resource or nil.
+type Document struct {
+	Data     any            `json:"data"`
+	Included []Resource     `json:"included,omitempty"`
+	Links    Links          `json:"links,omitempty"`
+	Meta     map[string]any `json:"meta,omitempty"`
+}
+
+// Resource is a resource object.
+type Resource struct {
+	Type          string                  `json:"type"`
+	Id            string                  `json:"id"`
+	Attributes    any                     `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         Links                   `json:"links,omitempty"`
+}
+
+// Identifier is a resource identifier object, the linkage of a relationship.
+type Identifier struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+// Relationship links to related resources. Data is an *Identifier or an []Identifier;
+// it is left out unless the related resources are known, e.g. because they are included.
+type Relationship struct {
+	Links Links          `json:"links,omitempty"`
+	Data  any            `json:"data,omitempty"`
+	Meta  map[string]any `json:"meta,omitempty"`
+}
+
+// Request is the document of a create or update request.
+type Request[A any] struct {
+	Data struct {
+		Type          string                         `json:"type"`
+		Id            string                         `json:"id"`
+		Attributes    A                              `json:"attributes"`
+		Relationships map[string]RequestRelationship `json:"relationships"`
+	} `json:"data"`
+}
+
+// RequestRelationship is a to-one relationship of a request document.
+type RequestRelationship struct {
+	Data *Identifier `json:"data"`
+}
+
+// Page is the page[number] and page[size] of a collection request.
+type Page struct {
+	Number int
+	Size   int
+}
+
+// Id formats a numeric id the way JSON:API wants it: as a string.
+func Id(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Send renders doc with the JSON:API media type.
+func Send(c *fiber.Ctx, status int, doc Document) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, ContentType)
+	return c.Status(status).Send(body)
+}
+
+// Decode reads a request document and checks that it holds a resource of the given type.
+func Decode[A any](c *fiber.Ctx, resourceType string) (*Request[A], error) {
+	var req Request[A]
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return nil, domain.NewValidationError(fmt.Errorf("invalid JSON:API document: %w", err))
+	}
+
+	if req.Data.Type != resourceType {
+		return nil, &domain.Error{
+			Kind:    domain.ErrConflict,
+			Message: fmt.Sprintf("expected a resource of type %q, got %q", resourceType, req.Data.Type),
+		}
+	}
+
+	return &req, nil
+}
+
+// RelatedId returns the id a to-one relationship of a request points to.
+func (r *Request[A]) RelatedId(name string, resourceType string) (uint, error) {
+	relationship, ok := r.Data.Relationships[name]
+	if !ok || relationship.Data == nil {
+		return 0, domain.NewValidationError(fmt.Errorf("relationship %s is required", name))
+	}
+
+	if relationship.Data.Type != resourceType {
+		return 0, domain.NewValidationError(fmt.Errorf("relationship %s must point to a %s", name, resourceType))
+	}
+
+	id, err := strconv.ParseUint(relationship.Data.Id, 10, 32)
+	if err != nil {
+		return 0, domain.NewValidationError(fmt.Errorf("invalid %s id", resourceType))
+	}
+
+	return uint(id), nil
+}
+
+// ParsePage reads page[number] and page[size], which default to 1 and DefaultPageSize.
+func ParsePage(c *fiber.Ctx) (Page, error) {
+	page := Page{Number: 1, Size: DefaultPageSize}
+
+	if value := c.Query("page[number]"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return page, domain.NewValidationError(errors.New("page[number] must be a positive integer"))
+		}
+		page.Number = number
+	}
+
+	if value := c.Query("page[size]"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > MaxPageSize {
+			return page, domain.NewValidationError(fmt.Errorf("page[size] must be between 1 and %d", MaxPageSize))
+		}
+		page.Size = size
+	}
+
+	return page, nil
+}
+
+// ParseInclude reads the include parameter and rejects relationships that cannot be included.
+func ParseInclude(c *fiber.Ctx, allowed ...string) (map[string]bool, error) {
+	include := make(map[string]bool)
+	if c.Query("include") == "" {
+		return include, nil
+	}
+
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		if !slices.Contains(allowed, name) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("relationship %q cannot be included", name))
+		}
+		include[name] = true
+	}
+
+	return include, nil
+}
+
+// Paginate returns the links and meta of a collection page. The links keep the
+// other query parameters of the request, such as include.
+func Paginate(c *fiber.Ctx, page Page, total int64) (Links, map[string]any) {
+	query := url.Values{}
+	for key, value := range c.Queries() {
+		query.Set(key, value)
+	}
+
+	return pageLinks(page, total, func(number int) string {
+		query.Set("page[number]", strconv.Itoa(number))
+		query.Set("page[size]", strconv.Itoa(page.Size))
+		return c.Path() + "?" + query.Encode()
+	})
+}
+
+// PaginateRelationship marks the linkage of a to-many relationship of resource
+// as one page of it, with links to the pages of its related collection.
+func PaginateRelationship(resource Resource, name string, page Page, total int64) {
+	relationship := resource.Relationships[name]
+	related := relationship.Links["related"]
+
+	links, meta := pageLinks(page, total, func(number int) string {
+		return related + "?" + url.Values{
+			"page[number]": {strconv.Itoa(number)},
+			"page[size]":   {strconv.Itoa(page.Size)},
+		}.Encode()
+	})
+	maps.Copy(relationship.Links, links)
+	delete(relationship.Links, "self")
+	relationship.Meta = meta
+
+	resource.Relationships[name] = relationship
+}
+
+func pageLinks(page Page, total int64, link func(number int) string) (Links, map[string]any) {
+	pages := int(math.Ceil(float64(total) / float64(page.Size)))
+
+	links := Links{
+		"self":  link(page.Number),
+		"first": link(1),
+		"last":  link(max(pages, 1)),
+	}
+	if page.Number > 1 {
+		links["prev"] = link(min(page.Number-1, max(pages, 1)))
+	}
+	if page.Number < pages {
+		links["next"] = link(page.Number + 1)
+	}
+
+	return links, map[string]any{
+		"page": map[string]any{
+			"number": page.Number,
+			"size":   page.Size,
+			"total":  pages,
+		},
+		"total": total,
+	}
+}
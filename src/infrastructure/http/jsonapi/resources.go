@@ -0,0 +1,94 @@
+package jsonapi
+
+import (
+	"DDD/src/domain"
+	"time"
+)
+
+const (
+	TypePosts    = "posts"
+	TypeComments = "comments"
+)
+
+type PostAttributes struct {
+	Title     string     `json:"title" example:"My post Title"`
+	Content   string     `json:"content" example:"Post content here"`
+	Version   uint       `json:"version" example:"1"`
+	CreatedAt time.Time  `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt time.Time  `json:"updatedAt" swaggertype:"string" format:"date-time"`
+	DeletedAt *time.Time `json:"deletedAt" swaggertype:"string" format:"date-time"`
+}
+
+type CommentAttributes struct {
+	Text      string     `json:"text" example:"Great post"`
+	CreatedAt time.Time  `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt time.Time  `json:"updatedAt" swaggertype:"string" format:"date-time"`
+	DeletedAt *time.Time `json:"deletedAt" swaggertype:"string" format:"date-time"`
+}
+
+func PostPath(id uint) string {
+	return "/api/v2/posts/" + Id(id)
+}
+
+func CommentPath(id uint) string {
+	return "/api/v2/comments/" + Id(id)
+}
+
+// PostResource renders a post. The linkage of its comments is only known, and
+// only rendered, when the comments are given, i.e. when they are included.
+func PostResource(post domain.Post, comments []domain.PostComment, withComments bool) Resource {
+	relationship := Relationship{Links: Links{"related": PostPath(post.Id) + "/comments"}}
+	if withComments {
+		linkage := make([]Identifier, 0, len(comments))
+		for _, comment := range comments {
+			linkage = append(linkage, Identifier{Type: TypeComments, Id: Id(comment.Id)})
+		}
+		relationship.Data = linkage
+	}
+
+	return Resource{
+		Type: TypePosts,
+		Id:   Id(post.Id),
+		Attributes: PostAttributes{
+			Title:     post.Title.String(),
+			Content:   post.Content.String(),
+			Version:   post.Version,
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: post.UpdatedAt,
+			DeletedAt: post.DeletedAt,
+		},
+		Relationships: map[string]Relationship{"comments": relationship},
+		Links:         Links{"self": PostPath(post.Id)},
+	}
+}
+
+// CommentResource renders a comment with the linkage of its post.
+func CommentResource(comment domain.PostComment) Resource {
+	return Resource{
+		Type: TypeComments,
+		Id:   Id(comment.Id),
+		Attributes: CommentAttributes{
+			Text:      comment.Text.String(),
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			DeletedAt: comment.DeletedAt,
+		},
+		Relationships: map[string]Relationship{
+			"post": {
+				Links: Links{"related": PostPath(comment.PostId)},
+				Data:  &Identifier{Type: TypePosts, Id: Id(comment.PostId)},
+			},
+		},
+		Links: Links{"self": CommentPath(comment.Id)},
+	}
+}
+
+// CommentResources renders comments, e.g. to include them in a document.
+func CommentResources(comments []domain.PostComment) []Resource {
+	resources := make([]Resource, 0, len(comments))
+	for _, comment := range comments {
+		resources = append(resources, CommentResource(comment))
+	}
+
+	return resources
+}
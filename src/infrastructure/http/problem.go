@@ -0,0 +1,83 @@
+package http
+
+import (
+	"DDD/src/domain"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"log/slog"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem type URIs. They are part of the public API and must not change.
+const (
+	ProblemTypeNotFound   = "/problems/not-found"
+	ProblemTypeConflict   = "/problems/conflict"
+	ProblemTypeValidation = "/problems/validation"
+	ProblemTypeForbidden  = "/problems/forbidden"
+	ProblemTypeInternal   = "/problems/internal"
+	ProblemTypeBlank      = "about:blank"
+)
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string `json:"type" example:"/problems/not-found"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"post with id 1 not found"`
+	Instance string `json:"instance,omitempty" example:"/api/v1/posts/1"`
+	TraceId  string `json:"traceId,omitempty" example:"3f1c9a52-7d0e-4b8e-9c55-2a4f0c6d1e8b"`
+}
+
+// ErrorHandler renders every error returned by a handler as application/problem+json.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	problem := NewProblem(err)
+	problem.Instance = c.OriginalURL()
+	problem.TraceId = TraceId(c)
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "request failed", slog.String("error", err.Error()))
+	}
+
+	c.Set(fiber.HeaderContentType, ProblemContentType)
+	return c.Status(problem.Status).JSON(problem, ProblemContentType)
+}
+
+// NewProblem maps err to a problem document without request-specific fields.
+func NewProblem(err error) Problem {
+	var fiberErr *fiber.Error
+
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return problem(ProblemTypeNotFound, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return problem(ProblemTypeConflict, fiber.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrValidation):
+		return problem(ProblemTypeValidation, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return problem(ProblemTypeForbidden, fiber.StatusForbidden, err.Error())
+	case errors.As(err, &fiberErr):
+		return problem(ProblemTypeBlank, fiberErr.Code, fiberErr.Message)
+	default:
+		return problem(ProblemTypeInternal, fiber.StatusInternalServerError, "")
+	}
+}
+
+// TraceId returns the identifier of the current request as set by the requestid middleware.
+func TraceId(c *fiber.Ctx) string {
+	if id, ok := c.Locals("requestid").(string); ok {
+		return id
+	}
+
+	return c.GetRespHeader(fiber.HeaderXRequestID)
+}
+
+func problem(problemType string, status int, detail string) Problem {
+	return Problem{
+		Type:   problemType,
+		Title:  utils.StatusMessage(status),
+		Status: status,
+		Detail: detail,
+	}
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"regexp"
+)
+
+// validRequestId keeps client supplied ids short and safe to log.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestId reuses the X-Request-ID header of the client when it is valid,
+// generates one otherwise, and echoes it in the response.
+func RequestId() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !validRequestId.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Locals("requestid", id)
+		c.Set(fiber.HeaderXRequestID, id)
+
+		return c.Next()
+	}
+}
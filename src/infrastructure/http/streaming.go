@@ -0,0 +1,12 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// Streaming tells the routes whose response never ends, such as event
+// streams, which middleware buffering the whole body must skip.
+func Streaming(c *fiber.Ctx) bool {
+	return strings.HasSuffix(c.Path(), "/stream")
+}
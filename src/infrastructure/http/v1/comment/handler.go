@@ -1,14 +1,15 @@
 package httpCommentV1
 
 import (
+	"DDD/src/application/post"
 	applicationComment "DDD/src/application/post_comment"
 	"DDD/src/domain"
 	"DDD/src/domain/value_object"
 	"DDD/src/infrastructure/http"
+	"DDD/src/infrastructure/stream"
 	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 	"math"
 	"strconv"
 	"time"
@@ -18,6 +19,10 @@ type CreatePostCommentRequest struct {
 	Text string `json:"text" example:"Great post"`
 }
 
+type UpdatePostCommentRequest struct {
+	Text string `json:"text" example:"Great post, updated"`
+}
+
 type PostCommentResponse struct {
 	ID        uint       `json:"id" example:"1"`
 	Text      string     `json:"text" example:"Great post"`
@@ -29,6 +34,11 @@ type PostCommentResponse struct {
 
 type Handler struct {
 	Service *applicationComment.PostCommentService
+	Posts   *applicationPost.PostService
+	Streams *stream.Hub
+	// Heartbeat is how often idle streams show they are alive.
+	Heartbeat time.Duration
+	upgrade   fiber.Handler
 }
 
 // FindComment Find post comment
@@ -39,19 +49,18 @@ type Handler struct {
 // @Produce json
 // @Param id path int true "post comment id"
 // @Success 201 {object} PostCommentResponse
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
 // @Router /api/v1/comments/{id} [get]
 func (h *Handler) FindComment(c *fiber.Ctx) error {
 	commentId, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment id"})
+		return domain.NewValidationError(errors.New("invalid comment id"))
 	}
 
-	comment, err := h.Service.FindById(c.Context(), commentId)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("PostComment with id %d not found", commentId)})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	comment, err := h.Service.FindById(c.UserContext(), commentId)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(PostCommentResponse{
@@ -73,8 +82,9 @@ func (h *Handler) FindComment(c *fiber.Ctx) error {
 // @Param postId path int true "post id"
 // @Param page query int false "page number" default(1)
 // @Param per_page query int false "per page number" default(10)
+// @Param If-None-Match header string false "ETag of the copy the client holds"
 // @Success 200 {object} http.PaginateResponse[domain.PostComment]
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
 // @Router /api/v1/posts/{postId}/comments [get]
 func (h *Handler) Paginate(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -82,14 +92,26 @@ func (h *Handler) Paginate(c *fiber.Ctx) error {
 
 	postId, err := c.ParamsInt("postId")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post id"})
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	variant := fmt.Sprintf("post:%d:comments:%d:%d", postId, page, perPage)
+	revisions, total, err := h.Service.FindPaginatedRevisions(c.UserContext(), postId, page, perPage)
+	if err != nil {
+		return err
+	}
+	if http.NotModified(c, http.ListValidators(variant, total, revisions)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
-	result, err := h.Service.FindPaginatedComments(c.Context(), postId, page, perPage)
+	result, err := h.Service.FindPaginatedComments(c.UserContext(), postId, page, perPage)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		return err
 	}
 
+	// The page may have changed since its revisions were read.
+	http.SetValidators(c, http.ListValidators(variant, result.TotalCount, http.Revisions(result.Comments)))
+
 	return c.JSON(http.PaginateResponse[domain.PostComment]{
 		Data: result.Comments,
 		Pagination: http.Pagination{
@@ -108,32 +130,81 @@ func (h *Handler) Paginate(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param request body CreatePostCommentRequest true "Post comment data to create"
+// @Param Idempotency-Key header string false "makes retries return the first response"
 // @Success 201 {object} PostCommentResponse
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem "request with the same Idempotency-Key in progress"
+// @Failure 422 {object} http.Problem "Idempotency-Key reused for a different request"
 // @Router /api/v1/posts/{postId}/comments [post]
 func (h *Handler) CreatePostComment(c *fiber.Ctx) error {
 	postId, err := c.ParamsInt("postId")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post id"})
+		return domain.NewValidationError(errors.New("invalid post id"))
 	}
 
 	req := CreatePostCommentRequest{}
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
 	postCommentText, err := value_object.NewText(req.Text)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
-	comment, err := h.Service.CreatePostComment(c.Context(), domain.PostComment{
+	comment, err := h.Service.CreatePostComment(c.UserContext(), domain.PostComment{
 		Text:   postCommentText,
 		PostId: uint(postId),
 	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(PostCommentResponse{
+		ID:        comment.Id,
+		Text:      comment.Text.String(),
+		PostId:    comment.PostId,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+		DeletedAt: comment.DeletedAt,
+	})
+}
 
+// UpdatePostComment edit a post comment
+// @Summary Update post comment
+// @Description Update the text of a post comment
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "post comment id"
+// @Param request body UpdatePostCommentRequest true "Post comment data to update"
+// @Success 200 {object} PostCommentResponse
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/comments/{id} [patch]
+func (h *Handler) UpdatePostComment(c *fiber.Ctx) error {
+	commentId, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(errors.New("invalid comment id"))
+	}
+
+	req := UpdatePostCommentRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	postCommentText, err := value_object.NewText(req.Text)
+	if err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	comment, err := h.Service.UpdatePostComment(c.UserContext(), domain.PostComment{
+		Id:   uint(commentId),
+		Text: postCommentText,
+	})
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(PostCommentResponse{
@@ -145,3 +216,27 @@ func (h *Handler) CreatePostComment(c *fiber.Ctx) error {
 		DeletedAt: comment.DeletedAt,
 	})
 }
+
+// DeletePostComment function removes a post comment by ID
+// @Summary Remove post comment by ID
+// @Description Remove post comment by ID
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "post comment id"
+// @Success 204 "No Content - Successful deletion"
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/comments/{id} [delete]
+func (h *Handler) DeletePostComment(c *fiber.Ctx) error {
+	commentId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid comment id"))
+	}
+
+	if err := h.Service.DeletePostComment(c.UserContext(), commentId); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
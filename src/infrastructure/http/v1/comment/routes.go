@@ -1,17 +1,25 @@
 package httpCommentV1
 
 import (
+	"DDD/src/application/post"
 	applicationComment "DDD/src/application/post_comment"
+	"DDD/src/infrastructure/stream"
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
-func SetupRoutes(app *fiber.App, service *applicationComment.PostCommentService) {
-	handler := &Handler{Service: service}
+func SetupRoutes(app *fiber.App, service *applicationComment.PostCommentService, posts *applicationPost.PostService, streams *stream.Hub, heartbeat time.Duration) {
+	handler := &Handler{Service: service, Posts: posts, Streams: streams, Heartbeat: heartbeat}
+	handler.upgrade = websocket.New(handler.follow)
 	postGroup := app.Group("/api/v1/posts")
 
 	postGroup.Get("/:postId/comments", handler.Paginate)
 	postGroup.Post("/:postId/comments", handler.CreatePostComment)
+	postGroup.Get("/:postId/comments/stream", handler.Stream)
 
 	commentGroup := app.Group("/api/v1/comments")
 	commentGroup.Get("/:id", handler.FindComment)
+	commentGroup.Patch("/:id", handler.UpdatePostComment)
+	commentGroup.Delete("/:id", handler.DeletePostComment)
 }
@@ -0,0 +1,216 @@
+package httpCommentV1
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/stream"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"time"
+)
+
+// HeaderLastEventId is sent by EventSource when it reconnects. Clients that
+// cannot set it, such as browser WebSockets, use the lastEventId query parameter.
+const HeaderLastEventId = "Last-Event-ID"
+
+// StreamMessage is a comment event as sent over WebSocket.
+type StreamMessage struct {
+	Id   string          `json:"id" example:"1718031234000042"`
+	Type string          `json:"type" example:"comment.created" enums:"ready,comment.created,comment.updated,comment.deleted,post.deleted,reset"`
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// postDeleted ends the stream, nothing follows it.
+var postDeleted = domain.PostDeleted{}.EventName()
+
+// eventReady tells a WebSocket client the id to resume from before any event arrived.
+const eventReady = "ready"
+
+// Stream follow post comments
+// @Summary Stream post comments
+// @Description Push comment.created, comment.updated and comment.deleted events of a post as Server-Sent Events,
+// @Description or as WebSocket messages when the request asks for an upgrade. A post.deleted event ends the stream.
+// @Description Reconnecting with Last-Event-ID replays the missed events, or sends a reset event when they are no longer known.
+// @Tags comments
+// @Produce text/event-stream
+// @Param postId path int true "post id"
+// @Param Last-Event-ID header string false "id of the last event received"
+// @Param lastEventId query string false "id of the last event received, for clients that cannot set headers"
+// @Success 200 {string} string "text/event-stream"
+// @Success 101 {object} StreamMessage "WebSocket messages"
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 503 {object} http.Problem "too many subscribers"
+// @Router /api/v1/posts/{postId}/comments/stream [get]
+func (h *Handler) Stream(c *fiber.Ctx) error {
+	postId, err := c.ParamsInt("postId")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	lastEventId, err := parseEventId(c.Get(HeaderLastEventId, c.Query("lastEventId")))
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.Posts.FindRevision(c.UserContext(), postId); err != nil {
+		return err
+	}
+
+	if websocket.IsWebSocketUpgrade(c) {
+		c.Locals("topic", stream.CommentsTopic(uint(postId)))
+		c.Locals("lastEventId", lastEventId)
+		return h.upgrade(c)
+	}
+
+	subscription, err := h.Streams.Subscribe(stream.CommentsTopic(uint(postId)), lastEventId)
+	if err != nil {
+		return subscribeError(err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer subscription.Close()
+
+		heartbeat := time.NewTicker(h.Heartbeat)
+		defer heartbeat.Stop()
+
+		// The server write timeout would end the stream, each write gets its own.
+		flush := func() bool {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(2 * h.Heartbeat))
+			}
+			return w.Flush() == nil
+		}
+
+		fmt.Fprintf(w, "retry: %d\n", (2 * time.Second).Milliseconds())
+		if lastEventId == 0 {
+			// An id without data is not dispatched, but is where EventSource resumes from.
+			fmt.Fprintf(w, "id: %d\n", subscription.Position())
+		}
+		w.WriteString("\n")
+		if !flush() {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-subscription.Events():
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, event.Data)
+				if !flush() || event.Type == postDeleted {
+					return
+				}
+			case <-heartbeat.C:
+				w.WriteString(": heartbeat\n\n")
+				if !flush() {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// follow sends the events of the topic stored in the locals over an upgraded connection.
+func (h *Handler) follow(conn *websocket.Conn) {
+	subscription, err := h.Streams.Subscribe(conn.Locals("topic").(string), conn.Locals("lastEventId").(uint64))
+	if err != nil {
+		closeSocket(conn, websocket.CloseTryAgainLater, err.Error())
+		return
+	}
+	defer subscription.Close()
+
+	// Reading handles pongs and notices clients that went away; they send nothing else.
+	gone := make(chan struct{})
+	_ = conn.SetReadDeadline(time.Now().Add(2 * h.Heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.Heartbeat))
+	})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+
+	send := func(message StreamMessage) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(2 * h.Heartbeat))
+		return conn.WriteJSON(message) == nil
+	}
+
+	if conn.Locals("lastEventId").(uint64) == 0 {
+		if !send(StreamMessage{Id: strconv.FormatUint(subscription.Position(), 10), Type: eventReady}) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if errors.Is(subscription.Err(), stream.ErrSlowSubscriber) {
+					closeSocket(conn, websocket.CloseTryAgainLater, "too slow, resume from the last event")
+				} else {
+					closeSocket(conn, websocket.CloseGoingAway, "stream closed")
+				}
+				return
+			}
+			if !send(StreamMessage{Id: strconv.FormatUint(event.Id, 10), Type: event.Type, Data: event.Data}) {
+				return
+			}
+			if event.Type == postDeleted {
+				closeSocket(conn, websocket.CloseNormalClosure, "post deleted")
+				return
+			}
+		case <-heartbeat.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.Heartbeat)) != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func closeSocket(conn *websocket.Conn, code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+}
+
+func parseEventId(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, domain.NewValidationError(errors.New("invalid last event id"))
+	}
+
+	return id, nil
+}
+
+func subscribeError(err error) error {
+	if errors.Is(err, stream.ErrTooManySubscribers) || errors.Is(err, stream.ErrClosed) {
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,144 @@
+package httpJobV1
+
+import (
+	applicationJob "DDD/src/application/job"
+	"DDD/src/domain"
+	"DDD/src/infrastructure/http"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"time"
+)
+
+type JobResponse struct {
+	ID          uint            `json:"id" example:"1"`
+	Queue       string          `json:"queue" example:"default"`
+	Type        string          `json:"type" example:"search.reindex"`
+	Status      string          `json:"status" example:"failed" enums:"pending,running,succeeded,failed"`
+	Attempts    int             `json:"attempts" example:"10"`
+	MaxAttempts int             `json:"maxAttempts" example:"10"`
+	RunAt       time.Time       `json:"runAt" swaggertype:"string" format:"date-time"`
+	LockedUntil *time.Time      `json:"lockedUntil" swaggertype:"string" format:"date-time"`
+	LastError   string          `json:"lastError" example:"connection refused"`
+	FinishedAt  *time.Time      `json:"finishedAt" swaggertype:"string" format:"date-time"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt   time.Time       `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt   time.Time       `json:"updatedAt" swaggertype:"string" format:"date-time"`
+}
+
+type Handler struct {
+	Service *applicationJob.JobService
+}
+
+// FindJob Find job
+// @Summary Find job by id
+// @Description Find a background job, with its payload and last error
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path int true "job id"
+// @Success 200 {object} JobResponse
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/admin/jobs/{id} [get]
+func (h *Handler) FindJob(c *fiber.Ctx) error {
+	jobId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid job id"))
+	}
+
+	job, err := h.Service.FindById(c.UserContext(), jobId)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toJobResponse(job))
+}
+
+// Paginate paginate
+// @Summary jobs pagination
+// @Description Background jobs, latest first, optionally of a queue or in a status
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param queue query string false "queue"
+// @Param status query string false "status" Enums(pending, running, succeeded, failed)
+// @Param page query int false "page number" default(1)
+// @Param per_page query int false "per page number" default(10)
+// @Success 200 {object} http.PaginateResponse[JobResponse]
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Router /api/v1/admin/jobs [get]
+func (h *Handler) Paginate(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	filter := domain.JobFilter{Queue: c.Query("queue"), Status: c.Query("status")}
+
+	result, err := h.Service.FindPaginatedJobs(c.UserContext(), filter, page, perPage)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]JobResponse, 0, len(result.Jobs))
+	for _, job := range result.Jobs {
+		jobs = append(jobs, toJobResponse(&job))
+	}
+
+	return c.JSON(http.PaginateResponse[JobResponse]{
+		Data: jobs,
+		Pagination: http.Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			TotalItems: result.TotalCount,
+			TotalPages: int(math.Ceil(float64(result.TotalCount) / float64(result.PerPage))),
+		},
+	})
+}
+
+// RetryJob run a failed job again
+// @Summary Retry a failed job
+// @Description Queue a failed job again with a fresh set of attempts, typically once what made it fail is fixed
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path int true "job id"
+// @Success 202 {object} JobResponse
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem "job not failed"
+// @Router /api/v1/admin/jobs/{id}/retry [post]
+func (h *Handler) RetryJob(c *fiber.Ctx) error {
+	jobId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid job id"))
+	}
+
+	job, err := h.Service.RetryJob(c.UserContext(), jobId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(toJobResponse(job))
+}
+
+func toJobResponse(job *domain.Job) JobResponse {
+	return JobResponse{
+		ID:          job.Id,
+		Queue:       job.Queue,
+		Type:        job.Type,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LockedUntil: job.LockedUntil,
+		LastError:   job.LastError,
+		FinishedAt:  job.FinishedAt,
+		Payload:     json.RawMessage(job.Payload),
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+}
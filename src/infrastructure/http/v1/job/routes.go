@@ -0,0 +1,17 @@
+package httpJobV1
+
+import (
+	applicationJob "DDD/src/application/job"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/http"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App, service *applicationJob.JobService, token config.Secret) {
+	handler := &Handler{Service: service}
+	jobGroup := app.Group("/api/v1/admin/jobs", http.RequireBearer("jobs", token))
+
+	jobGroup.Get("/", handler.Paginate)
+	jobGroup.Get("/:id", handler.FindJob)
+	jobGroup.Post("/:id/retry", handler.RetryJob)
+}
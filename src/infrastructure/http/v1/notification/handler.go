@@ -0,0 +1,118 @@
+package httpNotificationV1
+
+import (
+	applicationNotification "DDD/src/application/notification"
+	"DDD/src/domain"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+type UpdatePreferenceRequest struct {
+	OptedOut *bool   `json:"optedOut" example:"false"`
+	Digest   *string `json:"digest" example:"daily" enums:"batched,daily"`
+}
+
+type PreferenceResponse struct {
+	Email     string    `json:"email" example:"author@example.com"`
+	OptedOut  bool      `json:"optedOut" example:"false"`
+	Digest    string    `json:"digest" example:"batched" enums:"batched,daily"`
+	Confirmed bool      `json:"confirmed" example:"true"`
+	UpdatedAt time.Time `json:"updatedAt" swaggertype:"string" format:"date-time"`
+}
+
+type Handler struct {
+	Service *applicationNotification.NotificationService
+}
+
+// FindPreference Find notification preferences
+// @Summary Find notification preferences
+// @Description The notification preferences of the recipient of the token, which the emails link to
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param token path string true "token from the email"
+// @Success 200 {object} PreferenceResponse
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/notifications/preferences/{token} [get]
+func (h *Handler) FindPreference(c *fiber.Ctx) error {
+	preference, err := h.Service.FindPreference(c.UserContext(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toPreferenceResponse(preference))
+}
+
+// UpdatePreference change notification preferences
+// @Summary Update notification preferences
+// @Description Opt out of or back in to comment notifications, or get them as a daily digest instead of batched every few minutes
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param token path string true "token from the email"
+// @Param request body UpdatePreferenceRequest true "Choices to change"
+// @Success 200 {object} PreferenceResponse
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/notifications/preferences/{token} [patch]
+func (h *Handler) UpdatePreference(c *fiber.Ctx) error {
+	req := UpdatePreferenceRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	preference, err := h.Service.UpdatePreference(c.UserContext(), c.Params("token"), req.OptedOut, req.Digest)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toPreferenceResponse(preference))
+}
+
+// Confirm confirm the address
+// @Summary Confirm the address of notifications
+// @Description Confirm the address given for a post is the recipient's, the link of the confirmation request. Comments are only notified once it is confirmed.
+// @Tags notifications
+// @Produce json
+// @Param token path string true "token from the email"
+// @Success 200 {object} PreferenceResponse
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/notifications/preferences/{token}/confirm [post]
+// @Router /api/v1/notifications/preferences/{token}/confirm [get]
+func (h *Handler) Confirm(c *fiber.Ctx) error {
+	preference, err := h.Service.ConfirmPreference(c.UserContext(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toPreferenceResponse(preference))
+}
+
+// Unsubscribe opt out in one click
+// @Summary Unsubscribe from notifications
+// @Description Opt out of comment notifications, the target of the List-Unsubscribe header (RFC 8058)
+// @Tags notifications
+// @Produce json
+// @Param token path string true "token from the email"
+// @Success 200 {object} PreferenceResponse
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/notifications/preferences/{token}/unsubscribe [post]
+func (h *Handler) Unsubscribe(c *fiber.Ctx) error {
+	optedOut := true
+	preference, err := h.Service.UpdatePreference(c.UserContext(), c.Params("token"), &optedOut, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toPreferenceResponse(preference))
+}
+
+func toPreferenceResponse(preference *domain.NotificationPreference) PreferenceResponse {
+	return PreferenceResponse{
+		Email:     preference.Email.String(),
+		OptedOut:  preference.OptedOut,
+		Digest:    preference.Digest,
+		Confirmed: preference.Confirmed(),
+		UpdatedAt: preference.UpdatedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package httpNotificationV1
+
+import (
+	applicationNotification "DDD/src/application/notification"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App, service *applicationNotification.NotificationService) {
+	handler := &Handler{Service: service}
+	preferenceGroup := app.Group("/api/v1/notifications/preferences")
+
+	preferenceGroup.Get("/:token", handler.FindPreference)
+	preferenceGroup.Patch("/:token", handler.UpdatePreference)
+	preferenceGroup.Post("/:token/unsubscribe", handler.Unsubscribe)
+	// The confirmation request links to it, so following the link confirms too.
+	preferenceGroup.Get("/:token/confirm", handler.Confirm)
+	preferenceGroup.Post("/:token/confirm", handler.Confirm)
+}
@@ -2,13 +2,13 @@ package httpPostV1
 
 import (
 	"DDD/src/application/post"
+	"DDD/src/application/post_query"
 	"DDD/src/domain"
 	"DDD/src/domain/value_object"
 	"DDD/src/infrastructure/http"
 	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 	"math"
 	"strconv"
 	"time"
@@ -17,6 +17,8 @@ import (
 type CreatePostRequest struct {
 	Title   string `json:"title" example:"My post Title"`
 	Content string `json:"content" example:"Post content here"`
+	// AuthorEmail is notified of the comments on the post, never shown.
+	AuthorEmail string `json:"authorEmail" example:"author@example.com"`
 }
 
 type UpdatePostRequest struct {
@@ -33,8 +35,19 @@ type PostResponse struct {
 	DeletedAt *time.Time `json:"deletedAt" swaggertype:"string" format:"date-time"`
 }
 
+type SummaryResponse struct {
+	ID             uint      `json:"id" example:"1"`
+	Title          string    `json:"title" example:"My post Title"`
+	Excerpt        string    `json:"excerpt" example:"Post content here"`
+	CommentCount   int       `json:"commentCount" example:"3"`
+	LastActivityAt time.Time `json:"lastActivityAt" swaggertype:"string" format:"date-time"`
+	CreatedAt      time.Time `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt      time.Time `json:"updatedAt" swaggertype:"string" format:"date-time"`
+}
+
 type Handler struct {
 	Service *applicationPost.PostService
+	Queries *applicationPostQuery.PostQueryService
 }
 
 // FindPost Find post
@@ -44,30 +57,35 @@ type Handler struct {
 // @Accept json
 // @Produce json
 // @Param id path int true "post id"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 201 {object} PostResponse
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
 // @Router /api/v1/posts/{id} [get]
 func (h *Handler) FindPost(c *fiber.Ctx) error {
 	postID, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post id"})
+		return domain.NewValidationError(errors.New("invalid post id"))
 	}
 
-	post, err := h.Service.FindById(c.Context(), postID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Post with id %d not found", postID)})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	revision, err := h.Service.FindRevision(c.UserContext(), postID)
+	if err != nil {
+		return err
+	}
+	if http.NotModified(c, http.EntityValidators("post", *revision)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
-	return c.JSON(PostResponse{
-		ID:        post.Id,
-		Title:     post.Title.String(),
-		Content:   post.Content.String(),
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		DeletedAt: post.DeletedAt,
-	})
+	post, err := h.Service.FindById(c.UserContext(), postID)
+	if err != nil {
+		return err
+	}
+
+	// The post may have changed since its revision was read.
+	http.SetValidators(c, http.EntityValidators("post", post.Revision()))
+
+	return c.JSON(toPostResponse(post))
 }
 
 // Paginate paginate
@@ -78,20 +96,83 @@ func (h *Handler) FindPost(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "page number" default(1)
 // @Param per_page query int false "per page number" default(10)
-// @Success 200 {object} http.PaginateResponse[domain.Post]
-// @Failure 400 {string} error
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Success 200 {object} http.PaginateResponse[PostResponse]
+// @Failure 400 {object} http.Problem
 // @Router /api/v1/posts [get]
 func (h *Handler) Paginate(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
 
-	result, err := h.Service.FindPaginatedPosts(c.Context(), page, perPage)
+	variant := fmt.Sprintf("posts:%d:%d", page, perPage)
+	revisions, total, err := h.Service.FindPaginatedRevisions(c.UserContext(), page, perPage)
+	if err != nil {
+		return err
+	}
+	if http.NotModified(c, http.ListValidators(variant, total, revisions)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	result, err := h.Service.FindPaginatedPosts(c.UserContext(), page, perPage)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		return err
 	}
 
-	return c.JSON(http.PaginateResponse[domain.Post]{
-		Data: result.Posts,
+	// The page may have changed since its revisions were read.
+	http.SetValidators(c, http.ListValidators(variant, result.TotalCount, http.Revisions(result.Posts)))
+
+	posts := make([]PostResponse, 0, len(result.Posts))
+	for _, post := range result.Posts {
+		posts = append(posts, toPostResponse(&post))
+	}
+
+	return c.JSON(http.PaginateResponse[PostResponse]{
+		Data: posts,
+		Pagination: http.Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			TotalItems: result.TotalCount,
+			TotalPages: int(math.Ceil(float64(result.TotalCount) / float64(result.PerPage))),
+		},
+	})
+}
+
+// PaginateSummaries post listing
+// @Summary post summaries pagination
+// @Description Posts with their comment count and last activity, served from a read model that may lag the writes slightly
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param order query string false "latest post, latest activity or most comments first" Enums(latest, activity, comments) default(latest)
+// @Param page query int false "page number" default(1)
+// @Param per_page query int false "per page number" default(10)
+// @Success 200 {object} http.PaginateResponse[SummaryResponse]
+// @Failure 400 {object} http.Problem
+// @Router /api/v1/posts/summaries [get]
+func (h *Handler) PaginateSummaries(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+
+	result, err := h.Queries.FindPaginatedSummaries(c.UserContext(), c.Query("order", domain.SummariesByLatest), page, perPage)
+	if err != nil {
+		return err
+	}
+
+	summaries := make([]SummaryResponse, 0, len(result.Summaries))
+	for _, summary := range result.Summaries {
+		summaries = append(summaries, SummaryResponse{
+			ID:             summary.PostId,
+			Title:          summary.Title,
+			Excerpt:        summary.Excerpt,
+			CommentCount:   summary.CommentCount,
+			LastActivityAt: summary.LastActivityAt,
+			CreatedAt:      summary.CreatedAt,
+			UpdatedAt:      summary.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.PaginateResponse[SummaryResponse]{
+		Data: summaries,
 		Pagination: http.Pagination{
 			Page:       page,
 			PerPage:    perPage,
@@ -108,23 +189,26 @@ func (h *Handler) Paginate(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param request body CreatePostRequest true "Post data to create"
+// @Param Idempotency-Key header string false "makes retries return the first response"
 // @Success 201 {object} PostResponse
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 409 {object} http.Problem
+// @Failure 422 {object} http.Problem "Idempotency-Key reused for a different request"
 // @Router /api/v1/posts [post]
 func (h *Handler) CreatePost(c *fiber.Ctx) error {
 	req := CreatePostRequest{}
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
 	postTitle, err := value_object.NewTitle(req.Title)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
 	postContent, err := value_object.NewContent(req.Content)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
 	postData := domain.Post{
@@ -132,22 +216,19 @@ func (h *Handler) CreatePost(c *fiber.Ctx) error {
 		Content: postContent,
 	}
 
-	post, err := h.Service.CreatePost(c.Context(), postData)
+	if req.AuthorEmail != "" {
+		postData.AuthorEmail, err = value_object.NewEmail(req.AuthorEmail)
+		if err != nil {
+			return domain.NewValidationError(err)
+		}
+	}
 
-	if errors.Is(err, gorm.ErrDuplicatedKey) {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("Post with title %s already exists", postData.Title)})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	post, err := h.Service.CreatePost(c.UserContext(), postData)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(PostResponse{
-		ID:        post.Id,
-		Title:     post.Title.String(),
-		Content:   post.Content.String(),
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		DeletedAt: post.DeletedAt,
-	})
+	return c.Status(fiber.StatusCreated).JSON(toPostResponse(post))
 }
 
 // UpdatePost update post
@@ -158,29 +239,29 @@ func (h *Handler) CreatePost(c *fiber.Ctx) error {
 // @Produce json
 // @Param request body UpdatePostRequest true "Post data to update"
 // @Success 200 {object} PostResponse
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem
 // @Router /api/v1/posts/{id} [patch]
 func (h *Handler) UpdatePost(c *fiber.Ctx) error {
 	postID, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post id"})
+		return domain.NewValidationError(errors.New("invalid post id"))
 	}
-	post, err := h.Service.FindById(c.Context(), postID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Post with id %d not found", postID)})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	post, err := h.Service.FindById(c.UserContext(), postID)
+	if err != nil {
+		return err
 	}
 
 	req := UpdatePostRequest{}
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return domain.NewValidationError(err)
 	}
 
 	if req.Title != "" {
 		postTitle, err := value_object.NewTitle(req.Title)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return domain.NewValidationError(err)
 		}
 
 		post.Title = postTitle
@@ -189,28 +270,18 @@ func (h *Handler) UpdatePost(c *fiber.Ctx) error {
 	if req.Content != "" {
 		postContent, err := value_object.NewContent(req.Content)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return domain.NewValidationError(err)
 		}
 
 		post.Content = postContent
 	}
 
-	post, err = h.Service.UpdatePost(c.Context(), *post)
-
-	if errors.Is(err, gorm.ErrDuplicatedKey) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "You can't update a post with the same title."})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	post, err = h.Service.UpdatePost(c.UserContext(), *post)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(PostResponse{
-		ID:        post.Id,
-		Title:     post.Title.String(),
-		Content:   post.Content.String(),
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		DeletedAt: post.DeletedAt,
-	})
+	return c.JSON(toPostResponse(post))
 }
 
 // DeletePost function removes a post by ID
@@ -221,21 +292,29 @@ func (h *Handler) UpdatePost(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 204 "No Content - Successful deletion"
-// @Failure 400 {string} error
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
 // @Router /api/v1/posts/{id} [delete]
 func (h *Handler) DeletePost(c *fiber.Ctx) error {
 	postID, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post id"})
+		return domain.NewValidationError(errors.New("invalid post id"))
 	}
 
-	_, err = h.Service.FindById(c.Context(), postID)
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Post with id %d not found", postID)})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.Service.DeletePost(c.UserContext(), postID); err != nil {
+		return err
 	}
 
-	return h.Service.DeletePost(c.Context(), postID)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func toPostResponse(post *domain.Post) PostResponse {
+	return PostResponse{
+		ID:        post.Id,
+		Title:     post.Title.String(),
+		Content:   post.Content.String(),
+		CreatedAt: post.CreatedAt,
+		UpdatedAt: post.UpdatedAt,
+		DeletedAt: post.DeletedAt,
+	}
 }
@@ -2,14 +2,16 @@ package httpPostV1
 
 import (
 	"DDD/src/application/post"
+	"DDD/src/application/post_query"
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRoutes(app *fiber.App, service *applicationPost.PostService) {
-	handler := &Handler{Service: service}
+func SetupRoutes(app *fiber.App, service *applicationPost.PostService, queries *applicationPostQuery.PostQueryService) {
+	handler := &Handler{Service: service, Queries: queries}
 	postGroup := app.Group("/api/v1/posts")
 
 	postGroup.Get("/", handler.Paginate)
+	postGroup.Get("/summaries", handler.PaginateSummaries)
 	postGroup.Get("/:id", handler.FindPost)
 	postGroup.Post("/", handler.CreatePost)
 	postGroup.Patch("/:id", handler.UpdatePost)
@@ -0,0 +1,282 @@
+package httpWebhookV1
+
+import (
+	applicationWebhook "DDD/src/application/webhook"
+	"DDD/src/domain"
+	"DDD/src/infrastructure/http"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	Url string `json:"url" example:"https://partner.example.com/hooks"`
+	// Secret signs the deliveries, generated when empty.
+	Secret string   `json:"secret" example:""`
+	Events []string `json:"events" example:"post.created,comment.created"`
+}
+
+type WebhookResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Url       string    `json:"url" example:"https://partner.example.com/hooks"`
+	Events    []string  `json:"events" example:"post.created,comment.created"`
+	CreatedAt time.Time `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt time.Time `json:"updatedAt" swaggertype:"string" format:"date-time"`
+}
+
+// CreatedWebhookResponse is the only response that shows the secret.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret" example:"4f1c..."`
+}
+
+type DeliveryResponse struct {
+	ID             uint            `json:"id" example:"1"`
+	WebhookId      uint            `json:"webhookId" example:"1"`
+	EventId        string          `json:"eventId" example:"0b8e4f0c-3d5a-4c8e-9a55-1f6f2a4c7d10"`
+	Event          string          `json:"event" example:"post.created"`
+	Status         string          `json:"status" example:"pending" enums:"pending,succeeded,dead"`
+	Attempts       int             `json:"attempts" example:"1"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt" swaggertype:"string" format:"date-time"`
+	ResponseStatus int             `json:"responseStatus" example:"500"`
+	LastError      string          `json:"lastError" example:"receiver answered 500"`
+	DeliveredAt    *time.Time      `json:"deliveredAt" swaggertype:"string" format:"date-time"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"createdAt" swaggertype:"string" format:"date-time"`
+	UpdatedAt      time.Time       `json:"updatedAt" swaggertype:"string" format:"date-time"`
+}
+
+type Handler struct {
+	Service *applicationWebhook.WebhookService
+}
+
+// FindWebhook Find webhook
+// @Summary Find webhook by id
+// @Description Find webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook id"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/webhooks/{id} [get]
+func (h *Handler) FindWebhook(c *fiber.Ctx) error {
+	webhookId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid webhook id"))
+	}
+
+	webhook, err := h.Service.FindById(c.UserContext(), webhookId)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toWebhookResponse(webhook))
+}
+
+// Paginate paginate
+// @Summary webhooks pagination
+// @Description webhooks pagination
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param page query int false "page number" default(1)
+// @Param per_page query int false "per page number" default(10)
+// @Success 200 {object} http.PaginateResponse[WebhookResponse]
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Router /api/v1/webhooks [get]
+func (h *Handler) Paginate(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+
+	result, err := h.Service.FindPaginatedWebhooks(c.UserContext(), page, perPage)
+	if err != nil {
+		return err
+	}
+
+	webhooks := make([]WebhookResponse, 0, len(result.Webhooks))
+	for _, webhook := range result.Webhooks {
+		webhooks = append(webhooks, toWebhookResponse(&webhook))
+	}
+
+	return c.JSON(http.PaginateResponse[WebhookResponse]{
+		Data: webhooks,
+		Pagination: http.Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			TotalItems: result.TotalCount,
+			TotalPages: int(math.Ceil(float64(result.TotalCount) / float64(result.PerPage))),
+		},
+	})
+}
+
+// CreateWebhook subscribe to events
+// @Summary Create a new webhook
+// @Description Subscribe a URL to events. Deliveries are signed with the secret, which is only shown in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body CreateWebhookRequest true "Webhook to create"
+// @Param Idempotency-Key header string false "makes retries return the first response"
+// @Success 201 {object} CreatedWebhookResponse
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 422 {object} http.Problem "Idempotency-Key reused for a different request"
+// @Router /api/v1/webhooks [post]
+func (h *Handler) CreateWebhook(c *fiber.Ctx) error {
+	req := CreateWebhookRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	webhookData, err := domain.NewWebhook(req.Url, req.Secret, req.Events)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.Service.CreateWebhook(c.UserContext(), *webhookData)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(CreatedWebhookResponse{
+		WebhookResponse: toWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+// DeleteWebhook function removes a webhook by ID
+// @Summary Remove webhook by ID
+// @Description Remove webhook by ID, with its delivery log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 204 "No Content - Successful deletion"
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *fiber.Ctx) error {
+	webhookId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid webhook id"))
+	}
+
+	if err := h.Service.DeleteWebhook(c.UserContext(), webhookId); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PaginateDeliveries delivery log
+// @Summary webhook deliveries pagination
+// @Description The delivery log of a webhook, latest first
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook id"
+// @Param page query int false "page number" default(1)
+// @Param per_page query int false "per page number" default(10)
+// @Success 200 {object} http.PaginateResponse[DeliveryResponse]
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *Handler) PaginateDeliveries(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+
+	webhookId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid webhook id"))
+	}
+
+	result, err := h.Service.FindPaginatedDeliveries(c.UserContext(), webhookId, page, perPage)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]DeliveryResponse, 0, len(result.Deliveries))
+	for _, delivery := range result.Deliveries {
+		deliveries = append(deliveries, toDeliveryResponse(&delivery))
+	}
+
+	return c.JSON(http.PaginateResponse[DeliveryResponse]{
+		Data: deliveries,
+		Pagination: http.Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			TotalItems: result.TotalCount,
+			TotalPages: int(math.Ceil(float64(result.TotalCount) / float64(result.PerPage))),
+		},
+	})
+}
+
+// Redeliver queue a delivery again
+// @Summary Redeliver a webhook delivery
+// @Description Queue a delivery again with a fresh set of attempts, typically a dead one once the receiver is fixed
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook id"
+// @Param deliveryId path int true "delivery id"
+// @Success 202 {object} DeliveryResponse
+// @Failure 400 {object} http.Problem
+// @Failure 401 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem "delivery already pending"
+// @Router /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handler) Redeliver(c *fiber.Ctx) error {
+	webhookId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid webhook id"))
+	}
+
+	deliveryId, err := c.ParamsInt("deliveryId")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid delivery id"))
+	}
+
+	delivery, err := h.Service.Redeliver(c.UserContext(), webhookId, deliveryId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(toDeliveryResponse(delivery))
+}
+
+func toWebhookResponse(webhook *domain.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        webhook.Id,
+		Url:       webhook.Url,
+		Events:    webhook.Events,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(delivery *domain.WebhookDelivery) DeliveryResponse {
+	return DeliveryResponse{
+		ID:             delivery.Id,
+		WebhookId:      delivery.WebhookId,
+		EventId:        delivery.EventId,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
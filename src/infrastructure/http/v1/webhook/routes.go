@@ -0,0 +1,21 @@
+package httpWebhookV1
+
+import (
+	applicationWebhook "DDD/src/application/webhook"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/http"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App, service *applicationWebhook.WebhookService, token config.Secret) {
+	handler := &Handler{Service: service}
+	webhookGroup := app.Group("/api/v1/webhooks", http.RequireBearer("webhooks", token))
+
+	webhookGroup.Get("/", handler.Paginate)
+	webhookGroup.Get("/:id", handler.FindWebhook)
+	webhookGroup.Post("/", handler.CreateWebhook)
+	webhookGroup.Delete("/:id", handler.DeleteWebhook)
+
+	webhookGroup.Get("/:id/deliveries", handler.PaginateDeliveries)
+	webhookGroup.Post("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+}
@@ -0,0 +1,139 @@
+package httpCommentV2
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/http/jsonapi"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreatePostCommentAttributes struct {
+	Text string `json:"text" example:"Great post"`
+}
+
+type Handler struct {
+	Service *applicationPostComment.PostCommentService
+	Posts   *applicationPost.PostService
+}
+
+// FindComment Find post comment
+// @Summary Find post comment by id
+// @Description Find post comment as a JSON:API document
+// @Tags comments v2
+// @Produce application/vnd.api+json
+// @Param id path int true "post comment id"
+// @Param include query string false "related resources to include" Enums(post)
+// @Success 200 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v2/comments/{id} [get]
+func (h *Handler) FindComment(c *fiber.Ctx) error {
+	commentId, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid comment id"))
+	}
+
+	include, err := jsonapi.ParseInclude(c, "post")
+	if err != nil {
+		return err
+	}
+
+	comment, err := h.Service.FindById(c.UserContext(), commentId)
+	if err != nil {
+		return err
+	}
+
+	resource := jsonapi.CommentResource(*comment)
+	doc := jsonapi.Document{Data: &resource, Links: jsonapi.Links{"self": c.OriginalURL()}}
+
+	if include["post"] {
+		post, err := h.Posts.FindById(c.UserContext(), int(comment.PostId))
+		if err != nil {
+			return err
+		}
+
+		doc.Included = []jsonapi.Resource{jsonapi.PostResource(*post, nil, false)}
+	}
+
+	return jsonapi.Send(c, fiber.StatusOK, doc)
+}
+
+// Paginate paginate
+// @Summary post comments pagination
+// @Description post comments pagination as a JSON:API document with pagination links
+// @Tags posts v2
+// @Produce application/vnd.api+json
+// @Param postId path int true "post id"
+// @Param page[number] query int false "page number" default(1)
+// @Param page[size] query int false "page size" default(10)
+// @Success 200 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Router /api/v2/posts/{postId}/comments [get]
+func (h *Handler) Paginate(c *fiber.Ctx) error {
+	postId, err := c.ParamsInt("postId")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	page, err := jsonapi.ParsePage(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.Service.FindPaginatedComments(c.UserContext(), postId, page.Number, page.Size)
+	if err != nil {
+		return err
+	}
+
+	doc := jsonapi.Document{Data: jsonapi.CommentResources(result.Comments)}
+	doc.Links, doc.Meta = jsonapi.Paginate(c, page, result.TotalCount)
+
+	return jsonapi.Send(c, fiber.StatusOK, doc)
+}
+
+// CreatePostComment create a new post comment data
+// @Summary Create a new post comment
+// @Description Create a comment on the post given by its post relationship
+// @Tags comments v2
+// @Accept application/vnd.api+json
+// @Produce application/vnd.api+json
+// @Param request body jsonapi.Request[CreatePostCommentAttributes] true "Post comment to create"
+// @Param Idempotency-Key header string false "makes retries return the first response"
+// @Success 201 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem
+// @Failure 422 {object} http.Problem "Idempotency-Key reused for a different request"
+// @Router /api/v2/comments [post]
+func (h *Handler) CreatePostComment(c *fiber.Ctx) error {
+	req, err := jsonapi.Decode[CreatePostCommentAttributes](c, jsonapi.TypeComments)
+	if err != nil {
+		return err
+	}
+
+	postId, err := req.RelatedId("post", jsonapi.TypePosts)
+	if err != nil {
+		return err
+	}
+
+	postCommentText, err := value_object.NewText(req.Data.Attributes.Text)
+	if err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	comment, err := h.Service.CreatePostComment(c.UserContext(), domain.PostComment{
+		Text:   postCommentText,
+		PostId: postId,
+	})
+	if err != nil {
+		return err
+	}
+
+	resource := jsonapi.CommentResource(*comment)
+	c.Location(jsonapi.CommentPath(comment.Id))
+
+	return jsonapi.Send(c, fiber.StatusCreated, jsonapi.Document{Data: &resource})
+}
@@ -0,0 +1,18 @@
+package httpCommentV2
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App, service *applicationPostComment.PostCommentService, posts *applicationPost.PostService) {
+	handler := &Handler{Service: service, Posts: posts}
+	postGroup := app.Group("/api/v2/posts")
+
+	postGroup.Get("/:postId/comments", handler.Paginate)
+
+	commentGroup := app.Group("/api/v2/comments")
+	commentGroup.Get("/:id", handler.FindComment)
+	commentGroup.Post("/", handler.CreatePostComment)
+}
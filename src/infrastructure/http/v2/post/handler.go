@@ -0,0 +1,264 @@
+package httpPostV2
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/http/jsonapi"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreatePostAttributes struct {
+	Title   string `json:"title" example:"My post Title"`
+	Content string `json:"content" example:"Post content here"`
+}
+
+// UpdatePostAttributes leaves out the attributes that do not change.
+type UpdatePostAttributes struct {
+	Title   *string `json:"title" example:"My post Title"`
+	Content *string `json:"content" example:"Post content here"`
+}
+
+type Handler struct {
+	Service  *applicationPost.PostService
+	Comments *applicationPostComment.PostCommentService
+}
+
+// FindPost Find post
+// @Summary Find post by id
+// @Description Find post as a JSON:API document
+// @Tags posts v2
+// @Produce application/vnd.api+json
+// @Param id path int true "post id"
+// @Param include query string false "related resources to include" Enums(comments)
+// @Success 200 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v2/posts/{id} [get]
+func (h *Handler) FindPost(c *fiber.Ctx) error {
+	postID, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	include, err := jsonapi.ParseInclude(c, "comments")
+	if err != nil {
+		return err
+	}
+
+	post, err := h.Service.FindById(c.UserContext(), postID)
+	if err != nil {
+		return err
+	}
+
+	doc, err := h.document(c, []domain.Post{*post}, include["comments"])
+	if err != nil {
+		return err
+	}
+	doc.Data = &doc.Data.([]jsonapi.Resource)[0]
+	doc.Links = jsonapi.Links{"self": c.OriginalURL()}
+
+	return jsonapi.Send(c, fiber.StatusOK, *doc)
+}
+
+// Paginate paginate
+// @Summary posts pagination
+// @Description posts pagination as a JSON:API document with pagination links
+// @Tags posts v2
+// @Produce application/vnd.api+json
+// @Param page[number] query int false "page number" default(1)
+// @Param page[size] query int false "page size" default(10)
+// @Param include query string false "related resources to include" Enums(comments)
+// @Success 200 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Router /api/v2/posts [get]
+func (h *Handler) Paginate(c *fiber.Ctx) error {
+	page, err := jsonapi.ParsePage(c)
+	if err != nil {
+		return err
+	}
+
+	include, err := jsonapi.ParseInclude(c, "comments")
+	if err != nil {
+		return err
+	}
+
+	result, err := h.Service.FindPaginatedPosts(c.UserContext(), page.Number, page.Size)
+	if err != nil {
+		return err
+	}
+
+	doc, err := h.document(c, result.Posts, include["comments"])
+	if err != nil {
+		return err
+	}
+	doc.Links, doc.Meta = jsonapi.Paginate(c, page, result.TotalCount)
+
+	return jsonapi.Send(c, fiber.StatusOK, *doc)
+}
+
+// CreatePost create a new post data
+// @Summary Create a new post
+// @Description Create post from a JSON:API document
+// @Tags posts v2
+// @Accept application/vnd.api+json
+// @Produce application/vnd.api+json
+// @Param request body jsonapi.Request[CreatePostAttributes] true "Post to create"
+// @Param Idempotency-Key header string false "makes retries return the first response"
+// @Success 201 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Failure 409 {object} http.Problem
+// @Failure 422 {object} http.Problem "Idempotency-Key reused for a different request"
+// @Router /api/v2/posts [post]
+func (h *Handler) CreatePost(c *fiber.Ctx) error {
+	req, err := jsonapi.Decode[CreatePostAttributes](c, jsonapi.TypePosts)
+	if err != nil {
+		return err
+	}
+
+	postTitle, err := value_object.NewTitle(req.Data.Attributes.Title)
+	if err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	postContent, err := value_object.NewContent(req.Data.Attributes.Content)
+	if err != nil {
+		return domain.NewValidationError(err)
+	}
+
+	post, err := h.Service.CreatePost(c.UserContext(), domain.Post{
+		Title:   postTitle,
+		Content: postContent,
+	})
+	if err != nil {
+		return err
+	}
+
+	resource := jsonapi.PostResource(*post, nil, true)
+	c.Location(jsonapi.PostPath(post.Id))
+
+	return jsonapi.Send(c, fiber.StatusCreated, jsonapi.Document{Data: &resource})
+}
+
+// UpdatePost update post
+// @Summary Update post
+// @Description Update the attributes of a post given in a JSON:API document
+// @Tags posts v2
+// @Accept application/vnd.api+json
+// @Produce application/vnd.api+json
+// @Param id path int true "post id"
+// @Param request body jsonapi.Request[UpdatePostAttributes] true "Post attributes to update"
+// @Success 200 {object} jsonapi.Document
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Failure 409 {object} http.Problem
+// @Router /api/v2/posts/{id} [patch]
+func (h *Handler) UpdatePost(c *fiber.Ctx) error {
+	postID, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	req, err := jsonapi.Decode[UpdatePostAttributes](c, jsonapi.TypePosts)
+	if err != nil {
+		return err
+	}
+	if req.Data.Id != c.Params("id") {
+		return domain.NewConflictError("resource id %q does not match the URL", req.Data.Id)
+	}
+
+	post, err := h.Service.FindById(c.UserContext(), postID)
+	if err != nil {
+		return err
+	}
+
+	if req.Data.Attributes.Title != nil {
+		postTitle, err := value_object.NewTitle(*req.Data.Attributes.Title)
+		if err != nil {
+			return domain.NewValidationError(err)
+		}
+
+		post.Title = postTitle
+	}
+
+	if req.Data.Attributes.Content != nil {
+		postContent, err := value_object.NewContent(*req.Data.Attributes.Content)
+		if err != nil {
+			return domain.NewValidationError(err)
+		}
+
+		post.Content = postContent
+	}
+
+	post, err = h.Service.UpdatePost(c.UserContext(), *post)
+	if err != nil {
+		return err
+	}
+
+	resource := jsonapi.PostResource(*post, nil, false)
+	return jsonapi.Send(c, fiber.StatusOK, jsonapi.Document{Data: &resource})
+}
+
+// DeletePost function removes a post by ID
+// @Summary Remove post by ID
+// @Description Remove post by ID
+// @Tags posts v2
+// @Param id path int true "post id"
+// @Success 204 "No Content - Successful deletion"
+// @Failure 400 {object} http.Problem
+// @Failure 404 {object} http.Problem
+// @Router /api/v2/posts/{id} [delete]
+func (h *Handler) DeletePost(c *fiber.Ctx) error {
+	postID, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError(errors.New("invalid post id"))
+	}
+
+	if err := h.Service.DeletePost(c.UserContext(), postID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// includedComments bounds the comments included with every post, the first
+// page of them. The relationship links to the others.
+var includedComments = jsonapi.Page{Number: 1, Size: jsonapi.DefaultPageSize}
+
+// document renders posts as the primary data, with the first page of their
+// comments included when asked for. The pages of all posts are loaded at once.
+func (h *Handler) document(c *fiber.Ctx, posts []domain.Post, withComments bool) (*jsonapi.Document, error) {
+	var byPost map[uint]*applicationPostComment.PaginatedComments
+	if withComments {
+		ids := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			ids = append(ids, post.Id)
+		}
+
+		var err error
+		byPost, err = h.Comments.FindPaginatedCommentsByPostIds(c.UserContext(), ids, includedComments.Number, includedComments.Size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc := &jsonapi.Document{}
+	data := make([]jsonapi.Resource, 0, len(posts))
+	for _, post := range posts {
+		if !withComments {
+			data = append(data, jsonapi.PostResource(post, nil, false))
+			continue
+		}
+
+		comments := byPost[post.Id]
+		resource := jsonapi.PostResource(post, comments.Comments, true)
+		jsonapi.PaginateRelationship(resource, "comments", includedComments, comments.TotalCount)
+		data = append(data, resource)
+		doc.Included = append(doc.Included, jsonapi.CommentResources(comments.Comments)...)
+	}
+	doc.Data = data
+
+	return doc, nil
+}
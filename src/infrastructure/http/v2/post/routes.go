@@ -0,0 +1,18 @@
+package httpPostV2
+
+import (
+	"DDD/src/application/post"
+	"DDD/src/application/post_comment"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App, service *applicationPost.PostService, comments *applicationPostComment.PostCommentService) {
+	handler := &Handler{Service: service, Comments: comments}
+	postGroup := app.Group("/api/v2/posts")
+
+	postGroup.Get("/", handler.Paginate)
+	postGroup.Get("/:id", handler.FindPost)
+	postGroup.Post("/", handler.CreatePost)
+	postGroup.Patch("/:id", handler.UpdatePost)
+	postGroup.Delete("/:id", handler.DeletePost)
+}
@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"time"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Middleware makes POST requests carrying an Idempotency-Key safe to retry.
+// The first request runs and its response is stored for ttl; retries with
+// the same key and payload get that response back without running again.
+// Failures are not stored, so a request that failed can be retried.
+//
+// Keys are scoped by the caller that scope identifies, so that a caller
+// neither replays nor blocks the requests of another by using their key.
+func Middleware(store Store, scope func(c *fiber.Ctx) string, ttl time.Duration, lockTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderKey)
+		if c.Method() != fiber.MethodPost || key == "" {
+			return c.Next()
+		}
+		if len(key) > maxKeyLength {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s must not be longer than %d characters", HeaderKey, maxKeyLength))
+		}
+		key = scope(c) + ":" + key
+
+		ctx := c.UserContext()
+		fingerprint := fingerprint(c)
+
+		stored, err := store.Begin(ctx, key, lockTimeout)
+		if errors.Is(err, ErrInProgress) {
+			return fiber.NewError(fiber.StatusConflict, "a request with this "+HeaderKey+" is still being processed")
+		} else if err != nil {
+			return fmt.Errorf("failed to begin idempotent request: %w", err)
+		}
+
+		if stored != nil {
+			if stored.Fingerprint != fingerprint {
+				return fiber.NewError(fiber.StatusUnprocessableEntity, HeaderKey+" was already used for a different request")
+			}
+
+			return replay(c, stored)
+		}
+
+		if err := c.Next(); err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return errors.Join(err, store.Abort(ctx, key))
+		}
+
+		err = store.Complete(ctx, key, Response{
+			Fingerprint: fingerprint,
+			Status:      c.Response().StatusCode(),
+			ContentType: string(c.Response().Header.ContentType()),
+			Location:    string(c.Response().Header.Peek(fiber.HeaderLocation)),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}, ttl)
+		if err != nil {
+			// The request did succeed: report it, a retry finds the key locked until lockTimeout.
+			slog.WarnContext(ctx, "failed to store idempotent response", slog.String("error", err.Error()))
+		}
+
+		return nil
+	}
+}
+
+// fingerprint identifies the request a key was first used for.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(c *fiber.Ctx, response *Response) error {
+	c.Set(HeaderReplayed, "true")
+	c.Set(fiber.HeaderContentType, response.ContentType)
+	if response.Location != "" {
+		c.Set(fiber.HeaderLocation, response.Location)
+	}
+
+	return c.Status(response.Status).Send(response.Body)
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the responses in the process; retries must reach the same replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	responses map[string]memoryEntry
+	locks     map[string]time.Time
+	now       func() time.Time
+}
+
+type memoryEntry struct {
+	response  Response
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		responses: make(map[string]memoryEntry),
+		locks:     make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key string, lockTimeout time.Duration) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if entry, ok := s.responses[key]; ok {
+		return &entry.response, nil
+	}
+	if _, ok := s.locks[key]; ok {
+		return nil, ErrInProgress
+	}
+
+	s.locks[key] = now.Add(lockTimeout)
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, response Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locks, key)
+	s.responses[key] = memoryEntry{response: response, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Abort(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locks, key)
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.responses {
+		if now.After(entry.expiresAt) {
+			delete(s.responses, key)
+		}
+	}
+	for key, expiresAt := range s.locks {
+		if now.After(expiresAt) {
+			delete(s.locks, key)
+		}
+	}
+}
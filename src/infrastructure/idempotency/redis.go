@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// RedisStore shares the responses between replicas.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key string, lockTimeout time.Duration) (*Response, error) {
+	if response, err := s.get(ctx, key); response != nil || err != nil {
+		return response, err
+	}
+
+	locked, err := s.client.SetNX(ctx, s.lockKey(key), 1, lockTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrInProgress
+	}
+
+	// The holder of the previous lock may have completed between the two calls.
+	response, err := s.get(ctx, key)
+	if response != nil || err != nil {
+		return response, errors.Join(err, s.Abort(ctx, key))
+	}
+
+	return nil, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, response Response, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.responseKey(key), data, ttl)
+		pipe.Del(ctx, s.lockKey(key))
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Abort(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.lockKey(key)).Err()
+}
+
+func (s *RedisStore) get(ctx context.Context, key string) (*Response, error) {
+	data, err := s.client.Get(ctx, s.responseKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var response Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (s *RedisStore) responseKey(key string) string {
+	return s.prefix + key
+}
+
+func (s *RedisStore) lockKey(key string) string {
+	return s.prefix + key + ":lock"
+}
@@ -0,0 +1,30 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInProgress is returned while another request holds the key.
+var ErrInProgress = errors.New("request with this idempotency key is in progress")
+
+// Response is what a request with an idempotency key produced, replayed to its retries.
+type Response struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// Store keeps the responses of idempotent requests.
+type Store interface {
+	// Begin returns the stored response of key, if any. Otherwise it locks key
+	// for lockTimeout, and the caller must Complete or Abort it.
+	Begin(ctx context.Context, key string, lockTimeout time.Duration) (*Response, error)
+	// Complete stores the response for ttl and releases the lock.
+	Complete(ctx context.Context, key string, response Response, ttl time.Duration) error
+	// Abort releases the lock without storing anything, so that the request can be retried.
+	Abort(ctx context.Context, key string) error
+}
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	applicationJob "DDD/src/application/job"
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// Queues maps every queue worked on to how many of its jobs run at once.
+	Queues map[string]int
+	// PollInterval is how often a queue with free slots is polled for due jobs.
+	PollInterval time.Duration
+	// Lease bounds every run. Once it runs out, the job is taken over by
+	// another worker, so that the jobs of a lost worker are not stuck.
+	Lease time.Duration
+}
+
+// Handler runs a job. Returning an error fails the attempt.
+type Handler func(ctx context.Context, job *domain.Job) error
+
+// Worker runs the due jobs of its queues with the handlers registered for
+// their types, and records the outcome.
+type Worker struct {
+	service  *applicationJob.JobService
+	logger   *slog.Logger
+	options  Options
+	handlers map[string]Handler
+}
+
+func NewWorker(service *applicationJob.JobService, logger *slog.Logger, options Options) *Worker {
+	return &Worker{
+		service:  service,
+		logger:   logger,
+		options:  options,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler of a job type. Handlers are registered before the worker runs.
+func (w *Worker) Register(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Handle registers fn for the jobs of a kind, with their payloads decoded.
+func Handle[T any](w *Worker, kind applicationJob.Kind[T], fn func(ctx context.Context, payload T) error) {
+	w.Register(kind.Type, func(ctx context.Context, job *domain.Job) error {
+		payload, err := kind.Decode(job)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, payload)
+	})
+}
+
+// Run works on every queue until ctx is cancelled, then waits for the running jobs.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for queue, concurrency := range w.options.Queues {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(ctx, queue, concurrency)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// run keeps up to concurrency jobs of the queue running. It claims as many
+// jobs as there are free slots, and polls again when a job ends while the
+// queue has more, or every interval otherwise.
+func (w *Worker) run(ctx context.Context, queue string, concurrency int) {
+	ticker := time.NewTicker(w.options.PollInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	slots := make(chan struct{}, concurrency)
+	ended := make(chan struct{}, 1)
+	for {
+		free := concurrency - len(slots)
+		more := false
+		if free > 0 {
+			due, err := w.service.ClaimDue(ctx, queue, w.options.Lease, free)
+			if err != nil && ctx.Err() == nil {
+				w.logger.Error("failed to claim jobs", slog.String("queue", queue), slog.String("error", err.Error()))
+			}
+
+			for _, job := range due {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					w.perform(ctx, &job)
+					<-slots
+					select {
+					case ended <- struct{}{}:
+					default:
+					}
+				}()
+			}
+			more = err == nil && len(due) == free
+		}
+
+		var next <-chan struct{}
+		if more || free == 0 {
+			next = ended
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-next:
+		}
+	}
+}
+
+// Work runs one batch of the due jobs of a queue, as many as it runs at once,
+// and tells how many it ran.
+func (w *Worker) Work(ctx context.Context, queue string) (int, error) {
+	due, err := w.service.ClaimDue(ctx, queue, w.options.Lease, max(w.options.Queues[queue], 1))
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.perform(ctx, &job)
+		}()
+	}
+	wg.Wait()
+
+	return len(due), nil
+}
+
+// perform runs a job once, and records the outcome.
+func (w *Worker) perform(ctx context.Context, job *domain.Job) {
+	started := time.Now()
+
+	runCtx, cancel := context.WithTimeout(ctx, w.options.Lease)
+	err := w.handle(runCtx, job)
+	cancel()
+	if err != nil && ctx.Err() != nil {
+		// The handler was stopped rather than failing by itself, so no failure
+		// is recorded. The job is claimed again once its lease ends.
+		return
+	}
+
+	// Recorded even when shutting down, the job is done.
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		err = w.service.RecordSuccess(ctx, job)
+	} else {
+		w.logger.Warn("job failed",
+			slog.Uint64("job", uint64(job.Id)),
+			slog.String("type", job.Type),
+			slog.Int("attempt", job.Attempts),
+			slog.Duration("elapsed", time.Since(started)),
+			slog.String("error", err.Error()),
+		)
+		err = w.service.RecordFailure(ctx, job, err.Error())
+	}
+
+	if errors.Is(err, domain.ErrConflict) {
+		// Another worker claimed the job past the lease of this run, its own
+		// run decides the outcome.
+		w.logger.Warn("job taken over, outcome discarded", slog.Uint64("job", uint64(job.Id)))
+	} else if err != nil {
+		w.logger.Error("failed to record the outcome of a job", slog.Uint64("job", uint64(job.Id)), slog.String("error", err.Error()))
+	}
+}
+
+// handle runs the handler of the job, turning panics into failures.
+func (w *Worker) handle(ctx context.Context, job *domain.Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for jobs of type %q", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("job panicked", slog.Uint64("job", uint64(job.Id)), slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
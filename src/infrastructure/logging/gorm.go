@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log/slog"
+	"time"
+)
+
+// GormLogger writes GORM logs through slog, so that every query log carries
+// the request id and route of its context. Queries are logged at debug level.
+type GormLogger struct {
+	logger        *slog.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+func NewGormLogger(l *slog.Logger, level slog.Level, slowThreshold time.Duration) *GormLogger {
+	gormLevel := logger.Warn
+	switch {
+	case level <= slog.LevelDebug:
+		gormLevel = logger.Info
+	case level >= slog.LevelError:
+		gormLevel = logger.Error
+	}
+
+	return &GormLogger{logger: l, level: gormLevel, slowThreshold: slowThreshold}
+}
+
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+
+	return &copied
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []slog.Attr {
+		sql, rows := fc()
+		return []slog.Attr{
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.logger.LogAttrs(ctx, slog.LevelError, "query failed", append(attrs(), slog.String("error", err.Error()))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "slow query", append(attrs(), slog.Duration("threshold", l.slowThreshold))...)
+	case l.level >= logger.Info:
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "query", attrs()...)
+	}
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"DDD/src/infrastructure/config"
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// New creates the application logger. Records logged with a request context
+// carry the request id and route, and the trace and span ids when traced.
+func New(cfg config.Log, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: Level(cfg.Level)}
+
+	var handler slog.Handler
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+func Level(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type requestKey struct{}
+
+// Request describes the request a context belongs to.
+type Request struct {
+	Id string
+
+	mu    sync.Mutex
+	route func() string
+}
+
+// NewRequest describes a request whose route is resolved by route until Finish is called.
+func NewRequest(id string, route func() string) *Request {
+	return &Request{Id: id, route: route}
+}
+
+// Route returns the route pattern that matched the request.
+func (r *Request) Route() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.route()
+}
+
+// Finish freezes the route, so it stays readable after the request is gone.
+func (r *Request) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route := r.route()
+	r.route = func() string { return route }
+}
+
+func WithRequest(ctx context.Context, request *Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+func RequestFromContext(ctx context.Context) (*Request, bool) {
+	request, ok := ctx.Value(requestKey{}).(*Request)
+	return request, ok
+}
+
+// RequestId returns the id of the request ctx belongs to, or an empty string.
+func RequestId(ctx context.Context) string {
+	if request, ok := RequestFromContext(ctx); ok {
+		return request.Id
+	}
+
+	return ""
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if request, ok := RequestFromContext(ctx); ok {
+		record.AddAttrs(slog.String("request_id", request.Id), slog.String("route", request.Route()))
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
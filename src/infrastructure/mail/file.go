@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes every message to a .eml file of its directory, which
+// mail clients open, for development.
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) *FileTransport {
+	return &FileTransport{dir: dir}
+}
+
+func (t *FileTransport) Send(_ context.Context, message Message) error {
+	content, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(t.dir, name), content, 0o644)
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport keeps the messages instead of sending them, for tests.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(_ context.Context, message Message) error {
+	if _, err := message.Bytes(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, message)
+
+	return nil
+}
+
+// Messages are the messages sent so far, oldest first.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Message(nil), t.messages...)
+}
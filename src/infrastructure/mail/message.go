@@ -0,0 +1,107 @@
+// Package mail builds emails and sends them through a transport: SMTP, files
+// for development, or memory for tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Message is an email with a text and an HTML alternative.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	Html    string
+	// Headers are added as is, such as List-Unsubscribe.
+	Headers map[string]string
+}
+
+// Transport sends messages.
+type Transport interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Bytes renders the message as a multipart/alternative MIME document.
+func (m Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", m.From, err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", m.To, err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var out bytes.Buffer
+	body := multipart.NewWriter(&out)
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", to.String())
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/alternative; boundary="+body.Boundary())
+	for name, value := range m.Headers {
+		header.Set(name, value)
+	}
+
+	var head bytes.Buffer
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(&head, "%s: %s\r\n", name, value)
+		}
+	}
+	head.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.Html},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(w)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), out.Bytes()...), nil
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/http"
+	"crypto/subtle"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"strconv"
+	"time"
+)
+
+// Middleware records the count and latency of every request by route pattern,
+// and the validation failures of its value objects. It must be registered
+// after AccessLog, which renders errors, so that it sees the error returned by the handler.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = http.NewProblem(err).Status
+
+			var validationErr *value_object.ValidationError
+			if errors.As(err, &validationErr) {
+				m.ValidationFailed(validationErr.ValueObject)
+			}
+		}
+
+		// Label values outlive the request, and fiber reuses the method buffer.
+		method := utils.CopyString(c.Method())
+		route := c.Route().Path
+		m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		m.requestDuration.WithLabelValues(method, route).Observe(time.Since(started).Seconds())
+
+		return err
+	}
+}
+
+// Handler serves the registry in the Prometheus text format. When the token
+// is set, scrapers must send it as a bearer token.
+func (m *Metrics) Handler(token config.Secret) fiber.Handler {
+	handler := adaptor.HTTPHandler(promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}))
+	expected := []byte("Bearer " + token.Reveal())
+
+	return func(c *fiber.Ctx) error {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), expected) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="metrics"`)
+			return fiber.ErrUnauthorized
+		}
+
+		return handler(c)
+	}
+}
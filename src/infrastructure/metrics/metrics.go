@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"DDD/src/domain"
+	"context"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "ddd"
+
+// Metrics owns the Prometheus registry of the application. It uses a registry
+// of its own rather than the global one, so that tests can create as many as they need.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests           *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	postsCreated       prometheus.Counter
+	commentsCreated    prometheus.Counter
+	validationFailures *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_created_total",
+			Help:      "Posts created.",
+		}),
+		commentsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "comments_created_total",
+			Help:      "Comments created.",
+		}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "validation_failures_total",
+			Help:      "Rejected input by the value object that rejected it.",
+		}, []string{"value_object"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.postsCreated,
+		m.commentsCreated,
+		m.validationFailures,
+	)
+
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// HandleEvent counts the business events; subscribe it to the event bus.
+func (m *Metrics) HandleEvent(_ context.Context, event domain.Event) {
+	switch event.(type) {
+	case domain.PostCreated:
+		m.postsCreated.Inc()
+	case domain.CommentCreated:
+		m.commentsCreated.Inc()
+	}
+}
+
+// ValidationFailed counts input rejected by a value object.
+func (m *Metrics) ValidationFailed(valueObject string) {
+	m.validationFailures.WithLabelValues(valueObject).Inc()
+}
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	applicationNotification "DDD/src/application/notification"
+	"DDD/src/infrastructure/mail"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Options struct {
+	// Timeout bounds sending one email.
+	Timeout time.Duration
+	// BatchSize is how many recipients are emailed per poll at most.
+	BatchSize int
+}
+
+// Notifier emails the due digests, one recipient after the other, when their
+// jobs run.
+type Notifier struct {
+	service   *applicationNotification.NotificationService
+	transport mail.Transport
+	renderer  Renderer
+	logger    *slog.Logger
+	options   Options
+}
+
+func NewNotifier(service *applicationNotification.NotificationService, transport mail.Transport, renderer Renderer, logger *slog.Logger, options Options) *Notifier {
+	return &Notifier{
+		service:   service,
+		transport: transport,
+		renderer:  renderer,
+		logger:    logger,
+		options:   options,
+	}
+}
+
+// SendDigest handles the DigestJob: it emails the due digests, that of its
+// recipient among them, and queues the job again for the notifications of the
+// recipient that are still pending.
+func (n *Notifier) SendDigest(ctx context.Context, payload applicationNotification.DigestJobPayload) error {
+	if _, err := n.Notify(ctx); err != nil {
+		return err
+	}
+
+	return n.service.FollowUp(ctx, payload.Email)
+}
+
+// Notify emails one batch of due digests and tells how many it attempted.
+func (n *Notifier) Notify(ctx context.Context) (int, error) {
+	// Digests are sent one after the other, each within the timeout, and one
+	// more timeout leaves room to record the last of them.
+	lease := time.Duration(n.options.BatchSize+1) * n.options.Timeout
+	digests, err := n.service.ClaimDue(ctx, lease, n.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range digests {
+		if err := n.send(ctx, &digests[i]); err != nil {
+			return i + 1, err
+		}
+	}
+
+	return len(digests), nil
+}
+
+// send emails a digest once. Failures of the transport are recorded on the
+// notifications, only failures to record them are returned.
+func (n *Notifier) send(ctx context.Context, digest *applicationNotification.Digest) error {
+	message, err := n.renderer.Render(digest)
+	if err == nil {
+		sendCtx, cancel := context.WithTimeout(ctx, n.options.Timeout)
+		err = n.transport.Send(sendCtx, message)
+		cancel()
+	}
+	if ctx.Err() != nil {
+		// Nothing is recorded: the notifications stay claimed, and the next
+		// digest job claims them once their lease ends.
+		return ctx.Err()
+	}
+
+	if err == nil {
+		return n.service.RecordSent(context.WithoutCancel(ctx), digest)
+	}
+
+	attrs := []any{slog.Int("comments", len(digest.Items)), slog.String("error", err.Error())}
+	if len(digest.Notifications) > 0 {
+		attrs = append(attrs, slog.Int("attempt", digest.Notifications[0].Attempts+1))
+	}
+	n.logger.Warn("notification email failed", attrs...)
+
+	return n.service.RecordFailure(context.WithoutCancel(ctx), digest, err.Error())
+}
@@ -0,0 +1,148 @@
+// Package notification emails the authors of posts about new comments.
+package notification
+
+import (
+	applicationNotification "DDD/src/application/notification"
+	"DDD/src/infrastructure/mail"
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+var (
+	htmlDigest  = htmlTemplate.Must(htmlTemplate.ParseFS(templates, "templates/digest.html.tmpl"))
+	textDigest  = textTemplate.Must(textTemplate.ParseFS(templates, "templates/digest.txt.tmpl"))
+	htmlConfirm = htmlTemplate.Must(htmlTemplate.ParseFS(templates, "templates/confirm.html.tmpl"))
+	textConfirm = textTemplate.Must(textTemplate.ParseFS(templates, "templates/confirm.txt.tmpl"))
+)
+
+type digestView struct {
+	AppName        string
+	PreferencesUrl string
+	Posts          []postView
+}
+
+type confirmView struct {
+	AppName        string
+	PreferencesUrl string
+	ConfirmUrl     string
+}
+
+type postView struct {
+	Title    string
+	Url      string
+	Comments []commentView
+}
+
+type commentView struct {
+	Text      string
+	CreatedAt time.Time
+}
+
+// Renderer turns digests into emails.
+type Renderer struct {
+	// AppName signs the emails.
+	AppName string
+	// From is the sender of the emails.
+	From string
+	// BaseUrl is where the API is reached, for the links of the emails.
+	BaseUrl string
+}
+
+// PreferencesUrl is where the recipient of a token sees and changes their preferences.
+func (r Renderer) PreferencesUrl(token string) string {
+	return strings.TrimSuffix(r.BaseUrl, "/") + "/api/v1/notifications/preferences/" + token
+}
+
+// Render builds the email of a digest, the comments grouped by post, or the
+// request to confirm the address.
+func (r Renderer) Render(digest *applicationNotification.Digest) (mail.Message, error) {
+	if digest.Confirmation {
+		return r.renderConfirmation(digest)
+	}
+
+	view := digestView{AppName: r.AppName, PreferencesUrl: r.PreferencesUrl(digest.Preference.Token)}
+
+	byPost := make(map[uint]int)
+	for _, item := range digest.Items {
+		i, ok := byPost[item.Post.Id]
+		if !ok {
+			i = len(view.Posts)
+			byPost[item.Post.Id] = i
+			view.Posts = append(view.Posts, postView{
+				Title: item.Post.Title.String(),
+				Url:   strings.TrimSuffix(r.BaseUrl, "/") + "/api/v1/posts/" + strconv.FormatUint(uint64(item.Post.Id), 10),
+			})
+		}
+		view.Posts[i].Comments = append(view.Posts[i].Comments, commentView{
+			Text:      item.Comment.Text.String(),
+			CreatedAt: item.Comment.CreatedAt,
+		})
+	}
+
+	var html, text bytes.Buffer
+	if err := htmlDigest.Execute(&html, view); err != nil {
+		return mail.Message{}, err
+	}
+	if err := textDigest.Execute(&text, view); err != nil {
+		return mail.Message{}, err
+	}
+
+	return r.message(digest, subjectOf(view), view.PreferencesUrl, html, text), nil
+}
+
+// renderConfirmation builds the request to confirm the address, which tells
+// nothing of the comments: whoever gave the address may not own it.
+func (r Renderer) renderConfirmation(digest *applicationNotification.Digest) (mail.Message, error) {
+	preferencesUrl := r.PreferencesUrl(digest.Preference.Token)
+	view := confirmView{AppName: r.AppName, PreferencesUrl: preferencesUrl, ConfirmUrl: preferencesUrl + "/confirm"}
+
+	var html, text bytes.Buffer
+	if err := htmlConfirm.Execute(&html, view); err != nil {
+		return mail.Message{}, err
+	}
+	if err := textConfirm.Execute(&text, view); err != nil {
+		return mail.Message{}, err
+	}
+
+	return r.message(digest, "Confirm your address for comment notifications on "+r.AppName, preferencesUrl, html, text), nil
+}
+
+func (r Renderer) message(digest *applicationNotification.Digest, subject, preferencesUrl string, html, text bytes.Buffer) mail.Message {
+	return mail.Message{
+		From:    r.From,
+		To:      digest.Preference.Email.String(),
+		Subject: subject,
+		Text:    text.String(),
+		Html:    html.String(),
+		Headers: map[string]string{
+			// One-click unsubscribe, RFC 8058.
+			"List-Unsubscribe":      "<" + preferencesUrl + "/unsubscribe>",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
+
+func subjectOf(view digestView) string {
+	comments := 0
+	for _, post := range view.Posts {
+		comments += len(post.Comments)
+	}
+
+	switch {
+	case comments == 1:
+		return fmt.Sprintf("New comment on %q", view.Posts[0].Title)
+	case len(view.Posts) == 1:
+		return fmt.Sprintf("%d new comments on %q", comments, view.Posts[0].Title)
+	default:
+		return fmt.Sprintf("%d new comments on your posts", comments)
+	}
+}
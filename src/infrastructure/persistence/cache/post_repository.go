@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+type Options struct {
+	// Prefix namespaces the keys, so that several applications can share a Redis.
+	Prefix  string
+	PostTTL time.Duration
+	PageTTL time.Duration
+	// Pages is how many of the first listing pages are cached; later pages always hit the database.
+	Pages int
+}
+
+// PostRepository caches FindById and the first pages of Paginate in Redis in
+// front of another domain.PostRepository.
+//
+// Entries are dropped by HandleEvent, subscribed to the event bus, once for
+// every committed change, whether it was written through the decorator or in
+// a unit of work. A read that missed may load a post just before a change and
+// store it just after, so the writes of entries are guarded:
+//   - a post is only stored when its version is not below the floor that
+//     dropping it left, which a deletion raises past every version;
+//   - listing pages are keyed by a generation, read before the page is
+//     loaded and bumped by every change, so a page loaded before a change is
+//     stored under a generation no longer read.
+//
+// Redis failures are logged and fall through to the wrapped repository.
+type PostRepository struct {
+	next    domain.PostRepository
+	client  redis.UniversalClient
+	options Options
+	loads   singleflight.Group
+}
+
+func NewPostRepository(next domain.PostRepository, client redis.UniversalClient, options Options) *PostRepository {
+	return &PostRepository{next: next, client: client, options: options}
+}
+
+type cachedPage struct {
+	Posts []cachedPost `json:"posts"`
+	Total int64        `json:"total"`
+}
+
+func newCachedPage(posts []domain.Post, total int64) cachedPage {
+	page := cachedPage{Posts: make([]cachedPost, len(posts)), Total: total}
+	for i, post := range posts {
+		page.Posts[i] = newCachedPost(post)
+	}
+
+	return page
+}
+
+func (p cachedPage) posts() []domain.Post {
+	posts := make([]domain.Post, len(p.Posts))
+	for i, post := range p.Posts {
+		posts[i] = post.post()
+	}
+
+	return posts
+}
+
+// cachedPost keeps the address of the author, which a post leaves out of its JSON.
+type cachedPost struct {
+	domain.Post
+	AuthorEmail value_object.Email `json:"authorEmail,omitempty"`
+}
+
+func newCachedPost(post domain.Post) cachedPost {
+	return cachedPost{Post: post, AuthorEmail: post.AuthorEmail}
+}
+
+func (p cachedPost) post() domain.Post {
+	post := p.Post
+	post.AuthorEmail = p.AuthorEmail
+	return post
+}
+
+func (r *PostRepository) FindById(ctx context.Context, id int) (*domain.Post, error) {
+	key := r.postKey(uint(id))
+
+	var cached cachedPost
+	if r.get(ctx, key, &cached) {
+		post := cached.post()
+		return &post, nil
+	}
+
+	loaded, err, _ := r.loads.Do(key, func() (any, error) {
+		// Shared by every caller waiting on the key, so one of them giving up must not fail the others.
+		ctx := context.WithoutCancel(ctx)
+
+		post, err := r.next.FindById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		r.set(ctx, r.options.PostTTL, func(pipe redis.Pipeliner, value func(any) []byte) {
+			setIfNotStale.Eval(ctx, pipe, []string{key, r.floorKey(post.Id)},
+				value(newCachedPost(*post)), post.Version, r.options.PostTTL.Milliseconds())
+		})
+
+		return post, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	post := *loaded.(*domain.Post)
+	return &post, nil
+}
+
+// FindRevision always asks the wrapped repository: it is about as cheap as a
+// cache lookup and never stale.
+func (r *PostRepository) FindRevision(ctx context.Context, id int) (*domain.Revision, error) {
+	return r.next.FindRevision(ctx, id)
+}
+
+func (r *PostRepository) Paginate(ctx context.Context, page int, perPage int) ([]domain.Post, int64, error) {
+	if page > r.options.Pages {
+		return r.next.Paginate(ctx, page, perPage)
+	}
+
+	generation, err := r.client.Get(ctx, r.generationKey()).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.warn(ctx, "cache read failed", err)
+		return r.next.Paginate(ctx, page, perPage)
+	}
+
+	key := r.pageKey(generation, page, perPage)
+
+	var cached cachedPage
+	if r.get(ctx, key, &cached) {
+		return cached.posts(), cached.Total, nil
+	}
+
+	loaded, err, _ := r.loads.Do(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+
+		posts, total, err := r.next.Paginate(ctx, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded := newCachedPage(posts, total)
+		r.set(ctx, r.options.PageTTL, func(pipe redis.Pipeliner, value func(any) []byte) {
+			pipe.Set(ctx, key, value(loaded), r.options.PageTTL)
+		})
+
+		return loaded, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cached = loaded.(cachedPage)
+	return cached.posts(), cached.Total, nil
+}
+
+func (r *PostRepository) PaginateRevisions(ctx context.Context, page int, perPage int) ([]domain.Revision, int64, error) {
+	return r.next.PaginateRevisions(ctx, page, perPage)
+}
+
+// Writes go straight to the wrapped repository: the events of the committed
+// changes drop the entries they made stale.
+
+func (r *PostRepository) Create(ctx context.Context, post *domain.Post) error {
+	return r.next.Create(ctx, post)
+}
+
+func (r *PostRepository) Update(ctx context.Context, post *domain.Post) error {
+	return r.next.Update(ctx, post)
+}
+
+func (r *PostRepository) Delete(ctx context.Context, id int) error {
+	return r.next.Delete(ctx, id)
+}
+
+// deletedFloor is the floor a deleted post leaves, past every version.
+const deletedFloor = 1 << 53
+
+// setIfNotStale stores the entry of a post unless its version, ARGV[2], is
+// below the floor, KEYS[2].
+var setIfNotStale = redis.NewScript(`
+local floor = tonumber(redis.call('GET', KEYS[2]) or '0')
+if tonumber(ARGV[2]) < floor then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+return 1
+`)
+
+// drop deletes the entry of a post, KEYS[1], and raises its floor, KEYS[2],
+// to the version of the change, ARGV[1].
+var drop = redis.NewScript(`
+local floor = tonumber(redis.call('GET', KEYS[2]) or '0')
+if tonumber(ARGV[1]) > floor then
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+end
+redis.call('DEL', KEYS[1])
+return 1
+`)
+
+// HandleEvent drops the entries a committed change made stale; subscribe it to the event bus.
+func (r *PostRepository) HandleEvent(ctx context.Context, event domain.Event) {
+	var err error
+
+	switch event := event.(type) {
+	case domain.PostCreated:
+		err = r.client.Incr(ctx, r.generationKey()).Err()
+	case domain.PostUpdated:
+		err = errors.Join(r.dropPost(ctx, event.Post.Id, event.Post.Version), r.client.Incr(ctx, r.generationKey()).Err())
+	case domain.PostDeleted:
+		err = errors.Join(r.dropPost(ctx, event.PostId, deletedFloor), r.client.Incr(ctx, r.generationKey()).Err())
+	}
+
+	if err != nil {
+		r.warn(ctx, "cache invalidation failed", err, slog.String("event", event.EventName()))
+	}
+}
+
+// dropPost deletes a post, and keeps a post loaded before version from being
+// stored for as long as it could be cached.
+func (r *PostRepository) dropPost(ctx context.Context, id uint, version uint) error {
+	if r.options.PostTTL <= 0 {
+		return nil
+	}
+
+	return drop.Run(ctx, r.client, []string{r.postKey(id), r.floorKey(id)}, version, r.options.PostTTL.Milliseconds()).Err()
+}
+
+// get reports whether key was found and decoded into value.
+func (r *PostRepository) get(ctx context.Context, key string, value any) bool {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false
+	} else if err != nil {
+		r.warn(ctx, "cache read failed", err, slog.String("key", key))
+		return false
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		r.warn(ctx, "cache entry is corrupt", err, slog.String("key", key))
+		return false
+	}
+
+	return true
+}
+
+// set writes entries in one round trip, unless ttl disables caching.
+func (r *PostRepository) set(ctx context.Context, ttl time.Duration, fn func(pipe redis.Pipeliner, value func(any) []byte)) {
+	if ttl <= 0 {
+		return
+	}
+
+	var encodeErr error
+	value := func(v any) []byte {
+		data, err := json.Marshal(v)
+		encodeErr = errors.Join(encodeErr, err)
+		return data
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fn(pipe, value)
+		return encodeErr
+	})
+	if err != nil {
+		r.warn(ctx, "cache write failed", err)
+	}
+}
+
+func (r *PostRepository) warn(ctx context.Context, msg string, err error, attrs ...slog.Attr) {
+	slog.LogAttrs(ctx, slog.LevelWarn, msg, append(attrs, slog.String("error", err.Error()))...)
+}
+
+func (r *PostRepository) postKey(id uint) string {
+	return r.options.Prefix + "post:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func (r *PostRepository) floorKey(id uint) string {
+	return r.postKey(id) + ":floor"
+}
+
+func (r *PostRepository) generationKey() string {
+	return r.options.Prefix + "posts:generation"
+}
+
+func (r *PostRepository) pageKey(generation int64, page int, perPage int) string {
+	return fmt.Sprintf("%sposts:%d:page:%d:%d", r.options.Prefix, generation, page, perPage)
+}
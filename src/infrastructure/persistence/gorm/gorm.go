@@ -1,36 +1,29 @@
 package gorm
 
 import (
-	"DDD/src/infrastructure/persistence/gorm/migrations"
+	"DDD/src/infrastructure/config"
 	"fmt"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-	"log"
-	"os"
-	"time"
 )
 
-func NewGormConnection(connString string) (*gorm.DB, error) {
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
-		logger.Config{
-			SlowThreshold: time.Second,
-			LogLevel:      logger.Info,
-			Colorful:      true,
-		},
-	)
+func NewGormConnection(cfg config.Database, log logger.Interface) (*gorm.DB, error) {
+	dialector, err := NewDialector(cfg.Connection.Reveal())
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(connString), &gorm.Config{
-		Logger: newLogger,
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         log,
+		TranslateError: true,
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := migrations.NewGormMigrator(db).Run(); err != nil {
-		return nil, fmt.Errorf("failed to migrate: %w", err)
+	if err := configurePool(db, cfg); err != nil {
+		return nil, fmt.Errorf("failed to configure connection pool: %w", err)
 	}
 
 	return db, nil
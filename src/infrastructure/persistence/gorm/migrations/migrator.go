@@ -1,10 +1,40 @@
 package migrations
 
 import (
-	"DDD/src/domain"
+	"context"
+	"embed"
+	"fmt"
 	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 )
 
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// advisoryLockId identifies the PostgreSQL advisory lock held while migrating,
+// so that replicas starting at the same time do not race each other.
+const advisoryLockId = 7_301_984_512
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt"`
+}
+
 type GormMigrator struct {
 	db *gorm.DB
 }
@@ -13,9 +43,223 @@ func NewGormMigrator(db *gorm.DB) *GormMigrator {
 	return &GormMigrator{db: db}
 }
 
-func (m *GormMigrator) Run() error {
-	return m.db.AutoMigrate(
-		&domain.Post{},
-		&domain.PostComment{},
-	)
+// Up applies all pending migrations in version order.
+func (m *GormMigrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error {
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(db, migration); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *GormMigrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error {
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			if _, ok := applied[migrations[i].Version]; !ok {
+				continue
+			}
+
+			if err := m.revert(db, migrations[i]); err != nil {
+				return err
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Redo rolls back the last applied migration and applies it again.
+func (m *GormMigrator) Redo(ctx context.Context) error {
+	return m.locked(ctx, func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[migrations[i].Version]; !ok {
+				continue
+			}
+
+			if err := m.revert(db, migrations[i]); err != nil {
+				return err
+			}
+
+			return m.apply(db, migrations[i])
+		}
+
+		return nil
+	})
+}
+
+// Status lists every known migration and when it was applied.
+func (m *GormMigrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.connection(ctx, false, func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error {
+		for _, migration := range migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Pending reports how many migrations have not been applied yet.
+func (m *GormMigrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// locked runs fn on a single connection holding the migration lock.
+func (m *GormMigrator) locked(ctx context.Context, fn func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error) error {
+	return m.connection(ctx, true, fn)
+}
+
+// connection runs fn on a single connection with the known and applied migrations.
+// Only PostgreSQL has advisory locks; SQLite already allows a single writer.
+func (m *GormMigrator) connection(ctx context.Context, lock bool, fn func(db *gorm.DB, migrations []Migration, applied map[int]time.Time) error) error {
+	migrations, err := Load(m.db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+
+	return m.db.WithContext(ctx).Connection(func(db *gorm.DB) error {
+		if lock && db.Dialector.Name() == "postgres" {
+			if err := db.Exec("SELECT pg_advisory_lock(?)", advisoryLockId).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", advisoryLockId)
+		}
+
+		if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint PRIMARY KEY,
+			name       varchar(255) NOT NULL,
+			applied_at timestamp NOT NULL
+		)`).Error; err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+
+		var rows []struct {
+			Version   int
+			AppliedAt time.Time
+		}
+		if err := db.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+
+		applied := make(map[int]time.Time, len(rows))
+		for _, row := range rows {
+			applied[row.Version] = row.AppliedAt
+		}
+
+		return fn(db, migrations, applied)
+	})
+}
+
+func (m *GormMigrator) apply(db *gorm.DB, migration Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC(),
+		).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func (m *GormMigrator) revert(db *gorm.DB, migration Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+// Load reads the embedded migrations of a dialect, sorted by version.
+func Load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
 }
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS post_comments;
+DROP TABLE IF EXISTS posts;
@@ -0,0 +1,24 @@
+-- IF NOT EXISTS adopts databases created by the former AutoMigrate.
+CREATE TABLE IF NOT EXISTS posts (
+    id         bigserial PRIMARY KEY,
+    title      varchar(255) NOT NULL,
+    content    text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_comments (
+    id         bigserial PRIMARY KEY,
+    post_id    bigint NOT NULL,
+    text       text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_comments_post_id ON post_comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_post_comments_deleted_at ON post_comments (deleted_at);
@@ -0,0 +1,28 @@
+CREATE TABLE webhooks (
+    id         bigserial PRIMARY KEY,
+    url        varchar(2048) NOT NULL,
+    secret     varchar(255) NOT NULL,
+    events     text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    webhook_id      bigint NOT NULL,
+    event_id        varchar(36) NOT NULL,
+    event           varchar(64) NOT NULL,
+    payload         text NOT NULL,
+    status          varchar(16) NOT NULL,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz,
+    response_status integer,
+    last_error      text,
+    delivered_at    timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    CONSTRAINT fk_webhooks_deliveries FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,22 @@
+-- The read model of post listings, kept up to date from the events of posts and comments.
+CREATE TABLE post_summaries (
+    post_id          bigint PRIMARY KEY,
+    title            varchar(255) NOT NULL,
+    excerpt          text NOT NULL,
+    version          integer NOT NULL,
+    comment_count    integer NOT NULL DEFAULT 0,
+    last_activity_at timestamptz NOT NULL,
+    created_at       timestamptz,
+    updated_at       timestamptz
+);
+
+CREATE INDEX idx_post_summaries_last_activity_at ON post_summaries (last_activity_at);
+CREATE INDEX idx_post_summaries_comment_count ON post_summaries (comment_count);
+
+-- Summarize the posts already there, as the rebuild command does.
+INSERT INTO post_summaries (post_id, title, excerpt, version, comment_count, last_activity_at, created_at, updated_at)
+SELECT p.id, p.title, COALESCE(substr(p.content, 1, 200), ''), p.version, COUNT(c.id),
+       GREATEST(p.updated_at, MAX(c.updated_at)), p.created_at, p.updated_at
+FROM posts p
+LEFT JOIN post_comments c ON c.post_id = p.id
+GROUP BY p.id;
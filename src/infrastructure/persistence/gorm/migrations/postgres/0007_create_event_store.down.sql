@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS snapshots;
+DROP TABLE IF EXISTS stored_events;
+DROP FUNCTION IF EXISTS stored_events_append_only();
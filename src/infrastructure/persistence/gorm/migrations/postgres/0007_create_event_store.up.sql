@@ -0,0 +1,30 @@
+CREATE TABLE stored_events (
+    id          bigserial PRIMARY KEY,
+    stream_type varchar(64) NOT NULL,
+    stream_id   bigint NOT NULL,
+    version     integer NOT NULL,
+    type        varchar(128) NOT NULL,
+    data        text NOT NULL,
+    recorded_at timestamptz NOT NULL,
+    -- Two writers appending the same version of a stream is a concurrent change.
+    CONSTRAINT uq_stored_events_stream_version UNIQUE (stream_type, stream_id, version)
+);
+
+CREATE FUNCTION stored_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stored_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stored_events_append_only
+    BEFORE UPDATE OR DELETE ON stored_events
+    FOR EACH ROW EXECUTE FUNCTION stored_events_append_only();
+
+CREATE TABLE snapshots (
+    stream_type varchar(64) NOT NULL,
+    stream_id   bigint NOT NULL,
+    version     integer NOT NULL,
+    data        text NOT NULL,
+    created_at  timestamptz,
+    PRIMARY KEY (stream_type, stream_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE notification_preferences DROP COLUMN confirmation_sent_at;
+ALTER TABLE notification_preferences DROP COLUMN confirmed_at;
@@ -0,0 +1,3 @@
+-- Recipients are only notified once they confirmed their address, existing ones included.
+ALTER TABLE notification_preferences ADD COLUMN confirmed_at timestamptz;
+ALTER TABLE notification_preferences ADD COLUMN confirmation_sent_at timestamptz;
@@ -0,0 +1,14 @@
+-- Titles are checked before writing, the index refuses those taken meanwhile by another writer.
+-- Titles taken twice before the index existed keep their oldest post; the later ones get their id appended.
+UPDATE posts
+SET title      = title || ' (' || CAST(id AS varchar(20)) || ')',
+    version    = version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE EXISTS (SELECT 1 FROM posts earlier WHERE earlier.title = posts.title AND earlier.id < posts.id);
+
+UPDATE post_summaries
+SET title   = (SELECT title FROM posts WHERE posts.id = post_summaries.post_id),
+    version = (SELECT version FROM posts WHERE posts.id = post_summaries.post_id)
+WHERE EXISTS (SELECT 1 FROM posts WHERE posts.id = post_summaries.post_id AND posts.title <> post_summaries.title);
+
+CREATE UNIQUE INDEX idx_posts_title ON posts (title);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    title      varchar(255) NOT NULL,
+    content    text,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_comments (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    post_id    integer NOT NULL,
+    text       text NOT NULL,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_comments_post_id ON post_comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_post_comments_deleted_at ON post_comments (deleted_at);
@@ -0,0 +1,2 @@
+-- Incremented by every update, so that validators change even within one timestamp tick.
+ALTER TABLE posts ADD COLUMN version integer NOT NULL DEFAULT 1;
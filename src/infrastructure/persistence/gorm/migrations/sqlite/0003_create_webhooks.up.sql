@@ -0,0 +1,28 @@
+CREATE TABLE webhooks (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    url        varchar(2048) NOT NULL,
+    secret     varchar(255) NOT NULL,
+    events     text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+
+CREATE TABLE webhook_deliveries (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    webhook_id      integer NOT NULL,
+    event_id        varchar(36) NOT NULL,
+    event           varchar(64) NOT NULL,
+    payload         text NOT NULL,
+    status          varchar(16) NOT NULL,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at datetime,
+    response_status integer,
+    last_error      text,
+    delivered_at    datetime,
+    created_at      datetime,
+    updated_at      datetime,
+    CONSTRAINT fk_webhooks_deliveries FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,31 @@
+-- Where comments on a post are notified, none when empty.
+ALTER TABLE posts ADD COLUMN author_email varchar(254);
+
+CREATE TABLE notification_preferences (
+    email      varchar(254) PRIMARY KEY,
+    token      varchar(64) NOT NULL,
+    opted_out  boolean NOT NULL DEFAULT 0,
+    digest     varchar(16) NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+
+CREATE UNIQUE INDEX idx_notification_preferences_token ON notification_preferences (token);
+
+CREATE TABLE notifications (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    email      varchar(254) NOT NULL,
+    post_id    integer NOT NULL,
+    comment_id integer NOT NULL,
+    status     varchar(16) NOT NULL,
+    attempts   integer NOT NULL DEFAULT 0,
+    due_at     datetime,
+    last_error text,
+    sent_at    datetime,
+    created_at datetime,
+    updated_at datetime,
+    CONSTRAINT fk_notifications_comment FOREIGN KEY (comment_id) REFERENCES post_comments (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_due_at ON notifications (due_at) WHERE status = 'pending';
+CREATE INDEX idx_notifications_email ON notifications (email) WHERE status = 'pending';
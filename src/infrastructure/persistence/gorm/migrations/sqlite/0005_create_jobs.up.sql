@@ -0,0 +1,20 @@
+CREATE TABLE jobs (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    queue        varchar(64) NOT NULL,
+    type         varchar(128) NOT NULL,
+    payload      text NOT NULL,
+    status       varchar(16) NOT NULL,
+    attempts     integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL,
+    run_at       datetime NOT NULL,
+    locked_until datetime,
+    last_error   text,
+    finished_at  datetime,
+    created_at   datetime,
+    updated_at   datetime
+);
+
+-- Workers claim the due jobs of a queue, and take over those whose lease ran out.
+CREATE INDEX idx_jobs_run_at ON jobs (queue, run_at) WHERE status = 'pending';
+CREATE INDEX idx_jobs_locked_until ON jobs (queue, locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_status ON jobs (status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS snapshots;
+DROP TABLE IF EXISTS stored_events;
@@ -0,0 +1,30 @@
+CREATE TABLE stored_events (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    stream_type varchar(64) NOT NULL,
+    stream_id   integer NOT NULL,
+    version     integer NOT NULL,
+    type        varchar(128) NOT NULL,
+    data        text NOT NULL,
+    recorded_at datetime NOT NULL,
+    -- Two writers appending the same version of a stream is a concurrent change.
+    CONSTRAINT uq_stored_events_stream_version UNIQUE (stream_type, stream_id, version)
+);
+
+CREATE TRIGGER stored_events_no_update BEFORE UPDATE ON stored_events
+BEGIN
+    SELECT RAISE(ABORT, 'stored_events is append-only');
+END;
+
+CREATE TRIGGER stored_events_no_delete BEFORE DELETE ON stored_events
+BEGIN
+    SELECT RAISE(ABORT, 'stored_events is append-only');
+END;
+
+CREATE TABLE snapshots (
+    stream_type varchar(64) NOT NULL,
+    stream_id   integer NOT NULL,
+    version     integer NOT NULL,
+    data        text NOT NULL,
+    created_at  datetime,
+    PRIMARY KEY (stream_type, stream_id)
+);
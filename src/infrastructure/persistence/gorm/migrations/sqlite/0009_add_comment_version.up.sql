@@ -0,0 +1,2 @@
+-- Incremented by every update, like the version of posts, now that comments are edited.
+ALTER TABLE post_comments ADD COLUMN version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PostStream is the stream type of posts in the event store.
+const PostStream = "post"
+
+// Types of the events of a post stream.
+const (
+	PostCreatedEvent = "post.created"
+	PostUpdatedEvent = "post.updated"
+	PostDeletedEvent = "post.deleted"
+)
+
+// postChange is the data of a post event, the fields it sets.
+type postChange struct {
+	Title       value_object.Title   `json:"title,omitempty"`
+	Content     value_object.Content `json:"content,omitempty"`
+	AuthorEmail value_object.Email   `json:"authorEmail,omitempty"`
+	At          time.Time            `json:"at"`
+}
+
+// postSnapshot is the state of a post, with the address of its author which
+// a post leaves out of its JSON.
+type postSnapshot struct {
+	domain.Post
+	AuthorEmail value_object.Email `json:"authorEmail,omitempty"`
+}
+
+// EventSourcedPostRepository keeps the events of every post in the event
+// store and rebuilds a post from them, starting at its latest snapshot. The
+// version of a post is the version of its stream.
+//
+// The posts table is kept as a projection of the streams, written in the
+// same transaction: comments reference it, titles are checked against it, and
+// listings and revisions are read from it, so that conditional requests check
+// a row rather than replay a stream.
+type EventSourcedPostRepository struct {
+	*PostRepository
+	db *gorm.DB
+	// snapshotEvery events, the state of a post is snapshotted.
+	snapshotEvery uint
+}
+
+func NewEventSourcedPostRepository(db *gorm.DB, snapshotEvery int) domain.PostRepository {
+	return &EventSourcedPostRepository{
+		PostRepository: &PostRepository{db: db},
+		db:             db,
+		snapshotEvery:  uint(max(snapshotEvery, 1)),
+	}
+}
+
+func (r *EventSourcedPostRepository) FindById(ctx context.Context, id int) (*domain.Post, error) {
+	post, _, err := r.load(ctx, r.db, uint(id))
+	return post, err
+}
+
+func (r *EventSourcedPostRepository) Create(ctx context.Context, post *domain.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.checkTitle(tx, post.Title, 0); err != nil {
+			return err
+		}
+
+		now := timestamp()
+		post.Version = 1
+		post.CreatedAt = now
+		post.UpdatedAt = now
+		// The row assigns the id of the stream.
+		if err := tx.Create(post).Error; err != nil {
+			return titleTaken(err, post.Title)
+		}
+
+		return r.append(ctx, tx, post, 0, PostCreatedEvent, postChange{
+			Title:       post.Title,
+			Content:     post.Content,
+			AuthorEmail: post.AuthorEmail,
+			At:          now,
+		})
+	})
+}
+
+func (r *EventSourcedPostRepository) Update(ctx context.Context, post *domain.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.checkTitle(tx, post.Title, post.Id); err != nil {
+			return err
+		}
+
+		stored, stream, err := r.load(ctx, tx, post.Id)
+		if err != nil {
+			return err
+		}
+
+		change := postChange{Title: post.Title, Content: post.Content, At: timestamp()}
+		if err := r.adopt(ctx, tx, stored, stream); err != nil {
+			return err
+		}
+		if err := r.append(ctx, tx, stored, stored.Version, PostUpdatedEvent, change); err != nil {
+			return err
+		}
+
+		err = tx.Model(&domain.Post{}).Where("id = ?", stored.Id).Updates(map[string]any{
+			"title":      stored.Title,
+			"content":    stored.Content,
+			"version":    stored.Version,
+			"updated_at": stored.UpdatedAt,
+		}).Error
+		if err != nil {
+			return titleTaken(err, stored.Title)
+		}
+
+		*post = *stored
+		return nil
+	})
+}
+
+func (r *EventSourcedPostRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stored, stream, err := r.load(ctx, tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if err := r.adopt(ctx, tx, stored, stream); err != nil {
+			return err
+		}
+		if err := r.append(ctx, tx, stored, stored.Version, PostDeletedEvent, postChange{At: timestamp()}); err != nil {
+			return err
+		}
+
+		// Comments go with the row.
+		return tx.Delete(&domain.Post{}, stored.Id).Error
+	})
+}
+
+// History reads the events of a post, deleted or not, in the order they happened.
+func (r *EventSourcedPostRepository) History(ctx context.Context, id int) ([]StoredEvent, error) {
+	return NewEventStore(r.db).Events(ctx, PostStream, uint(id))
+}
+
+func (r *EventSourcedPostRepository) checkTitle(tx *gorm.DB, title value_object.Title, id uint) error {
+	if title == "" {
+		return nil
+	}
+
+	var existing domain.Post
+	err := tx.Where("title = ? AND id != ?", title, id).First(&existing).Error
+	if err == nil {
+		return domain.NewConflictError("post with title %s already exists", title)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// load rebuilds a post from its latest snapshot and the events after it, and
+// tells the version of its stream. A post written before the event store was
+// selected has no stream yet, and is read from its row.
+func (r *EventSourcedPostRepository) load(ctx context.Context, db *gorm.DB, id uint) (*domain.Post, uint, error) {
+	snapshot, events, err := NewEventStore(db).Load(ctx, PostStream, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	post := &domain.Post{}
+	var stream uint
+	switch {
+	case snapshot != nil:
+		var state postSnapshot
+		if err := json.Unmarshal([]byte(snapshot.Data), &state); err != nil {
+			return nil, 0, fmt.Errorf("decode snapshot of post %d: %w", id, err)
+		}
+		*post = state.Post
+		post.AuthorEmail = state.AuthorEmail
+		stream = snapshot.Version
+	case len(events) == 0:
+		if err := db.WithContext(ctx).First(post, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, domain.NewNotFoundError("post with id %d not found", id)
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		return post, 0, nil
+	}
+
+	for _, event := range events {
+		if err := applyPostEvent(post, event); err != nil {
+			return nil, 0, err
+		}
+		stream = event.Version
+	}
+
+	if post.DeletedAt != nil {
+		return nil, 0, domain.NewNotFoundError("post with id %d not found", id)
+	}
+
+	return post, stream, nil
+}
+
+// adopt starts the stream of a post read from its row with a snapshot of it,
+// so that its events apply to the state it had.
+func (r *EventSourcedPostRepository) adopt(ctx context.Context, tx *gorm.DB, post *domain.Post, stream uint) error {
+	if stream != 0 {
+		return nil
+	}
+
+	return r.snapshot(ctx, tx, post)
+}
+
+// append records a change of a post at the version following expected,
+// applies it, and snapshots the post every snapshotEvery versions.
+func (r *EventSourcedPostRepository) append(ctx context.Context, tx *gorm.DB, post *domain.Post, expected uint, eventType string, change postChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	event := StoredEvent{Type: eventType, Data: string(data), RecordedAt: change.At}
+	if err := NewEventStore(tx).Append(ctx, PostStream, post.Id, expected, event); err != nil {
+		return err
+	}
+
+	event.StreamId = post.Id
+	event.Version = expected + 1
+	if err := applyPostEvent(post, event); err != nil {
+		return err
+	}
+
+	if post.Version%r.snapshotEvery != 0 || post.DeletedAt != nil {
+		return nil
+	}
+
+	return r.snapshot(ctx, tx, post)
+}
+
+func (r *EventSourcedPostRepository) snapshot(ctx context.Context, tx *gorm.DB, post *domain.Post) error {
+	data, err := json.Marshal(postSnapshot{Post: *post, AuthorEmail: post.AuthorEmail})
+	if err != nil {
+		return err
+	}
+
+	return NewEventStore(tx).SaveSnapshot(ctx, &Snapshot{
+		StreamType: PostStream,
+		StreamId:   post.Id,
+		Version:    post.Version,
+		Data:       string(data),
+	})
+}
+
+// applyPostEvent changes a post as an event of its stream tells.
+func applyPostEvent(post *domain.Post, event StoredEvent) error {
+	var change postChange
+	if err := json.Unmarshal([]byte(event.Data), &change); err != nil {
+		return fmt.Errorf("decode %s event %d of post %d: %w", event.Type, event.Version, event.StreamId, err)
+	}
+
+	switch event.Type {
+	case PostCreatedEvent:
+		post.Id = event.StreamId
+		post.Title = change.Title
+		post.Content = change.Content
+		post.AuthorEmail = change.AuthorEmail
+		post.CreatedAt = change.At
+	case PostUpdatedEvent:
+		if change.Title != "" {
+			post.Title = change.Title
+		}
+		if change.Content != "" {
+			post.Content = change.Content
+		}
+	case PostDeletedEvent:
+		post.DeletedAt = &change.At
+	default:
+		return fmt.Errorf("unknown event %s of post %d", event.Type, event.StreamId)
+	}
+
+	post.Version = event.Version
+	post.UpdatedAt = change.At
+	return nil
+}
+
+// timestamp is the current time at the precision every database keeps, so
+// that a post rebuilt from its events matches its row.
+func timestamp() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// StoredEvent is a change of an aggregate, the stream, recorded in the event
+// store. Events are numbered from 1 within their stream and never change.
+type StoredEvent struct {
+	Id         uint `gorm:"primarykey"`
+	StreamType string
+	StreamId   uint
+	Version    uint
+	Type       string
+	Data       string
+	RecordedAt time.Time
+}
+
+// Snapshot is the state of a stream as of one of its versions, so that
+// loading it replays the later events only.
+type Snapshot struct {
+	StreamType string `gorm:"primarykey"`
+	StreamId   uint   `gorm:"primarykey"`
+	Version    uint
+	Data       string
+	CreatedAt  time.Time
+}
+
+// EventStore keeps streams of events in an append-only table, with the
+// latest snapshot of each stream beside them.
+type EventStore struct {
+	db *gorm.DB
+}
+
+func NewEventStore(db *gorm.DB) *EventStore {
+	return &EventStore{db: db}
+}
+
+// Append records events at the versions following expected. When another
+// writer appended to the stream past expected in the meantime, nothing is
+// recorded and a conflict is returned.
+func (s *EventStore) Append(ctx context.Context, streamType string, streamId uint, expected uint, events ...StoredEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for i := range events {
+		events[i].StreamType = streamType
+		events[i].StreamId = streamId
+		events[i].Version = expected + uint(i) + 1
+	}
+
+	err := s.db.WithContext(ctx).Create(&events).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.NewConflictError("%s %d was changed concurrently, it is past version %d", streamType, streamId, expected)
+	}
+
+	return err
+}
+
+// Load reads the latest snapshot of a stream, nil when there is none, and
+// the events recorded after it in version order.
+func (s *EventStore) Load(ctx context.Context, streamType string, streamId uint) (*Snapshot, []StoredEvent, error) {
+	db := s.db.WithContext(ctx)
+
+	var snapshot *Snapshot
+	var found Snapshot
+	err := db.Where("stream_type = ? AND stream_id = ?", streamType, streamId).First(&found).Error
+	if err == nil {
+		snapshot = &found
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	var after uint
+	if snapshot != nil {
+		after = snapshot.Version
+	}
+
+	var events []StoredEvent
+	err = db.
+		Where("stream_type = ? AND stream_id = ? AND version > ?", streamType, streamId, after).
+		Order("version").
+		Find(&events).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return snapshot, events, nil
+}
+
+// Events reads the whole history of a stream in version order.
+func (s *EventStore) Events(ctx context.Context, streamType string, streamId uint) ([]StoredEvent, error) {
+	var events []StoredEvent
+	err := s.db.WithContext(ctx).
+		Where("stream_type = ? AND stream_id = ?", streamType, streamId).
+		Order("version").
+		Find(&events).Error
+
+	return events, err
+}
+
+// SaveSnapshot replaces the snapshot of a stream, only ever by a later one.
+func (s *EventStore) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "stream_type"}, {Name: "stream_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"version", "data", "created_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "snapshots.version < excluded.version"},
+			}},
+		}).
+		Create(snapshot).Error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) domain.JobRepository {
+	return &JobRepository{db: db}
+}
+
+func (r *JobRepository) FindById(ctx context.Context, id int) (*domain.Job, error) {
+	var job domain.Job
+	err := r.db.WithContext(ctx).
+		First(&job, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("job with id %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *JobRepository) Paginate(ctx context.Context, filter domain.JobFilter, page int, perPage int) ([]domain.Job, int64, error) {
+	var jobs []domain.Job
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&domain.Job{})
+		if filter.Queue != "" {
+			query = query.Where("queue = ?", filter.Queue)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return query.
+			Order("id DESC").
+			Limit(perPage).
+			Offset(offset).
+			Find(&jobs).Error
+	})
+
+	return jobs, total, err
+}
+
+// Create joins the transaction of a unit of work in ctx, if any, so that a job
+// following a change is only queued once the change is committed.
+func (r *JobRepository) Create(ctx context.Context, job *domain.Job) error {
+	db := r.db
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+
+	return db.WithContext(ctx).Create(job).Error
+}
+
+func (r *JobRepository) Update(ctx context.Context, job *domain.Job, previous domain.Job) error {
+	// locked_until is set anew by every claim and cleared by every outcome, so
+	// it pins the lease the change was made under.
+	query := r.db.WithContext(ctx).
+		Model(job).
+		Where("status = ? AND attempts = ?", previous.Status, previous.Attempts)
+	if previous.LockedUntil == nil {
+		query = query.Where("locked_until IS NULL")
+	} else {
+		query = query.Where("locked_until = ?", *previous.LockedUntil)
+	}
+
+	result := query.Select("*").Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.NewConflictError("job with id %d was changed meanwhile", job.Id)
+	}
+
+	return nil
+}
+
+// failLostJobs fails the jobs whose lease ran out on their last attempt: the
+// worker running them was lost, and they have no attempt left to run again.
+const failLostJobs = `UPDATE jobs SET status = ?, locked_until = NULL, last_error = ?, finished_at = ?, updated_at = ?
+WHERE queue = ? AND status = ? AND locked_until <= ? AND attempts >= max_attempts`
+
+// claimJobs takes the jobs in a single statement. On Postgres the selected
+// rows are locked, and rows locked by another worker skipped, so that workers
+// polling the same queue never wait on each other nor take the same job.
+// SQLite runs one write at a time and needs no locking.
+const claimJobs = `UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = ?, updated_at = ?
+WHERE id IN (
+	SELECT id FROM jobs
+	WHERE queue = ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?))
+	ORDER BY run_at ASC, id ASC
+	LIMIT ?`
+
+func (r *JobRepository) Claim(ctx context.Context, queue string, now time.Time, lease time.Duration, limit int) ([]domain.Job, error) {
+	statement := claimJobs
+	if r.db.Dialector.Name() == "postgres" {
+		statement += "\n\tFOR UPDATE SKIP LOCKED"
+	}
+	statement += "\n) RETURNING *"
+
+	var jobs []domain.Job
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(failLostJobs,
+			domain.JobFailed, "lease expired, the worker running the job was lost", now, now,
+			queue, domain.JobRunning, now,
+		).Error; err != nil {
+			return err
+		}
+
+		return tx.Raw(statement,
+			domain.JobRunning, now.Add(lease), now,
+			queue, domain.JobPending, now, domain.JobRunning, now,
+			limit,
+		).Scan(&jobs).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(jobs, func(a, b domain.Job) int {
+		if c := a.RunAt.Compare(b.RunAt); c != 0 {
+			return c
+		}
+		return int(a.Id) - int(b.Id)
+	})
+
+	return jobs, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) domain.NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) NextDue(ctx context.Context, email value_object.Email) (*time.Time, error) {
+	var pending domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("email = ? AND status = ?", email, domain.NotificationPending).
+		Order("due_at ASC").
+		First(&pending).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return pending.DueAt, nil
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *NotificationRepository) Update(ctx context.Context, notifications []domain.Notification) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range notifications {
+			if err := tx.Save(&notifications[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Claim selects the addresses with due notifications, then takes the
+// notifications of each with a conditional update, which only one of several
+// workers racing for the same address wins.
+func (r *NotificationRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.Notification, error) {
+	var emails []value_object.Email
+	err := r.db.WithContext(ctx).
+		Model(&domain.Notification{}).
+		Where("status = ? AND due_at <= ?", domain.NotificationPending, now).
+		Group("email").
+		Order("MIN(due_at) ASC").
+		Limit(limit).
+		Pluck("email", &emails).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leased := now.Add(lease)
+	var claimed []domain.Notification
+	for _, email := range emails {
+		result := r.db.WithContext(ctx).
+			Model(&domain.Notification{}).
+			Where("email = ? AND status = ? AND due_at <= ?", email, domain.NotificationPending, now).
+			Update("due_at", leased)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		var notifications []domain.Notification
+		err := r.db.WithContext(ctx).
+			Where("email = ? AND status = ? AND due_at = ?", email, domain.NotificationPending, leased).
+			Order("id ASC").
+			Find(&notifications).Error
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, notifications...)
+	}
+
+	return claimed, nil
+}
+
+type NotificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) domain.NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+func (r *NotificationPreferenceRepository) FindByEmail(ctx context.Context, email value_object.Email) (*domain.NotificationPreference, error) {
+	var preference domain.NotificationPreference
+	err := r.db.WithContext(ctx).
+		Where("email = ?", email).
+		First(&preference).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("notification preferences of %s not found", email)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+func (r *NotificationPreferenceRepository) FindByToken(ctx context.Context, token string) (*domain.NotificationPreference, error) {
+	var preference domain.NotificationPreference
+	err := r.db.WithContext(ctx).
+		Where("token = ?", token).
+		First(&preference).Error
+
+	// The token is not echoed, it is as good as a password.
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("notification preferences not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+func (r *NotificationPreferenceRepository) Create(ctx context.Context, preference *domain.NotificationPreference) error {
+	err := r.db.WithContext(ctx).Create(preference).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.NewConflictError("notification preferences already exist for the address")
+	}
+
+	return err
+}
+
+func (r *NotificationPreferenceRepository) Update(ctx context.Context, preference *domain.NotificationPreference) error {
+	return r.db.WithContext(ctx).Save(preference).Error
+}
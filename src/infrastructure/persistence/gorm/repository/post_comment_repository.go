@@ -21,21 +21,22 @@ func (r *CommentRepository) FindById(ctx context.Context, id int) (*domain.PostC
 		First(&comment, id).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("comment with id %d not found", id)
+	} else if err != nil {
 		return nil, err
 	}
 
-	return &comment, err
+	return &comment, nil
 }
 
 func (r *CommentRepository) FindByPostId(ctx context.Context, postID int) ([]domain.PostComment, error) {
 	var comments []domain.PostComment
 	err := r.db.WithContext(ctx).
 		Where("post_id = ?", postID).
+		Order("id ASC").
 		Find(&comments).Error
 
-	domainComments := make([]domain.PostComment, len(comments))
-
-	return domainComments, err
+	return comments, err
 }
 
 func (r *CommentRepository) Paginate(ctx context.Context, postId int, page int, perPage int) ([]domain.PostComment, int64, error) {
@@ -59,12 +60,108 @@ func (r *CommentRepository) Paginate(ctx context.Context, postId int, page int,
 	return comments, total, err
 }
 
+func (r *CommentRepository) PaginateByPostIds(ctx context.Context, postIds []uint, page int, perPage int) ([]domain.PostComment, map[uint]int64, error) {
+	var comments []domain.PostComment
+	totals := make(map[uint]int64, len(postIds))
+	if len(postIds) == 0 {
+		return comments, totals, nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var counts []struct {
+			PostId uint
+			Total  int64
+		}
+		if err := tx.Model(&domain.PostComment{}).
+			Select("post_id, COUNT(*) AS total").
+			Where("post_id IN ?", postIds).
+			Group("post_id").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, count := range counts {
+			totals[count.PostId] = count.Total
+		}
+
+		// Every post is numbered on its own, so one query returns the page of each of them.
+		numbered := tx.Model(&domain.PostComment{}).
+			Select("post_comments.*, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY id DESC) AS position").
+			Where("post_id IN ?", postIds)
+
+		offset := (page - 1) * perPage
+		return tx.Table("(?) AS post_comments", numbered).
+			Where("position > ? AND position <= ?", offset, offset+perPage).
+			Order("post_id ASC, id DESC").
+			Find(&comments).Error
+	})
+
+	return comments, totals, err
+}
+
+func (r *CommentRepository) PaginateRevisions(ctx context.Context, postId int, page int, perPage int) ([]domain.Revision, int64, error) {
+	var revisions []domain.Revision
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.PostComment{}).Where("post_id = ?", postId).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return tx.
+			Model(&domain.PostComment{}).
+			Select("id", "version", "updated_at").
+			Where("post_id = ?", postId).
+			Order("id DESC").
+			Limit(perPage).
+			Offset(offset).
+			Scan(&revisions).Error
+	})
+
+	return revisions, total, err
+}
+
 func (r *CommentRepository) Create(ctx context.Context, comment *domain.PostComment) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := r.db.WithContext(ctx).Create(&comment).Error; err != nil {
+		comment.Version = 1
+		if err := tx.Create(comment).Error; errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return domain.NewNotFoundError("post with id %d not found", comment.PostId)
+		} else if err != nil {
 			return err
 		}
 
 		return nil
 	})
 }
+
+func (r *CommentRepository) Update(ctx context.Context, comment *domain.PostComment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.PostComment{}).Where("id = ?", comment.Id).Updates(map[string]any{
+			"text":    comment.Text,
+			"version": gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return domain.NewNotFoundError("comment with id %d not found", comment.Id)
+		}
+
+		// Reload for the post, version and timestamps the database holds.
+		return tx.First(comment, comment.Id).Error
+	})
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&domain.PostComment{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.NewNotFoundError("comment with id %d not found", id)
+	}
+
+	return nil
+}
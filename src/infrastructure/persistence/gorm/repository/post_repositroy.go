@@ -2,6 +2,7 @@ package repository
 
 import (
 	"DDD/src/domain"
+	"DDD/src/domain/value_object"
 	"context"
 	"errors"
 	"gorm.io/gorm"
@@ -21,10 +22,30 @@ func (r *PostRepository) FindById(ctx context.Context, id int) (*domain.Post, er
 		First(&post, id).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("post with id %d not found", id)
+	} else if err != nil {
 		return nil, err
 	}
 
-	return &post, err
+	return &post, nil
+}
+
+func (r *PostRepository) FindRevision(ctx context.Context, id int) (*domain.Revision, error) {
+	var revision domain.Revision
+	result := r.db.WithContext(ctx).
+		Model(&domain.Post{}).
+		Select("id", "version", "updated_at").
+		Where("id = ?", id).
+		Limit(1).
+		Scan(&revision)
+
+	if result.Error != nil {
+		return nil, result.Error
+	} else if result.RowsAffected == 0 {
+		return nil, domain.NewNotFoundError("post with id %d not found", id)
+	}
+
+	return &revision, nil
 }
 
 func (r *PostRepository) Paginate(ctx context.Context, page int, perPage int) ([]domain.Post, int64, error) {
@@ -47,17 +68,40 @@ func (r *PostRepository) Paginate(ctx context.Context, page int, perPage int) ([
 	return posts, total, err
 }
 
+func (r *PostRepository) PaginateRevisions(ctx context.Context, page int, perPage int) ([]domain.Revision, int64, error) {
+	var revisions []domain.Revision
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Post{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return tx.
+			Model(&domain.Post{}).
+			Select("id", "version", "updated_at").
+			Order("id DESC").
+			Limit(perPage).
+			Offset(offset).
+			Scan(&revisions).Error
+	})
+
+	return revisions, total, err
+}
+
 func (r *PostRepository) Create(ctx context.Context, post *domain.Post) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing domain.Post
 		if err := tx.Where("title = ?", post.Title).First(&existing).Error; err == nil {
-			return gorm.ErrDuplicatedKey
+			return domain.NewConflictError("post with title %s already exists", post.Title)
 		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
 
-		if err := r.db.WithContext(ctx).Create(&post).Error; err != nil {
-			return err
+		post.Version = 1
+		if err := tx.Create(post).Error; err != nil {
+			return titleTaken(err, post.Title)
 		}
 
 		return nil
@@ -68,26 +112,52 @@ func (r *PostRepository) Update(ctx context.Context, post *domain.Post) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing domain.Post
 		if err := tx.Where("title = ? AND id != ?", post.Title, post.Id).First(&existing).Error; err == nil {
-			return gorm.ErrDuplicatedKey
+			return domain.NewConflictError("post with title %s already exists", post.Title)
 		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
 
-		if err := r.db.WithContext(ctx).Updates(&post).Error; err != nil {
-			return err
+		updates := map[string]any{"version": gorm.Expr("version + 1")}
+		if post.Title != "" {
+			updates["title"] = post.Title
+		}
+		if post.Content != "" {
+			updates["content"] = post.Content
 		}
 
-		return nil
+		result := tx.Model(&domain.Post{}).Where("id = ?", post.Id).Updates(updates)
+		if result.Error != nil {
+			return titleTaken(result.Error, post.Title)
+		}
+
+		if result.RowsAffected == 0 {
+			return domain.NewNotFoundError("post with id %d not found", post.Id)
+		}
+
+		// Reload for the version and timestamps the database assigned.
+		return tx.First(post, post.Id).Error
 	})
 }
 
 func (r *PostRepository) Delete(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var post domain.Post
-		if err := tx.First(&post, id).Error; err != nil {
+		if err := tx.First(&post, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewNotFoundError("post with id %d not found", id)
+		} else if err != nil {
 			return err
 		}
 
 		return tx.Delete(&post).Error
 	})
 }
+
+// titleTaken reports a title taken by another writer after it was checked,
+// which the unique index refuses, as the conflict the check would have found.
+func titleTaken(err error, title value_object.Title) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.NewConflictError("post with title %s already exists", title)
+	}
+
+	return err
+}
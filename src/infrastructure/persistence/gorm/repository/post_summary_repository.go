@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PostSummaryRepository struct {
+	db *gorm.DB
+}
+
+func NewPostSummaryRepository(db *gorm.DB) domain.PostSummaryRepository {
+	return &PostSummaryRepository{db: db}
+}
+
+var summaryOrders = map[string]string{
+	domain.SummariesByLatest:   "post_id DESC",
+	domain.SummariesByActivity: "last_activity_at DESC, post_id DESC",
+	domain.SummariesByComments: "comment_count DESC, post_id DESC",
+}
+
+func (r *PostSummaryRepository) Paginate(ctx context.Context, order string, page int, perPage int) ([]domain.PostSummary, int64, error) {
+	var summaries []domain.PostSummary
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.PostSummary{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return tx.
+			Order(summaryOrders[order]).
+			Limit(perPage).
+			Offset(offset).
+			Find(&summaries).Error
+	})
+
+	return summaries, total, err
+}
+
+func (r *PostSummaryRepository) Create(ctx context.Context, summary *domain.PostSummary) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(summary).Error
+}
+
+func (r *PostSummaryRepository) UpdatePost(ctx context.Context, post domain.Post) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.PostSummary{}).
+		Where("post_id = ? AND version < ?", post.Id, post.Version).
+		Updates(map[string]any{
+			"title":            post.Title.String(),
+			"excerpt":          domain.Excerpt(post.Content.String()),
+			"version":          post.Version,
+			"updated_at":       post.UpdatedAt,
+			"last_activity_at": latest(post.UpdatedAt),
+		}).Error
+}
+
+func (r *PostSummaryRepository) AddActivity(ctx context.Context, postId uint, comments int, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.PostSummary{}).
+		Where("post_id = ?", postId).
+		Updates(map[string]any{
+			"comment_count":    gorm.Expr("comment_count + ?", comments),
+			"last_activity_at": latest(at),
+		}).Error
+}
+
+// latest keeps the last activity unless at is later, events of concurrent
+// writes being published in any order.
+func latest(at time.Time) clause.Expr {
+	return gorm.Expr("CASE WHEN last_activity_at < ? THEN ? ELSE last_activity_at END", at, at)
+}
+
+func (r *PostSummaryRepository) Delete(ctx context.Context, postId uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.PostSummary{}, postId).Error
+}
+
+func (r *PostSummaryRepository) Refresh(ctx context.Context, postId uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.PostSummary{}, postId).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(r.summarize("WHERE p.id = ?"), postId).Error
+	})
+}
+
+func (r *PostSummaryRepository) Rebuild(ctx context.Context) (int64, error) {
+	var summarized int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&domain.PostSummary{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(r.summarize(""))
+		summarized = result.RowsAffected
+		return result.Error
+	})
+
+	return summarized, err
+}
+
+// summarize is the statement summarizing the posts matching where from the
+// posts and comments tables, the same as the migration creating the table.
+func (r *PostSummaryRepository) summarize(where string) string {
+	lastActivity := "MAX(p.updated_at, COALESCE(MAX(c.updated_at), p.updated_at))"
+	if r.db.Dialector.Name() == "postgres" {
+		lastActivity = "GREATEST(p.updated_at, MAX(c.updated_at))"
+	}
+
+	return fmt.Sprintf(`INSERT INTO post_summaries (post_id, title, excerpt, version, comment_count, last_activity_at, created_at, updated_at)
+SELECT p.id, p.title, COALESCE(substr(p.content, 1, %d), ''), p.version, COUNT(c.id), %s, p.created_at, p.updated_at
+FROM posts p
+LEFT JOIN post_comments c ON c.post_id = p.id
+%s
+GROUP BY p.id`, domain.ExcerptLength, lastActivity, where)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"context"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type UnitOfWork struct {
+	db    *gorm.DB
+	posts func(tx *gorm.DB) domain.PostRepository
+}
+
+func NewUnitOfWork(db *gorm.DB) domain.UnitOfWork {
+	return NewUnitOfWorkWithPosts(db, NewPostRepository)
+}
+
+// NewUnitOfWorkWithPosts shares the transaction with the post repository
+// posts builds, such as the event-sourced one.
+func NewUnitOfWorkWithPosts(db *gorm.DB, posts func(tx *gorm.DB) domain.PostRepository) domain.UnitOfWork {
+	return &UnitOfWork{db: db, posts: posts}
+}
+
+// Do shares a single transaction between the repositories. GORM turns a
+// transaction started inside another one into a savepoint.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	db := u.db
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx), &repositories{
+			posts:    u.posts(tx),
+			comments: NewCommentRepository(tx),
+		})
+	})
+}
+
+type repositories struct {
+	posts    domain.PostRepository
+	comments domain.PostCommentRepository
+}
+
+func (r *repositories) Posts() domain.PostRepository {
+	return r.posts
+}
+
+func (r *repositories) Comments() domain.PostCommentRepository {
+	return r.comments
+}
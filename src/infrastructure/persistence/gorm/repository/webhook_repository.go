@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"DDD/src/domain"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) domain.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) FindById(ctx context.Context, id int) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := r.db.WithContext(ctx).
+		First(&webhook, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("webhook with id %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// FindByEvent filters in Go, since the events are stored as a JSON list and
+// there are few webhooks compared to the events they receive.
+func (r *WebhookRepository) FindByEvent(ctx context.Context, event string) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+
+	return subscribed, nil
+}
+
+func (r *WebhookRepository) Paginate(ctx context.Context, page int, perPage int) ([]domain.Webhook, int64, error) {
+	var webhooks []domain.Webhook
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Webhook{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return tx.
+			Order("id DESC").
+			Limit(perPage).
+			Offset(offset).
+			Find(&webhooks).Error
+	})
+
+	return webhooks, total, err
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&domain.Webhook{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewNotFoundError("webhook with id %d not found", id)
+	}
+
+	return nil
+}
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) domain.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+func (r *WebhookDeliveryRepository) FindById(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		First(&delivery, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("delivery with id %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) Paginate(ctx context.Context, webhookId int, page int, perPage int) ([]domain.WebhookDelivery, int64, error) {
+	var deliveries []domain.WebhookDelivery
+	var total int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.WebhookDelivery{}).Where("webhook_id = ?", webhookId).Count(&total).Error; err != nil {
+			return err
+		}
+
+		offset := (page - 1) * perPage
+		return tx.
+			Where("webhook_id = ?", webhookId).
+			Order("id DESC").
+			Limit(perPage).
+			Offset(offset).
+			Find(&deliveries).Error
+	})
+
+	return deliveries, total, err
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery, previous domain.WebhookDelivery) error {
+	// A claim moves next_attempt_at, so it tells the leases of a delivery apart.
+	query := r.db.WithContext(ctx).
+		Model(delivery).
+		Where("status = ? AND attempts = ?", previous.Status, previous.Attempts)
+	if previous.NextAttemptAt == nil {
+		query = query.Where("next_attempt_at IS NULL")
+	} else {
+		query = query.Where("next_attempt_at = ?", *previous.NextAttemptAt)
+	}
+
+	result := query.Select("*").Updates(delivery)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.NewConflictError("delivery with id %d was changed meanwhile", delivery.Id)
+	}
+
+	return nil
+}
+
+// Claim selects the due deliveries, then takes each with a conditional update,
+// which only one of several workers racing for the same delivery wins.
+func (r *WebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var due []domain.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", domain.DeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored as read back, to the microsecond, so that Update finds the lease.
+	leased := now.Add(lease).Truncate(time.Microsecond)
+	claimed := due[:0]
+	for _, delivery := range due {
+		result := r.db.WithContext(ctx).
+			Model(&domain.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.Id, domain.DeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", leased)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = &leased
+			claimed = append(claimed, delivery)
+		}
+	}
+
+	return claimed, nil
+}
@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"DDD/src/domain"
+	"context"
+	"slices"
+	"sort"
+	"time"
+)
+
+type CommentRepository struct {
+	store *Store
+}
+
+func NewCommentRepository(store *Store) domain.PostCommentRepository {
+	return &CommentRepository{store: store}
+}
+
+func (r *CommentRepository) FindById(ctx context.Context, id int) (*domain.PostComment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comment, ok := r.store.comments[uint(id)]
+	if !ok {
+		return nil, domain.NewNotFoundError("comment with id %d not found", id)
+	}
+
+	return &comment, nil
+}
+
+func (r *CommentRepository) FindByPostId(ctx context.Context, postID int) ([]domain.PostComment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := r.byPost(uint(postID))
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Id < comments[j].Id
+	})
+
+	return comments, nil
+}
+
+func (r *CommentRepository) Paginate(ctx context.Context, postId int, page int, perPage int) ([]domain.PostComment, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := r.byPost(uint(postId))
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Id > comments[j].Id
+	})
+
+	return paginate(comments, page, perPage), int64(len(comments)), nil
+}
+
+func (r *CommentRepository) PaginateByPostIds(ctx context.Context, postIds []uint, page int, perPage int) ([]domain.PostComment, map[uint]int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	ids := slices.Clone(postIds)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	comments := make([]domain.PostComment, 0)
+	totals := make(map[uint]int64, len(ids))
+	for _, postId := range ids {
+		byPost := r.byPost(postId)
+		if len(byPost) == 0 {
+			continue
+		}
+
+		sort.Slice(byPost, func(i, j int) bool {
+			return byPost[i].Id > byPost[j].Id
+		})
+		comments = append(comments, paginate(byPost, page, perPage)...)
+		totals[postId] = int64(len(byPost))
+	}
+
+	return comments, totals, nil
+}
+
+func (r *CommentRepository) PaginateRevisions(ctx context.Context, postId int, page int, perPage int) ([]domain.Revision, int64, error) {
+	comments, total, err := r.Paginate(ctx, postId, page, perPage)
+
+	return revisions(comments), total, err
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *domain.PostComment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.posts[comment.PostId]; !ok {
+		return domain.NewNotFoundError("post with id %d not found", comment.PostId)
+	}
+
+	now := time.Now()
+	r.store.lastCommentId++
+	comment.Id = r.store.lastCommentId
+	comment.Version = 1
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	r.store.comments[comment.Id] = *comment
+
+	return nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *domain.PostComment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.comments[comment.Id]
+	if !ok {
+		return domain.NewNotFoundError("comment with id %d not found", comment.Id)
+	}
+
+	existing.Text = comment.Text
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	r.store.comments[comment.Id] = existing
+	*comment = existing
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.comments[uint(id)]; !ok {
+		return domain.NewNotFoundError("comment with id %d not found", id)
+	}
+
+	delete(r.store.comments, uint(id))
+
+	return nil
+}
+
+// byPost must be called with the store lock held.
+func (r *CommentRepository) byPost(postId uint) []domain.PostComment {
+	comments := make([]domain.PostComment, 0)
+	for _, comment := range r.store.comments {
+		if comment.PostId == postId {
+			comments = append(comments, comment)
+		}
+	}
+
+	return comments
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"DDD/src/domain"
+	"context"
+	"sort"
+	"time"
+)
+
+type PostRepository struct {
+	store *Store
+}
+
+func NewPostRepository(store *Store) domain.PostRepository {
+	return &PostRepository{store: store}
+}
+
+func (r *PostRepository) FindById(ctx context.Context, id int) (*domain.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	post, ok := r.store.posts[uint(id)]
+	if !ok {
+		return nil, domain.NewNotFoundError("post with id %d not found", id)
+	}
+
+	return &post, nil
+}
+
+func (r *PostRepository) FindRevision(ctx context.Context, id int) (*domain.Revision, error) {
+	post, err := r.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := post.Revision()
+	return &revision, nil
+}
+
+func (r *PostRepository) Paginate(ctx context.Context, page int, perPage int) ([]domain.Post, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	posts := make([]domain.Post, 0, len(r.store.posts))
+	for _, post := range r.store.posts {
+		posts = append(posts, post)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Id > posts[j].Id
+	})
+
+	return paginate(posts, page, perPage), int64(len(posts)), nil
+}
+
+func (r *PostRepository) PaginateRevisions(ctx context.Context, page int, perPage int) ([]domain.Revision, int64, error) {
+	posts, total, err := r.Paginate(ctx, page, perPage)
+
+	return revisions(posts), total, err
+}
+
+func (r *PostRepository) Create(ctx context.Context, post *domain.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.titleTaken(post.Title.String(), 0) {
+		return domain.NewConflictError("post with title %s already exists", post.Title)
+	}
+
+	now := time.Now()
+	r.store.lastPostId++
+	post.Id = r.store.lastPostId
+	post.Version = 1
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	post.Comments = nil
+	r.store.posts[post.Id] = *post
+
+	return nil
+}
+
+func (r *PostRepository) Update(ctx context.Context, post *domain.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.posts[post.Id]
+	if !ok {
+		return domain.NewNotFoundError("post with id %d not found", post.Id)
+	}
+
+	if r.titleTaken(post.Title.String(), post.Id) {
+		return domain.NewConflictError("post with title %s already exists", post.Title)
+	}
+
+	if post.Title != "" {
+		existing.Title = post.Title
+	}
+	if post.Content != "" {
+		existing.Content = post.Content
+	}
+
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	r.store.posts[post.Id] = existing
+	*post = existing
+
+	return nil
+}
+
+func (r *PostRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.posts[uint(id)]; !ok {
+		return domain.NewNotFoundError("post with id %d not found", id)
+	}
+
+	delete(r.store.posts, uint(id))
+	for commentId, comment := range r.store.comments {
+		if comment.PostId == uint(id) {
+			delete(r.store.comments, commentId)
+		}
+	}
+
+	return nil
+}
+
+// titleTaken must be called with the store lock held.
+func (r *PostRepository) titleTaken(title string, exceptId uint) bool {
+	for _, post := range r.store.posts {
+		if post.Title.String() == title && post.Id != exceptId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func paginate[T any](items []T, page int, perPage int) []T {
+	offset := (page - 1) * perPage
+	if offset < 0 || perPage <= 0 || offset >= len(items) {
+		return []T{}
+	}
+
+	end := offset + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[offset:end]
+}
+
+func revisions[T interface{ Revision() domain.Revision }](items []T) []domain.Revision {
+	result := make([]domain.Revision, len(items))
+	for i, item := range items {
+		result[i] = item.Revision()
+	}
+
+	return result
+}
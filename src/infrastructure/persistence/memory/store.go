@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"DDD/src/domain"
+	"maps"
+	"sync"
+)
+
+// Store holds posts and comments shared by the in-memory repositories,
+// so that deleting a post cascades to its comments like the database does.
+type Store struct {
+	mu            sync.RWMutex
+	txMu          sync.Mutex
+	posts         map[uint]domain.Post
+	comments      map[uint]domain.PostComment
+	lastPostId    uint
+	lastCommentId uint
+}
+
+func NewStore() *Store {
+	return &Store{
+		posts:    make(map[uint]domain.Post),
+		comments: make(map[uint]domain.PostComment),
+	}
+}
+
+type snapshot struct {
+	posts    map[uint]domain.Post
+	comments map[uint]domain.PostComment
+}
+
+func (s *Store) snapshot() snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return snapshot{
+		posts:    maps.Clone(s.posts),
+		comments: maps.Clone(s.comments),
+	}
+}
+
+// restore rolls back to a snapshot. Ids are not reused, like database sequences.
+func (s *Store) restore(snapshot snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.posts = snapshot.posts
+	s.comments = snapshot.comments
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"DDD/src/domain"
+	"context"
+)
+
+type unitOfWorkKey struct{}
+
+type UnitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) domain.UnitOfWork {
+	return &UnitOfWork{store: store}
+}
+
+// Do serializes units of work and restores a snapshot of the store when fn
+// fails. Writes made outside of a unit of work while it runs are not isolated.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) (err error) {
+	if ctx.Value(unitOfWorkKey{}) == nil {
+		u.store.txMu.Lock()
+		defer u.store.txMu.Unlock()
+
+		ctx = context.WithValue(ctx, unitOfWorkKey{}, true)
+	}
+
+	snapshot := u.store.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			u.store.restore(snapshot)
+			panic(r)
+		}
+
+		if err != nil {
+			u.store.restore(snapshot)
+		}
+	}()
+
+	return fn(ctx, &repositories{
+		posts:    NewPostRepository(u.store),
+		comments: NewCommentRepository(u.store),
+	})
+}
+
+type repositories struct {
+	posts    domain.PostRepository
+	comments domain.PostCommentRepository
+}
+
+func (r *repositories) Posts() domain.PostRepository {
+	return r.posts
+}
+
+func (r *repositories) Comments() domain.PostCommentRepository {
+	return r.comments
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"DDD/src/infrastructure/config"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderApiKey = "X-API-Key"
+	// UserLocal is the fiber local holding the id of the authenticated user,
+	// for authentication middleware to set.
+	UserLocal = "user"
+
+	limitedLocal = "ratelimit"
+)
+
+// KeyFunc identifies the client a request is counted against.
+type KeyFunc func(c *fiber.Ctx) string
+
+// NewKeyFunc keys clients by ip, api_key or user, falling back to the IP.
+func NewKeyFunc(kind string) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		switch kind {
+		case "user":
+			if user, ok := c.Locals(UserLocal).(string); ok && user != "" {
+				return "user:" + user
+			}
+		case "api_key":
+			if key := c.Get(HeaderApiKey); key != "" {
+				// Keys are secrets, keep them out of the store.
+				sum := sha256.Sum256([]byte(key))
+				return "key:" + hex.EncodeToString(sum[:16])
+			}
+		}
+
+		return "ip:" + c.IP()
+	}
+}
+
+// Limiter applies per-client limits to groups of routes.
+type Limiter struct {
+	store Store
+	key   KeyFunc
+}
+
+func NewLimiter(store Store, key KeyFunc) *Limiter {
+	return &Limiter{store: store, key: key}
+}
+
+// Limit counts the requests to the path it is registered on, of the given
+// methods or of any method, against the bucket of the group. Only the first
+// limit a request meets applies, so specific paths are registered before the broad groups.
+//
+// A store failure lets the request through: losing the limit for a moment is
+// better than losing the API.
+func (l *Limiter) Limit(group string, rate config.Rate, methods ...string) fiber.Handler {
+	policy := fmt.Sprintf("%d;w=%d", rate.Requests, int(rate.Period.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		if rate.Unlimited() || c.Locals(limitedLocal) != nil {
+			return c.Next()
+		}
+		if len(methods) > 0 && !slices.Contains(methods, c.Method()) {
+			return c.Next()
+		}
+		c.Locals(limitedLocal, group)
+
+		result, err := l.store.Take(c.UserContext(), group+":"+l.key(c), rate)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "rate limit store failed", slog.String("group", group), slog.String("error", err.Error()))
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", seconds(result.Reset))
+		c.Set("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, seconds(result.RetryAfter))
+			return fiber.NewError(fiber.StatusTooManyRequests, fmt.Sprintf("rate limit of %s exceeded for %s", rate, group))
+		}
+
+		return c.Next()
+	}
+}
+
+// ByMethod limits safe methods as reads and every other method as writes.
+func (l *Limiter) ByMethod(reads config.Rate, writes config.Rate) fiber.Handler {
+	limitReads, limitWrites := l.Limit("reads", reads), l.Limit("writes", writes)
+
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return limitReads(c)
+		default:
+			return limitWrites(c)
+		}
+	}
+}
+
+// seconds rounds up, so that a client waiting that long is sure to get a token.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"DDD/src/infrastructure/config"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the buckets in the process; every replica limits on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	rate   config.Rate
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, rate config.Rate) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Requests), at: now, rate: rate}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(rate, b.tokens, b.at, now)
+	b.at = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return newResult(rate, b.tokens, allowed), nil
+}
+
+// sweep forgets, at most once a minute, the buckets that have refilled, since
+// a new bucket would be the same.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if refill(b.rate, b.tokens, b.at, now) >= float64(b.rate.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+}
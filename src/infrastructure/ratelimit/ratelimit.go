@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"DDD/src/infrastructure/config"
+	"context"
+	"math"
+	"time"
+)
+
+// Store keeps the token buckets. Each bucket holds up to rate.Requests tokens
+// and refills at rate.Requests per rate.Period; a request takes one token.
+type Store interface {
+	Take(ctx context.Context, key string, rate config.Rate) (Result, error)
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, when the request was not allowed.
+	RetryAfter time.Duration
+}
+
+// newResult derives the result from the tokens left in the bucket after the take.
+func newResult(rate config.Rate, tokens float64, allowed bool) Result {
+	perToken := float64(rate.Period) / float64(rate.Requests)
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rate.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(rate.Requests) - tokens) * perToken),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+
+	return result
+}
+
+// refill returns the tokens of a bucket that held tokens at the given time.
+func refill(rate config.Rate, tokens float64, at time.Time, now time.Time) float64 {
+	elapsed := now.Sub(at)
+	if elapsed <= 0 {
+		return tokens
+	}
+
+	return math.Min(float64(rate.Requests), tokens+float64(elapsed)*float64(rate.Requests)/float64(rate.Period))
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"DDD/src/infrastructure/config"
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// takeScript refills and takes from a bucket atomically. The bucket expires
+// once it would be full again, since a new bucket would be the same.
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "at")
+local tokens = tonumber(state[1]) or capacity
+local at = tonumber(state[2]) or now
+
+if now > at then
+	tokens = math.min(capacity, tokens + (now - at) * capacity / period)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "at", math.max(now, at))
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) * period / capacity) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore shares the buckets between replicas. Replicas pass their own
+// clock, so it relies on their clocks being synchronised.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, rate config.Rate) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		rate.Requests, rate.Period.Milliseconds(), s.now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens, err := strconv.ParseFloat(reply[1].(string), 64)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(rate, tokens, allowed == 1), nil
+}
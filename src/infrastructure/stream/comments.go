@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"DDD/src/domain"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+)
+
+// CommentsTopic is the topic of the comments of a post.
+func CommentsTopic(postId uint) string {
+	return "posts/" + strconv.FormatUint(uint64(postId), 10) + "/comments"
+}
+
+// HandleEvent publishes comment changes to the topic of their post, and the
+// deletion of the post itself; subscribe it to the event bus.
+func (h *Hub) HandleEvent(ctx context.Context, event domain.Event) {
+	if name, data, ok := encode(ctx, event); ok {
+		h.Publish(name, event.EventName(), data)
+	}
+}
+
+// encode tells the topic of the events streams follow, and their data.
+func encode(ctx context.Context, event domain.Event) (string, []byte, bool) {
+	var name string
+	var v any
+	switch e := event.(type) {
+	case domain.CommentCreated:
+		name, v = CommentsTopic(e.Comment.PostId), e.Comment
+	case domain.CommentUpdated:
+		name, v = CommentsTopic(e.Comment.PostId), e.Comment
+	case domain.CommentDeleted:
+		name, v = CommentsTopic(e.Comment.PostId), e.Comment
+	case domain.PostDeleted:
+		name, v = CommentsTopic(e.PostId), map[string]uint{"id": e.PostId}
+	default:
+		return "", nil, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode stream event", slog.String("event", event.EventName()), slog.String("error", err.Error()))
+		return "", nil, false
+	}
+
+	return name, data, true
+}
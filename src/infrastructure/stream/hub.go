@@ -0,0 +1,281 @@
+// Package stream fans events out to the clients following a topic, such as
+// the comments of a post, and replays what a reconnecting client missed.
+package stream
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSlowSubscriber ends a subscription that fell a whole buffer behind.
+	// The client should reconnect and resume from its last event.
+	ErrSlowSubscriber = errors.New("subscriber too slow")
+	// ErrClosed ends the subscriptions when the hub closes, on shutdown.
+	ErrClosed = errors.New("stream closed")
+	// ErrTooManySubscribers rejects a subscription beyond the configured bound.
+	ErrTooManySubscribers = errors.New("too many subscribers")
+)
+
+// EventReset tells the client that events it missed cannot be replayed, so
+// it should reload what it shows before following the stream again.
+const EventReset = "reset"
+
+// Event is a message on a topic. Ids grow across all topics.
+type Event struct {
+	Id   uint64
+	Type string
+	Data []byte
+}
+
+type Options struct {
+	// Buffer is how many events may queue for a subscriber before it is dropped.
+	Buffer int
+	// History is how many of the latest events of a topic are kept for resuming.
+	History int
+	// Retention is how long the history of a topic nobody follows is kept.
+	Retention time.Duration
+	// MaxSubscribers bounds the subscriptions across all topics, 0 for no bound.
+	MaxSubscribers int
+}
+
+// Hub holds the subscriptions and the recent history of every followed topic.
+// Publishing never blocks on a subscriber.
+type Hub struct {
+	mu          sync.Mutex
+	options     Options
+	topics      map[string]*topic
+	subscribers int
+	// last is the id of the latest event. horizon is the id up to which events
+	// of forgotten topics may be lost, starting with those of previous processes.
+	last      uint64
+	horizon   uint64
+	lastSweep time.Time
+	closed    bool
+}
+
+type topic struct {
+	history     []Event
+	trimmed     uint64
+	idleSince   time.Time
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub(options Options) *Hub {
+	// Ids continue from the clock, so that ids handed out before a restart
+	// are older than any of this process and are answered with a reset.
+	start := uint64(time.Now().UnixMicro())
+
+	return &Hub{
+		options:   options,
+		topics:    make(map[string]*topic),
+		last:      start,
+		horizon:   start,
+		lastSweep: time.Now(),
+	}
+}
+
+// Subscription receives the events of a topic until it is closed or dropped.
+type Subscription struct {
+	hub      *Hub
+	name     string
+	position uint64
+	events   chan Event
+	err      error
+}
+
+// Position is the id of the latest event when the subscription started. A
+// client that received nothing yet resumes from it.
+func (s *Subscription) Position() uint64 {
+	return s.position
+}
+
+// Events delivers the events in order, and is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err tells why Events was closed: ErrSlowSubscriber, ErrClosed, or nil after Close.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s, nil)
+}
+
+// Subscribe follows a topic. With a lastEventId, the events after it are
+// replayed first, or a reset event is sent when they are no longer known.
+func (h *Hub) Subscribe(name string, lastEventId uint64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if h.options.MaxSubscribers > 0 && h.subscribers >= h.options.MaxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	t, ok := h.topics[name]
+	if !ok {
+		t = &topic{trimmed: h.horizon, subscribers: make(map[*Subscription]struct{})}
+		h.topics[name] = t
+	}
+
+	var replay []Event
+	if lastEventId > 0 {
+		if lastEventId < t.trimmed || lastEventId > h.last {
+			replay = []Event{{Id: h.last, Type: EventReset, Data: []byte(`{}`)}}
+		} else {
+			for _, event := range t.history {
+				if event.Id > lastEventId {
+					replay = append(replay, event)
+				}
+			}
+		}
+	}
+
+	s := &Subscription{hub: h, name: name, position: h.last, events: make(chan Event, max(h.options.Buffer, len(replay)))}
+	for _, event := range replay {
+		s.events <- event
+	}
+
+	t.subscribers[s] = struct{}{}
+	h.subscribers++
+
+	return s, nil
+}
+
+// Publish sends an event to the subscribers of a topic, and keeps it for
+// those resuming. Subscribers whose buffer is full are dropped.
+func (h *Hub) Publish(name string, eventType string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.publish(name, Event{Id: h.last + 1, Type: eventType, Data: data})
+}
+
+// relay publishes an event numbered by the relay. Events older than the
+// latest were missed before a resync, and are dropped.
+func (h *Hub) relay(name string, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event.Id > h.last {
+		h.publish(name, event)
+	}
+}
+
+// resync continues from the latest id of the relay, once events may have been
+// missed: on start, and after reconnecting. The history is forgotten, and the
+// subscribers are sent a reset event.
+func (h *Hub) resync(last uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.last, h.horizon = last, last
+	reset := Event{Id: last, Type: EventReset, Data: []byte(`{}`)}
+	for _, t := range h.topics {
+		t.history, t.trimmed = nil, last
+		for s := range t.subscribers {
+			select {
+			case s.events <- reset:
+			default:
+				h.remove(s, ErrSlowSubscriber)
+			}
+		}
+	}
+}
+
+// publish must be called with the lock held, with ids growing from one event to the next.
+func (h *Hub) publish(name string, event Event) {
+	if h.closed {
+		return
+	}
+
+	h.sweep()
+	h.last = event.Id
+
+	t, ok := h.topics[name]
+	if !ok {
+		// Nobody follows the topic, nor resumes it: ids before now are lost for it.
+		return
+	}
+
+	t.history = append(t.history, event)
+	if over := len(t.history) - h.options.History; over > 0 {
+		t.trimmed = t.history[over-1].Id
+		t.history = append(t.history[:0], t.history[over:]...)
+	}
+
+	for s := range t.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			h.remove(s, ErrSlowSubscriber)
+		}
+	}
+}
+
+// Close ends every subscription, so that streams finish before the server drains.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, t := range h.topics {
+		for s := range t.subscribers {
+			h.remove(s, ErrClosed)
+		}
+	}
+	h.closed = true
+}
+
+// remove must be called with the lock held; it is a no-op for ended subscriptions.
+func (h *Hub) remove(s *Subscription, err error) {
+	t, ok := h.topics[s.name]
+	if !ok {
+		return
+	}
+	if _, ok := t.subscribers[s]; !ok {
+		return
+	}
+
+	delete(t.subscribers, s)
+	h.subscribers--
+	if len(t.subscribers) == 0 {
+		t.idleSince = time.Now()
+	}
+
+	s.err = err
+	close(s.events)
+}
+
+// sweep forgets, at most once a minute, the topics nobody followed for the
+// retention. It must be called with the lock held.
+func (h *Hub) sweep() {
+	now := time.Now()
+	if now.Sub(h.lastSweep) < time.Minute {
+		return
+	}
+	h.lastSweep = now
+
+	for name, t := range h.topics {
+		if len(t.subscribers) == 0 && now.Sub(t.idleSince) >= h.options.Retention {
+			delete(h.topics, name)
+			h.horizon = h.last
+		}
+	}
+}
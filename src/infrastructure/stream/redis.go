@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"DDD/src/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// publish numbers an event from the shared counter and publishes it in the
+// same step, so that ids grow in the order of the channel.
+var publish = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+redis.call('PUBLISH', KEYS[2], id .. ' ' .. ARGV[1])
+return id
+`)
+
+// RedisRelay shares the events of the streams between replicas through Redis
+// pub/sub. Every replica publishes to the channel instead of its own hub, and
+// every hub receives the events of all of them, numbered by a shared counter,
+// so that a client resumes on any replica.
+type RedisRelay struct {
+	hub     *Hub
+	client  redis.UniversalClient
+	channel string
+	counter string
+}
+
+func NewRedisRelay(hub *Hub, client redis.UniversalClient, prefix string) *RedisRelay {
+	return &RedisRelay{hub: hub, client: client, channel: prefix + "events", counter: prefix + "last"}
+}
+
+type message struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// HandleEvent publishes the events streams follow to every replica; subscribe
+// it to the event bus instead of the hub.
+func (r *RedisRelay) HandleEvent(ctx context.Context, event domain.Event) {
+	name, data, ok := encode(ctx, event)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(message{Topic: name, Type: event.EventName(), Data: data})
+	if err == nil {
+		err = publish.Run(ctx, r.client, []string{r.counter, r.channel}, payload).Err()
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to relay stream event", slog.String("event", event.EventName()), slog.String("error", err.Error()))
+	}
+}
+
+// Run delivers the events of the channel to the hub until ctx is cancelled.
+// Events published while it is not subscribed are lost, so the hub resyncs
+// whenever the subscription starts, after a reconnection too.
+func (r *RedisRelay) Run(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, r.channel)
+	defer pubsub.Close()
+
+	for ctx.Err() == nil {
+		// Receiving does not return on cancellation, so it is given a timeout.
+		received, err := pubsub.ReceiveTimeout(ctx, time.Second)
+		if err != nil {
+			var netErr net.Error
+			if ctx.Err() != nil || errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			slog.ErrorContext(ctx, "failed to receive stream events", slog.String("error", err.Error()))
+			if !sleep(ctx, time.Second) {
+				return
+			}
+			continue
+		}
+
+		switch m := received.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				r.resync(ctx)
+			}
+		case *redis.Message:
+			r.deliver(ctx, m.Payload)
+		}
+	}
+}
+
+// resync reads the latest id once subscribed: the events up to it were
+// missed, and those after it are received. It retries until it can read it.
+func (r *RedisRelay) resync(ctx context.Context) {
+	for {
+		last, err := r.client.Get(ctx, r.counter).Uint64()
+		if err == nil || errors.Is(err, redis.Nil) {
+			r.hub.resync(last)
+			return
+		}
+
+		slog.ErrorContext(ctx, "failed to read the latest stream event", slog.String("error", err.Error()))
+		if !sleep(ctx, time.Second) {
+			return
+		}
+	}
+}
+
+func (r *RedisRelay) deliver(ctx context.Context, payload string) {
+	var m message
+	id, body, _ := strings.Cut(payload, " ")
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err == nil {
+		err = json.Unmarshal([]byte(body), &m)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to decode stream event", slog.String("error", err.Error()))
+		return
+	}
+
+	r.hub.relay(m.Topic, Event{Id: n, Type: m.Type, Data: m.Data})
+}
+
+// sleep waits for d, and tells false when ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the trace of
+// the incoming traceparent header. It must be registered before the handlers
+// render errors, so that the recorded status is the one sent.
+func Middleware() fiber.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c: c})
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPathKey.String(c.Path()),
+				semconv.ClientAddressKey.String(c.IP()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			semconv.HTTPRouteKey.String(route),
+			semconv.HTTPResponseStatusCodeKey.Int(status),
+		)
+
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= fiber.StatusInternalServerError || err != nil {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+
+		return err
+	}
+}
+
+// headerCarrier adapts the request and response headers to the propagator.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key string, value string) {
+	h.c.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
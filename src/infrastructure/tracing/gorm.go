@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// GormPlugin creates a child span of the statement context for every SQL
+// statement. Query parameters are not recorded.
+type GormPlugin struct {
+	tracer trace.Tracer
+}
+
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{tracer: otel.Tracer(instrumentationName)}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", p.before("INSERT")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", p.before("SELECT")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", p.before("UPDATE")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("DELETE")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", p.before("ROW")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("RAW")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+
+		_, span := p.tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationNameKey.String(operation),
+				semconv.DBCollectionNameKey.String(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(semconv.DBQueryTextKey.String(db.Statement.SQL.String()))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// UnaryServerInterceptor starts a server span for every call, continuing the
+// trace of the incoming traceparent metadata. It must run before the
+// interceptor that maps errors to status codes, so that it records the code sent.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+		ctx, span := tracer.Start(ctx, service+"/"+method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCService(service),
+				semconv.RPCMethod(method),
+			),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, code.String())
+		}
+
+		return resp, err
+	}
+}
+
+// metadataCarrier adapts the incoming metadata to the propagator.
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (m metadataCarrier) Set(key string, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"DDD/src/infrastructure/config"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"io"
+)
+
+const instrumentationName = "DDD/src/infrastructure/tracing"
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg config.Tracing, serviceName string, stdout io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg, stdout)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := NewProvider(exporter,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a provider exporting in batches to exporter. Tests pass
+// a tracetest.InMemoryExporter and read the recorded spans from it.
+func NewProvider(exporter sdktrace.SpanExporter, options ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithBatcher(exporter)}, options...)...)
+}
+
+func newExporter(ctx context.Context, cfg config.Tracing, stdout io.Writer) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(stdout))
+	case "otlp":
+		var options []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"DDD/src/application/webhook"
+	"DDD/src/domain"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type Options struct {
+	// Interval is how often the queue is polled for due deliveries.
+	Interval time.Duration
+	// Timeout bounds every attempt, from connecting to reading the response.
+	Timeout time.Duration
+	// Concurrency is how many deliveries are in flight at once.
+	Concurrency int
+	// AllowPrivateNetworks lets webhooks target loopback and private
+	// addresses, which are refused otherwise so that subscriptions cannot
+	// probe the internal network.
+	AllowPrivateNetworks bool
+	// UserAgent is sent with every delivery.
+	UserAgent string
+}
+
+// Dispatcher posts due deliveries to their webhooks, and records the outcome.
+type Dispatcher struct {
+	service *applicationWebhook.WebhookService
+	client  *http.Client
+	logger  *slog.Logger
+	options Options
+}
+
+func NewDispatcher(service *applicationWebhook.WebhookService, logger *slog.Logger, options Options) *Dispatcher {
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	if !options.AllowPrivateNetworks {
+		dialer.Control = refusePrivate
+	}
+
+	return &Dispatcher{
+		service: service,
+		logger:  logger,
+		options: options,
+		client: &http.Client{
+			Timeout: options.Timeout,
+			// A proxy would dial on our behalf, past the address check.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: options.Timeout,
+				MaxIdleConnsPerHost: options.Concurrency,
+			},
+			// Receivers answer, they do not send us elsewhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again, a batch at a time.
+		for {
+			n, err := d.Dispatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				d.logger.Error("failed to dispatch webhook deliveries", slog.String("error", err.Error()))
+			}
+			if err != nil || n < d.batchSize() {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempts one batch of due deliveries and tells how many it attempted.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	// A batch is attempted all at once, each attempt bounded by the timeout,
+	// so a lease of two timeouts leaves room to record the outcomes before
+	// another replica may claim the deliveries again.
+	due, err := d.service.ClaimDue(ctx, 2*d.options.Timeout, d.batchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	var group errgroup.Group
+	group.SetLimit(d.options.Concurrency)
+	for _, next := range due {
+		group.Go(func() error {
+			return d.attempt(ctx, next)
+		})
+	}
+
+	return len(due), group.Wait()
+}
+
+// batchSize claims no more deliveries than are attempted at once: those
+// waiting for a free slot would see their lease run out meanwhile.
+func (d *Dispatcher) batchSize() int {
+	return d.options.Concurrency
+}
+
+// attempt posts a delivery once. Failures of the receiver are recorded on the
+// delivery, only failures to record them are returned.
+func (d *Dispatcher) attempt(ctx context.Context, due applicationWebhook.DueDelivery) error {
+	delivery, webhook := &due.Delivery, due.Webhook
+
+	status, err := d.post(ctx, webhook.Url, webhook.Secret, delivery.EventId, delivery.Event, []byte(delivery.Payload))
+	if ctx.Err() != nil {
+		// Cut short by the shutdown, the receiver may have the payload or not.
+		// The delivery stays claimed until its lease ends and is then sent
+		// again, receivers tell repeats apart by their Webhook-Id.
+		return nil
+	}
+
+	if err == nil {
+		return d.record(d.service.RecordSuccess(context.WithoutCancel(ctx), delivery, status), delivery)
+	}
+
+	d.logger.Warn("webhook delivery failed",
+		slog.Uint64("webhook", uint64(webhook.Id)),
+		slog.Uint64("delivery", uint64(delivery.Id)),
+		slog.Int("attempt", delivery.Attempts+1),
+		slog.String("error", err.Error()),
+	)
+
+	return d.record(d.service.RecordFailure(context.WithoutCancel(ctx), delivery, status, err.Error()), delivery)
+}
+
+// record tells the error of recording an outcome. An attempt that outlived its
+// lease leaves the delivery to the worker that claimed it since.
+func (d *Dispatcher) record(err error, delivery *domain.WebhookDelivery) error {
+	if errors.Is(err, domain.ErrConflict) {
+		d.logger.Warn("webhook delivery taken over, outcome discarded", slog.Uint64("delivery", uint64(delivery.Id)))
+		return nil
+	}
+
+	return err
+}
+
+// post sends a signed payload and tells the response status, 0 without a response.
+func (d *Dispatcher) post(ctx context.Context, url string, secret string, id string, event string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", d.options.UserAgent)
+	req.Header.Set(HeaderId, id)
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Read a little of the body, so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// refusePrivate rejects connections to addresses of the local and private networks.
+func refusePrivate(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errors.New("refusing to connect to a private address " + host)
+	}
+
+	return nil
+}
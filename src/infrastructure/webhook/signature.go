@@ -0,0 +1,41 @@
+// Package webhook posts queued webhook deliveries to their receivers.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderId        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign computes the Webhook-Signature header: the hex HMAC-SHA256, keyed with
+// the webhook secret, of the Unix timestamp, a dot and the body. Signing the
+// timestamp lets receivers reject old deliveries replayed by someone else.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether signature is the signature of body at timestamp, as a receiver checks it.
+func Verify(secret string, timestamp string, signature string, body []byte) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, unix, body)), []byte(signature))
+}
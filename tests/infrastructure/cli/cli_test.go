@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"DDD/src/infrastructure/cli"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run executes a command line on a database and tells its exit code and output.
+func run(t *testing.T, db string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := cli.RunWith(context.Background(), append([]string{"-db", db}, args...), strings.NewReader(""), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+// newDatabase returns the connection string of an empty SQLite database file.
+// An in-memory database would not outlive a command.
+func newDatabase(t *testing.T) string {
+	t.Helper()
+	t.Setenv("LOG_LEVEL", "error")
+
+	return "sqlite://" + filepath.Join(t.TempDir(), "blog.db")
+}
+
+func TestExitCodes(t *testing.T) {
+	db := newDatabase(t)
+
+	tests := []struct {
+		args   []string
+		code   int
+		stderr string
+	}{
+		{args: []string{"help"}, code: 0, stderr: "Commands:"},
+		{args: []string{"seed", "-h"}, code: 0, stderr: "Usage:"},
+		{args: []string{"-config", filepath.Join(t.TempDir(), "missing.yaml"), "config"}, code: 1, stderr: "missing.yaml"},
+		{args: []string{"migrate", "down", "-steps", "0"}, code: 2, stderr: "steps must be at least 1"},
+		{args: []string{"migrate", "sideways"}, code: 2, stderr: `unknown migrate action "sideways"`},
+		{args: []string{"seed", "-posts", "-1"}, code: 2, stderr: "must not be negative"},
+		{args: []string{"seed", "-unknown"}, code: 2, stderr: "flag provided but not defined"},
+		{args: []string{"deploy"}, code: 2, stderr: `unknown command "deploy"`},
+	}
+
+	for _, test := range tests {
+		code, _, stderr := run(t, db, test.args...)
+		if code != test.code || !strings.Contains(stderr, test.stderr) {
+			t.Fatalf("%v: expected exit code %d with %q, got %d with %q", test.args, test.code, test.stderr, code, stderr)
+		}
+	}
+}
+
+func TestMigrateStatus(t *testing.T) {
+	db := newDatabase(t)
+
+	code, out, _ := run(t, db, "migrate", "status")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(lines) < 2 || strings.Join(strings.Fields(lines[0]), " ") != "VERSION NAME APPLIED AT" {
+		t.Fatalf("expected the status table, got %d %q", code, out)
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 3 || fields[0] != "0001" || fields[1] != "create_posts_and_comments" || fields[2] != "pending" {
+		t.Fatalf("expected the first migration to be pending, got %q", lines[1])
+	}
+
+	if code, _, stderr := run(t, db, "migrate", "up"); code != 0 {
+		t.Fatalf("migrate up exited with %d: %s", code, stderr)
+	}
+
+	_, out, _ = run(t, db, "migrate", "status")
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		if strings.HasSuffix(line, "pending") {
+			t.Fatalf("expected every migration to be applied, got %q", line)
+		}
+	}
+}
+
+func TestSeedExportImport(t *testing.T) {
+	source, target := newDatabase(t), newDatabase(t)
+	dir := t.TempDir()
+	exported, reexported := filepath.Join(dir, "source.json"), filepath.Join(dir, "target.json")
+
+	steps := []struct {
+		db   string
+		args []string
+		out  string
+	}{
+		{db: source, args: []string{"migrate", "up"}},
+		{db: source, args: []string{"seed", "-posts", "3", "-comments", "2"}, out: "Created 3 posts with 2 comments each"},
+		{db: source, args: []string{"export", "-file", exported}},
+		{db: target, args: []string{"migrate", "up"}},
+		{db: target, args: []string{"import", "-file", exported}, out: "Imported 3 posts and 6 comments"},
+		{db: target, args: []string{"export", "-file", reexported}},
+	}
+	for _, step := range steps {
+		code, out, stderr := run(t, step.db, step.args...)
+		if code != 0 || !strings.Contains(out, step.out) {
+			t.Fatalf("%v: exited with %d, %q %q", step.args, code, out, stderr)
+		}
+	}
+
+	var before, after any
+	for path, document := range map[string]*any{exported: &before, reexported: &after} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if err := json.Unmarshal(content, document); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+
+	if posts := before.(map[string]any)["posts"].([]any); len(posts) != 3 {
+		t.Fatalf("expected the 3 seeded posts exported, got %d", len(posts))
+	}
+	beforeJson, _ := json.Marshal(before)
+	afterJson, _ := json.Marshal(after)
+	if !bytes.Equal(beforeJson, afterJson) {
+		t.Fatalf("expected the import to export the same document\nsource %s\ntarget %s", beforeJson, afterJson)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	code, out, _ := run(t, newDatabase(t), "routes")
+	if code != 0 || !strings.HasPrefix(strings.Join(strings.Fields(out), " "), "METHOD PATH") {
+		t.Fatalf("expected the route table, got %d %q", code, out)
+	}
+
+	for _, route := range []string{"GET /api/v1/posts/:id", "POST /api/v2/comments/", "GET /readyz", "POST /graphql"} {
+		found := false
+		for _, line := range strings.Split(out, "\n") {
+			found = found || strings.Join(strings.Fields(line), " ") == route
+		}
+		if !found {
+			t.Fatalf("expected %s in the route table, got %q", route, out)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	t.Setenv("APP_NAME", "FromEnv")
+	db := newDatabase(t)
+
+	code, out, _ := run(t, db, "config")
+	if code != 0 || !strings.Contains(out, "name: FromEnv") {
+		t.Fatalf("expected the effective configuration, got %d %q", code, out)
+	}
+	if strings.Contains(out, db) || !strings.Contains(out, "[REDACTED]") {
+		t.Fatalf("expected the connection string to be redacted, got %q", out)
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"DDD/src/infrastructure/cli"
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	db := newDatabase(t)
+	redis := miniredis.RunT(t)
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	t.Setenv("APP_PORT", port)
+	t.Setenv("SERVER_SHUTDOWN_DELAY", "200ms")
+	t.Setenv("REDIS_URL", "redis://"+redis.Addr())
+	t.Setenv("STREAM_RELAY", "redis")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exited := make(chan int, 1)
+	go func() {
+		exited <- cli.RunWith(ctx, []string{"-db", db, "serve"}, strings.NewReader(""), io.Discard, io.Discard)
+	}()
+
+	base := "http://127.0.0.1:" + port
+	readiness := func() int {
+		resp, err := http.Get(base + "/readyz")
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	waitFor(t, "the server to be ready", func() bool { return readiness() == http.StatusOK })
+
+	// A slow client sends the headers of its request, and the body only once
+	// the server is shutting down.
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	body := `{"title":"Sent slowly","content":"Content"}`
+	fmt.Fprintf(conn, "POST /api/v1/posts HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n", len(body))
+
+	cancel()
+	waitFor(t, "the server to report not ready", func() bool { return readiness() == http.StatusServiceUnavailable })
+
+	// Past the delay, the listener closes and the request is drained.
+	time.Sleep(300 * time.Millisecond)
+	if _, err := http.Get(base + "/livez"); err == nil {
+		t.Fatal("expected new connections to be refused once draining")
+	}
+
+	io.WriteString(conn, body)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("expected the request in flight to complete: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected the post to be created, got %d", resp.StatusCode)
+	}
+
+	select {
+	case code := <-exited:
+		if code != 0 {
+			t.Fatalf("expected serve to exit with 0, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected serve to exit once drained")
+	}
+
+	// The container closed Redis, the stream relay subscription included.
+	waitFor(t, "the Redis connections to be closed", func() bool { return redis.CurrentConnectionCount() == 0 })
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"DDD/src/infrastructure/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write creates a file in a temporary directory and returns its path.
+func write(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return path
+}
+
+func TestSourcesOverrideEachOther(t *testing.T) {
+	yamlFile := write(t, "config.yaml", `
+app:
+  name: FromYaml
+server:
+  port: 4000
+  read_timeout: 20s
+  write_timeout: 20s
+  idle_timeout: 90s
+`)
+	envFile := write(t, ".env", "DB_CONNECTION=sqlite://:memory:\nSERVER_WRITE_TIMEOUT=30s\nSERVER_IDLE_TIMEOUT=2m\n")
+	t.Setenv("SERVER_IDLE_TIMEOUT", "3m")
+
+	cfg, err := config.Load(config.Sources{
+		YamlFile:         yamlFile,
+		YamlFileRequired: true,
+		EnvFile:          envFile,
+		EnvFileRequired:  true,
+		Override: func(cfg *config.Config) {
+			cfg.App.Name = "FromFlags"
+		},
+	})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	defaults := config.Default()
+	if cfg.Server.ShutdownTimeout != defaults.Server.ShutdownTimeout {
+		t.Fatalf("expected the default shutdown timeout, got %s", cfg.Server.ShutdownTimeout)
+	}
+	if cfg.Server.Port != 4000 || cfg.Server.ReadTimeout != 20*time.Second {
+		t.Fatalf("expected the YAML over the defaults, got %d %s", cfg.Server.Port, cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.WriteTimeout != 30*time.Second {
+		t.Fatalf("expected the env file over the YAML, got %s", cfg.Server.WriteTimeout)
+	}
+	if cfg.Server.IdleTimeout != 3*time.Minute {
+		t.Fatalf("expected the environment over the env file, got %s", cfg.Server.IdleTimeout)
+	}
+	if cfg.App.Name != "FromFlags" {
+		t.Fatalf("expected the override last, got %q", cfg.App.Name)
+	}
+
+	if _, ok := os.LookupEnv("SERVER_WRITE_TIMEOUT"); ok {
+		t.Fatal("expected the env file not to leak into the environment")
+	}
+}
+
+func TestMissingFilesAreOptionalUnlessRequired(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	t.Setenv("DB_CONNECTION", "sqlite://:memory:")
+
+	if _, err := config.Load(config.Sources{YamlFile: missing, EnvFile: missing}); err != nil {
+		t.Fatalf("expected optional files to be skipped, got %v", err)
+	}
+	if _, err := config.Load(config.Sources{YamlFile: missing, YamlFileRequired: true}); err == nil {
+		t.Fatal("expected a required YAML file to be read")
+	}
+	if _, err := config.Load(config.Sources{EnvFile: missing, EnvFileRequired: true}); err == nil {
+		t.Fatal("expected a required env file to be read")
+	}
+}
+
+func TestUnknownYamlKeysAreRejected(t *testing.T) {
+	yamlFile := write(t, "config.yaml", "server:\n  prot: 4000\n")
+
+	_, err := config.Load(config.Sources{YamlFile: yamlFile})
+	if err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Fatalf("expected the unknown key to be reported, got %v", err)
+	}
+}
+
+func TestInvalidEnvironmentValues(t *testing.T) {
+	t.Setenv("SERVER_READ_TIMEOUT", "soon")
+
+	_, err := config.Load(config.Sources{})
+	if err == nil || !strings.Contains(err.Error(), "SERVER_READ_TIMEOUT") {
+		t.Fatalf("expected the variable to be named, got %v", err)
+	}
+}
+
+func TestValidationReportsEverySetting(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Port = 0
+	cfg.Grpc.Port = 70000
+	cfg.RateLimit.Store = "redis"
+	cfg.Stream.Relay = "kafka"
+	cfg.Server.ProxyHeader = "X-Real-IP"
+	cfg.Server.TrustedProxies = config.List{"10.0.0.0/8", "proxy"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected the configuration to be invalid")
+	}
+
+	for _, expected := range []string{
+		"server.port (APP_PORT) must be between 1 and 65535, got 0",
+		"grpc.port (GRPC_PORT) must be between 1 and 65535, got 70000",
+		"rate_limit.store (RATE_LIMIT_STORE) is redis, but redis.url (REDIS_URL) is not set",
+		`stream.relay (STREAM_RELAY) must be one of [memory redis], got "kafka"`,
+		`server.trusted_proxies (SERVER_TRUSTED_PROXIES) must list IPs and CIDR ranges, got "proxy"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in:\n%v", expected, err)
+		}
+	}
+
+	valid := config.Default()
+	valid.Database.Connection = "sqlite://:memory:"
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected the defaults with a database to be valid, got %v", err)
+	}
+}
+
+func TestOptionalFeaturesAreOffByDefault(t *testing.T) {
+	cfg := config.Default()
+
+	if cfg.Grpc.Reflection {
+		t.Error("expected gRPC reflection to be opt-in")
+	}
+	if cfg.Notifications.Enabled {
+		t.Error("expected email notifications to be opt-in")
+	}
+}
+
+func TestSecretsAreRedacted(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Connection = "host=db password=hunter2"
+	cfg.Webhooks.AdminToken = "hunter2"
+
+	printed := map[string]string{
+		"config command": cfg.String(),
+		"%v":             fmt.Sprintf("%v", cfg),
+		"%+v":            fmt.Sprintf("%+v", cfg),
+		"%#v":            fmt.Sprintf("%#v", cfg),
+	}
+	if encoded, err := json.Marshal(cfg); err != nil {
+		t.Fatalf("marshal: %v", err)
+	} else {
+		printed["json"] = string(encoded)
+	}
+
+	for name, output := range printed {
+		if strings.Contains(output, "hunter2") || !strings.Contains(output, "[REDACTED]") {
+			t.Errorf("expected the secrets redacted in the %s output, got:\n%s", name, output)
+		}
+	}
+
+	if cfg.Database.Connection.Reveal() != "host=db password=hunter2" {
+		t.Fatalf("expected the secret to be revealed on purpose, got %q", cfg.Database.Connection.Reveal())
+	}
+	if cfg.Redis.Url.String() != "" {
+		t.Fatalf("expected an unset secret to print empty, got %q", cfg.Redis.Url.String())
+	}
+}
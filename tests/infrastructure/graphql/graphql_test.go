@@ -0,0 +1,137 @@
+package graphql
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/tests/testenv"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newApp(t *testing.T) (func(method, query string, variables map[string]any) (int, response), *atomic.Int32) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.GraphQL.MaxDepth = 6
+		cfg.GraphQL.MaxComplexity = 500
+	})
+
+	var commentQueries atomic.Int32
+	env.C.DB.Callback().Query().After("gorm:query").Register("test:count_comments", func(db *gorm.DB) {
+		if db.Statement.Table == "post_comments" {
+			commentQueries.Add(1)
+		}
+	})
+
+	send := func(method, query string, variables map[string]any) (int, response) {
+		target, body := "/graphql", ""
+		if method == "GET" {
+			target += "?query=" + url.QueryEscape(query)
+		} else {
+			payload, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+			body = string(payload)
+		}
+
+		resp, out := env.Do(method, target, body)
+
+		var result response
+		if err := json.Unmarshal(out, &result); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+
+		return resp.StatusCode, result
+	}
+
+	return send, &commentQueries
+}
+
+func TestGraphQL(t *testing.T) {
+	send, commentQueries := newApp(t)
+
+	for i := 0; i < 3; i++ {
+		status, result := send("POST", `mutation($input: CreatePostInput!) { createPost(input: $input) { id title } }`, map[string]any{
+			"input": map[string]any{"title": fmt.Sprintf("Post %d", i), "content": "Content"},
+		})
+		if status != 200 || len(result.Errors) > 0 {
+			t.Fatalf("create post: %d %+v", status, result.Errors)
+		}
+
+		for j := 0; j < 2; j++ {
+			status, result := send("POST", `mutation($postId: ID!) { createComment(input: {postId: $postId, text: "Nice"}) { id post { id } } }`, map[string]any{
+				"postId": result.Data["createPost"].(map[string]any)["id"],
+			})
+			if status != 200 || len(result.Errors) > 0 {
+				t.Fatalf("create comment: %d %+v", status, result.Errors)
+			}
+		}
+	}
+
+	t.Run("batches nested comments", func(t *testing.T) {
+		commentQueries.Store(0)
+
+		status, result := send("GET", `{ posts(perPage: 3) { totalCount nodes { id comments(perPage: 1) { totalCount nodes { text } pageInfo { hasNextPage } } } } }`, nil)
+		if status != 200 || len(result.Errors) > 0 {
+			t.Fatalf("query: %d %+v", status, result.Errors)
+		}
+
+		nodes := result.Data["posts"].(map[string]any)["nodes"].([]any)
+		if len(nodes) != 3 {
+			t.Fatalf("expected 3 posts, got %d", len(nodes))
+		}
+		for _, node := range nodes {
+			comments := node.(map[string]any)["comments"].(map[string]any)
+			if comments["totalCount"] != float64(2) || len(comments["nodes"].([]any)) != 1 || comments["pageInfo"].(map[string]any)["hasNextPage"] != true {
+				t.Fatalf("unexpected comments page %+v", comments)
+			}
+		}
+
+		if n := commentQueries.Load(); n != 2 {
+			t.Fatalf("expected a page and the counts of the comments of all posts in 2 queries, got %d", n)
+		}
+	})
+
+	t.Run("domain errors", func(t *testing.T) {
+		status, result := send("POST", `{ post(id: "99") { id } }`, nil)
+		if status != 200 || len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "NOT_FOUND" {
+			t.Fatalf("expected NOT_FOUND, got %d %+v", status, result.Errors)
+		}
+
+		status, result = send("POST", `mutation { updatePost(id: "1", input: {title: ""}) { id } }`, nil)
+		if status != 200 || len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+			t.Fatalf("expected BAD_USER_INPUT, got %d %+v", status, result.Errors)
+		}
+	})
+
+	t.Run("mutations need POST", func(t *testing.T) {
+		if status, _ := send("GET", `mutation { deletePost(id: "1") }`, nil); status != 405 {
+			t.Fatalf("expected 405, got %d", status)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		status, result := send("POST", `{ posts { nodes { comments { nodes { post { comments { nodes { text } } } } } } } }`, nil)
+		if status != 400 || !strings.Contains(result.Errors[0].Message, "depth") {
+			t.Fatalf("expected the depth limit, got %d %+v", status, result.Errors)
+		}
+
+		status, result = send("POST", `query($size: Int) { posts(perPage: $size) { nodes { comments(perPage: 100) { nodes { text } } } } }`, map[string]any{"size": 100})
+		if status != 400 || !strings.Contains(result.Errors[0].Message, "complexity") {
+			t.Fatalf("expected the complexity limit, got %d %+v", status, result.Errors)
+		}
+
+		status, result = send("POST", `{ __schema { types { name fields { name type { ofType { ofType { name } } } } } } }`, nil)
+		if status != 200 || len(result.Errors) > 0 {
+			t.Fatalf("expected introspection to ignore the limits, got %d %+v", status, result.Errors)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/cli"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/grpc/pb"
+	"DDD/tests/testenv"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func dial(t *testing.T) *grpc.ClientConn {
+	c := testenv.New(t, func(cfg *config.Config) {
+		cfg.Grpc.Reflection = true
+	}).C
+
+	lis := bufconn.Listen(1 << 20)
+	server := bootstrap.NewGrpcServer(c)
+	go server.Serve(lis)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestPostsAndComments(t *testing.T) {
+	conn := dial(t)
+	ctx := context.Background()
+	posts, comments := pb.NewPostsClient(conn), pb.NewCommentsClient(conn)
+
+	post, err := posts.CreatePost(ctx, &pb.CreatePostRequest{Title: "Over gRPC", Content: "Content"})
+	if err != nil || post.GetId() == 0 || post.GetVersion() != 1 {
+		t.Fatalf("create post: %+v %v", post, err)
+	}
+
+	if _, err := posts.CreatePost(ctx, &pb.CreatePostRequest{Title: "Over gRPC", Content: "Content"}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists for a duplicate title, got %v", err)
+	}
+	if _, err := posts.CreatePost(ctx, &pb.CreatePostRequest{Content: "Content"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without a title, got %v", err)
+	}
+
+	updated, err := posts.UpdatePost(ctx, &pb.UpdatePostRequest{Id: post.GetId(), Title: proto.String("Renamed")})
+	if err != nil || updated.GetTitle() != "Renamed" || updated.GetContent() != "Content" || updated.GetVersion() != 2 {
+		t.Fatalf("expected only the title to change: %+v %v", updated, err)
+	}
+
+	comment, err := comments.CreateComment(ctx, &pb.CreateCommentRequest{PostId: post.GetId(), Text: "Nice"})
+	if err != nil || comment.GetPostId() != post.GetId() {
+		t.Fatalf("create comment: %+v %v", comment, err)
+	}
+
+	list, err := comments.ListComments(ctx, &pb.ListCommentsRequest{PostId: post.GetId()})
+	if err != nil || len(list.GetComments()) != 1 || list.GetPagination().GetPerPage() != 10 || list.GetPagination().GetTotalItems() != 1 {
+		t.Fatalf("list comments: %+v %v", list, err)
+	}
+	if _, err := comments.ListComments(ctx, &pb.ListCommentsRequest{PostId: post.GetId(), PerPage: 101}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for too many comments per page, got %v", err)
+	}
+	if _, err := posts.ListPosts(ctx, &pb.ListPostsRequest{Page: -1}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a negative page, got %v", err)
+	}
+
+	if _, err := posts.DeletePost(ctx, &pb.DeletePostRequest{Id: post.GetId()}); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	if _, err := posts.GetPost(ctx, &pb.GetPostRequest{Id: post.GetId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound after the delete, got %v", err)
+	}
+}
+
+func TestHealthAndReflection(t *testing.T) {
+	conn := dial(t)
+	ctx := context.Background()
+
+	for _, service := range []string{"", "ddd.v1.Posts", "ddd.v1.Comments"} {
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected %q to be serving, got %v %v", service, resp.GetStatus(), err)
+		}
+	}
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatalf("reflection: %v", err)
+	}
+	defer stream.CloseSend()
+
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+
+	services := make(map[string]bool)
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services[service.GetName()] = true
+	}
+	if !services["ddd.v1.Posts"] || !services["ddd.v1.Comments"] {
+		t.Fatalf("expected the services to be listed, got %v", services)
+	}
+}
+
+func TestServeStopsWhenTheGrpcPortIsTaken(t *testing.T) {
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer taken.Close()
+
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	httpPort := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	t.Setenv("APP_PORT", httpPort)
+	t.Setenv("GRPC_ENABLED", "true")
+	t.Setenv("GRPC_PORT", strconv.Itoa(taken.Addr().(*net.TCPAddr).Port))
+
+	db := "sqlite://" + filepath.Join(t.TempDir(), "blog.db")
+	if code := cli.Run(context.Background(), []string{"-db", db, "serve"}); code != 1 {
+		t.Fatalf("expected serve to fail, exited with %d", code)
+	}
+
+	// A server left running would bind the port shortly after.
+	time.Sleep(100 * time.Millisecond)
+	lis, err := net.Listen("tcp", ":"+httpPort)
+	if err != nil {
+		t.Fatalf("expected no HTTP server left running: %v", err)
+	}
+	lis.Close()
+}
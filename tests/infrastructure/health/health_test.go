@@ -0,0 +1,73 @@
+package health
+
+import (
+	"DDD/src/infrastructure/health"
+	"DDD/tests/testenv"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	env := testenv.NewUnmigrated(t, nil)
+	c := env.C
+	probe := func(target string) (int, health.Report) {
+		resp, out := env.Do("GET", target, "")
+
+		var report health.Report
+		if err := json.Unmarshal(out, &report); err != nil {
+			t.Fatalf("decode %s: %v", target, err)
+		}
+
+		return resp.StatusCode, report
+	}
+
+	status, report := probe("/readyz")
+	if status != 503 || report.Checks["migrations"].Status != health.StatusDown || report.Checks["database"].Status != health.StatusUp {
+		t.Fatalf("expected not ready with pending migrations, got %d %+v", status, report)
+	}
+
+	if err := c.Migrator().Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	status, report = probe("/readyz")
+	if status != 200 || report.Status != health.StatusUp {
+		t.Fatalf("expected ready, got %d %+v", status, report)
+	}
+
+	c.Health.Shutdown()
+
+	status, report = probe("/readyz")
+	if status != 503 || report.Checks["shutdown"].Status != health.StatusDown {
+		t.Fatalf("expected not ready while shutting down, got %d %+v", status, report)
+	}
+
+	if status, report = probe("/livez"); status != 200 || report.Status != health.StatusUp {
+		t.Fatalf("expected live while shutting down, got %d %+v", status, report)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := health.NewChecker(10 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.Add("broken", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	report := checker.Ready(context.Background())
+	if report.Status != health.StatusDown {
+		t.Fatalf("expected down, got %+v", report)
+	}
+	if report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the slow check to time out, got %+v", report.Checks["slow"])
+	}
+	if report.Checks["broken"].Error != "connection refused" {
+		t.Fatalf("expected the broken check error, got %+v", report.Checks["broken"])
+	}
+}
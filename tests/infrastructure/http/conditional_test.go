@@ -0,0 +1,92 @@
+package http
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/tests/testenv"
+	"strings"
+	"testing"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.HttpCache.Post = "private, max-age=0, must-revalidate"
+	})
+	cfg := env.C.Config
+
+	if resp, _ := env.Do("POST", "/api/v1/posts", `{"title":"Conditional","content":"Content"}`); resp.StatusCode != 201 {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	resp, _ := env.Do("GET", "/api/v1/posts/1", "")
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != 200 || strings.HasPrefix(etag, "W/") || etag == "" || lastModified == "" {
+		t.Fatalf("expected a strong ETag and Last-Modified, got %d %q %q", resp.StatusCode, etag, lastModified)
+	}
+	if policy := resp.Header.Get("Cache-Control"); policy != cfg.HttpCache.Post {
+		t.Fatalf("expected the configured Cache-Control, got %q", policy)
+	}
+
+	resp, _ = env.Do("GET", "/api/v1/posts/1", "", "If-None-Match", etag)
+	if resp.StatusCode != 304 || resp.Header.Get("ETag") != etag || resp.Header.Get("Cache-Control") != cfg.HttpCache.Post {
+		t.Fatalf("expected 304 with the validators and policy, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	if resp, _ = env.Do("GET", "/api/v1/posts/1", "", "If-Modified-Since", lastModified); resp.StatusCode != 304 {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", resp.StatusCode)
+	}
+
+	if resp, _ = env.Do("PATCH", "/api/v1/posts/1", `{"content":"Changed"}`); resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// The update may land within the second of Last-Modified, the ETag still tells.
+	resp, _ = env.Do("GET", "/api/v1/posts/1", "", "If-None-Match", etag, "If-Modified-Since", lastModified)
+	if resp.StatusCode != 200 || resp.Header.Get("ETag") == etag {
+		t.Fatalf("expected the updated post with a new ETag, got %d %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	resp, _ = env.Do("GET", "/api/v1/posts?page=1&per_page=10", "")
+	listEtag := resp.Header.Get("ETag")
+	if resp.StatusCode != 200 || listEtag == "" || resp.Header.Get("Last-Modified") != "" {
+		t.Fatalf("expected a listing with an ETag only, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	if resp, _ = env.Do("GET", "/api/v1/posts?page=1&per_page=10", "", "If-None-Match", listEtag); resp.StatusCode != 304 {
+		t.Fatalf("expected 304 for an unchanged listing, got %d", resp.StatusCode)
+	}
+	if resp, _ = env.Do("GET", "/api/v1/posts?page=1&per_page=5", "", "If-None-Match", listEtag); resp.StatusCode != 200 {
+		t.Fatalf("expected another page size to have another ETag, got %d", resp.StatusCode)
+	}
+
+	if resp, _ = env.Do("DELETE", "/api/v1/posts/1", ""); resp.StatusCode != 204 {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if resp, _ = env.Do("GET", "/api/v1/posts?page=1&per_page=10", "", "If-None-Match", listEtag); resp.StatusCode != 200 {
+		t.Fatalf("expected the listing to change after a delete, got %d", resp.StatusCode)
+	}
+}
+
+func TestCompressedResponsesHaveWeakETags(t *testing.T) {
+	env := testenv.New(t, nil)
+
+	content := strings.Repeat("Long enough to be compressed. ", 10)
+	if resp, _ := env.Do("POST", "/api/v1/posts", `{"title":"Compressed","content":"`+content+`"}`); resp.StatusCode != 201 {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	resp, _ := env.Do("GET", "/api/v1/posts/1", "")
+	strong := resp.Header.Get("ETag")
+	if resp.Header.Get("Content-Encoding") != "" || strings.HasPrefix(strong, "W/") || resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected an uncompressed response with a strong ETag varying by encoding, got %v", resp.Header)
+	}
+
+	resp, _ = env.Do("GET", "/api/v1/posts/1", "", "Accept-Encoding", "gzip")
+	weak := resp.Header.Get("ETag")
+	if resp.Header.Get("Content-Encoding") != "gzip" || weak != "W/"+strong || resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected a compressed response with a weak ETag, got %v", resp.Header)
+	}
+
+	if resp, _ = env.Do("GET", "/api/v1/posts/1", "", "Accept-Encoding", "gzip", "If-None-Match", weak); resp.StatusCode != 304 {
+		t.Fatalf("expected 304 for the weak ETag, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/http/jsonapi"
+	"DDD/tests/testenv"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type document struct {
+	Data     json.RawMessage   `json:"data"`
+	Included []resource        `json:"included"`
+	Links    map[string]string `json:"links"`
+	Meta     map[string]any    `json:"meta"`
+}
+
+type resource struct {
+	Type          string                     `json:"type"`
+	Id            string                     `json:"id"`
+	Attributes    map[string]any             `json:"attributes"`
+	Relationships map[string]json.RawMessage `json:"relationships"`
+	Links         map[string]string          `json:"links"`
+}
+
+// request sends a JSON:API document and decodes the one answered.
+func request(t *testing.T, env *testenv.Env, method, target, body string) (*http.Response, document) {
+	t.Helper()
+
+	resp, out := env.Do(method, target, body, "Content-Type", jsonapi.ContentType)
+
+	var doc document
+	if resp.Header.Get("Content-Type") == jsonapi.ContentType {
+		if err := json.Unmarshal(out, &doc); err != nil {
+			t.Fatalf("decode %s %s: %v", method, target, err)
+		}
+	}
+
+	return resp, doc
+}
+
+func TestJsonApi(t *testing.T) {
+	env := testenv.New(t, nil)
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		resp, doc := request(t, env, "POST", "/api/v2/posts", `{"data":{"type":"posts","attributes":{"title":"`+title+`","content":"Content"}}}`)
+		if resp.StatusCode != 201 || !strings.HasPrefix(resp.Header.Get("Location"), "/api/v2/posts/") {
+			t.Fatalf("expected 201 with a Location, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+		}
+
+		var post resource
+		json.Unmarshal(doc.Data, &post)
+		if post.Type != "posts" || post.Attributes["title"] != title || post.Links["self"] != resp.Header.Get("Location") {
+			t.Fatalf("unexpected created resource %+v", post)
+		}
+	}
+
+	resp, _ := request(t, env, "POST", "/api/v2/comments", `{"data":{"type":"comments","attributes":{"text":"Nice"},"relationships":{"post":{"data":{"type":"posts","id":"1"}}}}}`)
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected 201 for the comment, got %d", resp.StatusCode)
+	}
+
+	if resp, _ := request(t, env, "POST", "/api/v2/comments", `{"data":{"type":"posts","attributes":{"text":"Nice"}}}`); resp.StatusCode != 409 {
+		t.Fatalf("expected 409 for a resource of the wrong type, got %d", resp.StatusCode)
+	}
+	if resp, _ := request(t, env, "POST", "/api/v2/comments", `{"data":{"type":"comments","attributes":{"text":"Nice"}}}`); resp.StatusCode != 400 {
+		t.Fatalf("expected 400 without the post relationship, got %d", resp.StatusCode)
+	}
+
+	t.Run("compound document", func(t *testing.T) {
+		resp, doc := request(t, env, "GET", "/api/v2/posts/1?include=comments", "")
+		if resp.StatusCode != 200 || len(doc.Included) != 1 || doc.Included[0].Type != "comments" {
+			t.Fatalf("expected the comment to be included, got %d %+v", resp.StatusCode, doc.Included)
+		}
+
+		var post resource
+		json.Unmarshal(doc.Data, &post)
+		if !strings.Contains(string(post.Relationships["comments"]), `"data":[{"type":"comments","id":"1"}]`) {
+			t.Fatalf("expected the comments linkage, got %s", post.Relationships["comments"])
+		}
+
+		if resp, _ := request(t, env, "GET", "/api/v2/posts/1?include=author", ""); resp.StatusCode != 400 {
+			t.Fatalf("expected 400 for an unknown include, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("pagination links", func(t *testing.T) {
+		resp, doc := request(t, env, "GET", "/api/v2/posts?page[number]=2&page[size]=1&include=comments", "")
+		if resp.StatusCode != 200 {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+
+		for name, number := range map[string]string{"first": "1", "prev": "1", "next": "3", "last": "3"} {
+			if !strings.Contains(doc.Links[name], "page%5Bnumber%5D="+number) || !strings.Contains(doc.Links[name], "include=comments") {
+				t.Fatalf("unexpected %s link %q", name, doc.Links[name])
+			}
+		}
+
+		if resp, _ := request(t, env, "GET", "/api/v2/posts?page[size]=1000", ""); resp.StatusCode != 400 {
+			t.Fatalf("expected 400 for a page size above the maximum, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		resp, doc := request(t, env, "PATCH", "/api/v2/posts/2", `{"data":{"type":"posts","id":"2","attributes":{"title":"Renamed"}}}`)
+		var post resource
+		json.Unmarshal(doc.Data, &post)
+		if resp.StatusCode != 200 || post.Attributes["title"] != "Renamed" || post.Attributes["content"] != "Content" {
+			t.Fatalf("expected only the title to change, got %d %+v", resp.StatusCode, post.Attributes)
+		}
+
+		if resp, _ := request(t, env, "PATCH", "/api/v2/posts/2", `{"data":{"type":"posts","id":"3","attributes":{}}}`); resp.StatusCode != 409 {
+			t.Fatalf("expected 409 for a mismatching id, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("included comments are paged", func(t *testing.T) {
+		for i := 0; i < jsonapi.DefaultPageSize+1; i++ {
+			text, _ := value_object.NewText(fmt.Sprintf("Comment %d", i))
+			if _, err := env.C.CommentService.CreatePostComment(context.Background(), domain.PostComment{PostId: 3, Text: text}); err != nil {
+				t.Fatalf("create comment: %v", err)
+			}
+		}
+
+		resp, doc := request(t, env, "GET", "/api/v2/posts/3?include=comments", "")
+		if resp.StatusCode != 200 || len(doc.Included) != jsonapi.DefaultPageSize || doc.Included[0].Attributes["text"] != "Comment 10" {
+			t.Fatalf("expected the newest page of comments to be included, got %d %+v", resp.StatusCode, doc.Included)
+		}
+
+		var post resource
+		json.Unmarshal(doc.Data, &post)
+		var comments struct {
+			Links map[string]string `json:"links"`
+			Meta  map[string]any    `json:"meta"`
+		}
+		json.Unmarshal(post.Relationships["comments"], &comments)
+		if comments.Links["next"] != "/api/v2/posts/3/comments?page%5Bnumber%5D=2&page%5Bsize%5D=10" || comments.Meta["total"] != float64(11) {
+			t.Fatalf("expected the relationship to link the other comments, got %s", post.Relationships["comments"])
+		}
+	})
+
+	t.Run("comment relationships", func(t *testing.T) {
+		resp, doc := request(t, env, "GET", "/api/v2/comments/1?include=post", "")
+		if resp.StatusCode != 200 || len(doc.Included) != 1 || doc.Included[0].Links["self"] != "/api/v2/posts/1" {
+			t.Fatalf("expected the post to be included, got %d %+v", resp.StatusCode, doc.Included)
+		}
+	})
+}
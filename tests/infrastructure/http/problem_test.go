@@ -0,0 +1,94 @@
+package http
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/http"
+	"DDD/tests/testenv"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblemDetails(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: http.ErrorHandler})
+	app.Use(http.RequestId())
+
+	failures := map[string]error{
+		"missing":   domain.NewNotFoundError("post with id %d not found", 7),
+		"taken":     domain.NewConflictError("post with title %s already exists", "Taken"),
+		"invalid":   domain.NewValidationError(errors.New("title is too long")),
+		"forbidden": domain.NewForbiddenError("webhook targets a private network"),
+		"teapot":    fiber.NewError(fiber.StatusTeapot, "short and stout"),
+		"broken":    errors.New("dial tcp db:5432: password authentication failed for user app"),
+	}
+	app.Get("/fail/:name", func(c *fiber.Ctx) error {
+		return failures[c.Params("name")]
+	})
+
+	cases := []struct {
+		name string
+		want http.Problem
+	}{
+		{"missing", http.Problem{Type: http.ProblemTypeNotFound, Title: "Not Found", Status: 404, Detail: "post with id 7 not found"}},
+		{"taken", http.Problem{Type: http.ProblemTypeConflict, Title: "Conflict", Status: 409, Detail: "post with title Taken already exists"}},
+		{"invalid", http.Problem{Type: http.ProblemTypeValidation, Title: "Bad Request", Status: 400, Detail: "title is too long"}},
+		{"forbidden", http.Problem{Type: http.ProblemTypeForbidden, Title: "Forbidden", Status: 403, Detail: "webhook targets a private network"}},
+		{"teapot", http.Problem{Type: http.ProblemTypeBlank, Title: "I'm a teapot", Status: 418, Detail: "short and stout"}},
+		// The cause of an internal error is logged, never sent.
+		{"broken", http.Problem{Type: http.ProblemTypeInternal, Title: "Internal Server Error", Status: 500}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/fail/"+tc.name+"?page=2", nil)
+			req.Header.Set(fiber.HeaderXRequestID, "trace-"+tc.name)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.want.Status || resp.Header.Get("Content-Type") != http.ProblemContentType {
+				t.Fatalf("expected %d as %s, got %d %s", tc.want.Status, http.ProblemContentType, resp.StatusCode, resp.Header.Get("Content-Type"))
+			}
+
+			var raw map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			encoded, _ := json.Marshal(raw)
+			if tc.want.Detail == "" {
+				if _, ok := raw["detail"]; ok || strings.Contains(string(encoded), "password") {
+					t.Fatalf("expected no detail, got %s", encoded)
+				}
+			}
+
+			var got http.Problem
+			_ = json.Unmarshal(encoded, &got)
+			tc.want.Instance = "/fail/" + tc.name + "?page=2"
+			tc.want.TraceId = "trace-" + tc.name
+			if got != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDomainErrorsAreProblems(t *testing.T) {
+	env := testenv.New(t, nil)
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Taken","content":"Content"}`)
+	status, body := env.Send("POST", "/api/v1/posts", `{"title":"Taken","content":"Other content"}`)
+	if status != 409 || body["type"] != http.ProblemTypeConflict || body["status"] != float64(409) || body["instance"] != "/api/v1/posts" {
+		t.Fatalf("expected a conflict problem, got %d %v", status, body)
+	}
+
+	status, body = env.Send("GET", "/api/v1/posts/99", "")
+	if status != 404 || body["type"] != http.ProblemTypeNotFound || body["detail"] != "post with id 99 not found" {
+		t.Fatalf("expected a not found problem, got %d %v", status, body)
+	}
+}
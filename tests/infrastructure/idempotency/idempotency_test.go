@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/idempotency"
+	"DDD/tests/testenv"
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) idempotency.Store{
+		"memory": func(t *testing.T) idempotency.Store {
+			return idempotency.NewMemoryStore()
+		},
+		"redis": func(t *testing.T) idempotency.Store {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			return idempotency.NewRedisStore(client, "test:")
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			if stored, err := store.Begin(ctx, "key", time.Minute); stored != nil || err != nil {
+				t.Fatalf("expected the key to be locked for the caller, got %v %v", stored, err)
+			}
+			if _, err := store.Begin(ctx, "key", time.Minute); !errors.Is(err, idempotency.ErrInProgress) {
+				t.Fatalf("expected a concurrent duplicate to be refused, got %v", err)
+			}
+
+			if err := store.Abort(ctx, "key"); err != nil {
+				t.Fatalf("abort: %v", err)
+			}
+			if stored, err := store.Begin(ctx, "key", time.Minute); stored != nil || err != nil {
+				t.Fatalf("expected an aborted key to be free again, got %v %v", stored, err)
+			}
+
+			response := idempotency.Response{Fingerprint: "abc", Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+			if err := store.Complete(ctx, "key", response, time.Minute); err != nil {
+				t.Fatalf("complete: %v", err)
+			}
+
+			stored, err := store.Begin(ctx, "key", time.Minute)
+			if err != nil || stored == nil || stored.Status != 201 || string(stored.Body) != `{"id":1}` {
+				t.Fatalf("expected the stored response, got %+v %v", stored, err)
+			}
+		})
+	}
+}
+
+// post sends a JSON body under an Idempotency-Key, none when key is empty,
+// and tells whether the response was replayed.
+func post(env *testenv.Env, target, key, body string, headers ...string) (int, string, string) {
+	if key != "" {
+		headers = append(headers, idempotency.HeaderKey, key)
+	}
+
+	resp, out := env.Do("POST", target, body, headers...)
+	return resp.StatusCode, string(out), resp.Header.Get(idempotency.HeaderReplayed)
+}
+
+func TestReplay(t *testing.T) {
+	env := testenv.New(t, nil)
+
+	if status, _, _ := post(env, "/api/v1/posts", "", `{"title":"Retried","content":"Content"}`); status != 201 {
+		t.Fatalf("expected 201, got %d", status)
+	}
+
+	status, first, _ := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"Only once"}`)
+	if status != 201 {
+		t.Fatalf("expected 201, got %d %s", status, first)
+	}
+
+	status, replayed, header := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"Only once"}`)
+	if status != 201 || replayed != first || header != "true" {
+		t.Fatalf("expected the first response replayed, got %d %s %q", status, replayed, header)
+	}
+
+	if status, body, _ := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"Something else"}`); status != 422 {
+		t.Fatalf("expected 422 for a reused key, got %d %s", status, body)
+	}
+
+	// A failed request stores nothing, so that it can be retried once fixed.
+	if status, _, _ := post(env, "/api/v1/posts/1/comments", "comment-2", `{"text":""}`); status != 400 {
+		t.Fatalf("expected 400, got %d", status)
+	}
+	if status, _, _ := post(env, "/api/v1/posts/1/comments", "comment-2", `{"text":"Fixed text"}`); status != 201 {
+		t.Fatalf("expected the retry to run, got %d", status)
+	}
+
+	comments, err := env.C.CommentRepo.FindByPostId(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("find comments: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(comments))
+	}
+}
+
+func TestKeysAreScopedByClient(t *testing.T) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.RateLimit.Key = "api_key"
+	})
+
+	post(env, "/api/v1/posts", "", `{"title":"Shared","content":"Content"}`)
+
+	status, first, _ := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"From one"}`, "X-API-Key", "one")
+	if status != 201 {
+		t.Fatalf("expected 201, got %d %s", status, first)
+	}
+
+	// Another client using the same key neither gets the response of the first nor is refused.
+	status, second, header := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"From another"}`, "X-API-Key", "another")
+	if status != 201 || second == first || header != "" {
+		t.Fatalf("expected the request of another client to run, got %d %s %q", status, second, header)
+	}
+
+	if _, replayed, header := post(env, "/api/v1/posts/1/comments", "comment-1", `{"text":"From one"}`, "X-API-Key", "one"); replayed != first || header != "true" {
+		t.Fatalf("expected the first client to get its response replayed, got %s %q", replayed, header)
+	}
+}
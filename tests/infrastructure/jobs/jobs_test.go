@@ -0,0 +1,405 @@
+package jobs
+
+import (
+	applicationJob "DDD/src/application/job"
+	"DDD/src/domain"
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/jobs"
+	"DDD/src/infrastructure/mail"
+	"DDD/tests/testenv"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+var greet = applicationJob.Kind[greeting]{Type: "test.greet", Queue: "greetings"}
+
+func newEnv(t *testing.T, configure func(cfg *config.Config)) *testenv.Env {
+	return testenv.New(t, func(cfg *config.Config) {
+		cfg.Jobs.Queues = config.Queues{"default": 1, "greetings": 2}
+		cfg.Jobs.MaxAttempts = 2
+		cfg.Jobs.Backoff = 0
+		cfg.Jobs.MaxBackoff = 0
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+}
+
+// send calls the admin API, bearing token unless it is empty.
+func send(env *testenv.Env, method, target, token string) (int, map[string]any) {
+	if token == "" {
+		return env.Send(method, target, "")
+	}
+
+	return env.Send(method, target, "", "Authorization", "Bearer "+token)
+}
+
+func work(t *testing.T, c *bootstrap.Container, queue string, expected int) {
+	t.Helper()
+
+	n, err := c.Jobs.Work(context.Background(), queue)
+	if err != nil || n != expected {
+		t.Fatalf("expected %d jobs of %s to run, got %d %v", expected, queue, n, err)
+	}
+}
+
+func find(t *testing.T, c *bootstrap.Container, id uint) *domain.Job {
+	t.Helper()
+
+	job, err := c.JobService.FindById(context.Background(), int(id))
+	if err != nil {
+		t.Fatalf("find job: %v", err)
+	}
+
+	return job
+}
+
+func TestTypedJobsWithRetries(t *testing.T) {
+	env := newEnv(t, nil)
+	ctx := context.Background()
+
+	var greeted []string
+	jobs.Handle(env.C.Jobs, greet, func(ctx context.Context, payload greeting) error {
+		greeted = append(greeted, payload.Name)
+		if len(greeted) == 1 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	job, err := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Ada"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if job.Queue != "greetings" || job.MaxAttempts != 2 || job.Status != domain.JobPending {
+		t.Fatalf("expected a pending job of the kind's queue, got %+v", job)
+	}
+
+	// Queues are worked on separately.
+	work(t, env.C, "default", 0)
+
+	work(t, env.C, "greetings", 1)
+	if failed := find(t, env.C, job.Id); failed.Status != domain.JobPending || failed.Attempts != 1 || failed.LastError != "not yet" {
+		t.Fatalf("expected the job to be retried, got %+v", failed)
+	}
+
+	work(t, env.C, "greetings", 1)
+	done := find(t, env.C, job.Id)
+	if done.Status != domain.JobSucceeded || done.Attempts != 2 || done.FinishedAt == nil || done.LastError != "" {
+		t.Fatalf("expected the job to succeed, got %+v", done)
+	}
+	if len(greeted) != 2 || greeted[1] != "Ada" {
+		t.Fatalf("expected the payload to be decoded on every run, got %v", greeted)
+	}
+
+	work(t, env.C, "greetings", 0)
+}
+
+func TestScheduledJobs(t *testing.T) {
+	env := newEnv(t, nil)
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	jobs.Handle(env.C.Jobs, greet, func(ctx context.Context, payload greeting) error {
+		runs.Add(1)
+		return nil
+	})
+
+	if _, err := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Later"}, applicationJob.After(time.Hour)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	work(t, env.C, "greetings", 0)
+
+	if _, err := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Overdue"}, applicationJob.At(time.Now().Add(-time.Minute))); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	work(t, env.C, "greetings", 1)
+
+	if runs.Load() != 1 {
+		t.Fatalf("expected only the due job to run, got %d runs", runs.Load())
+	}
+}
+
+func TestFailedJobsAreRetriedByHand(t *testing.T) {
+	env := newEnv(t, func(cfg *config.Config) {
+		cfg.Jobs.AdminToken = "admin-token"
+	})
+	ctx := context.Background()
+
+	var panicked atomic.Bool
+	jobs.Handle(env.C.Jobs, greet, func(ctx context.Context, payload greeting) error {
+		if !panicked.Swap(true) {
+			panic("boom")
+		}
+		return errors.New("still failing")
+	})
+
+	job, _ := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Grace"})
+	work(t, env.C, "greetings", 1)
+	work(t, env.C, "greetings", 1)
+	work(t, env.C, "greetings", 0)
+
+	failed := find(t, env.C, job.Id)
+	if failed.Status != domain.JobFailed || failed.Attempts != 2 || failed.LastError != "still failing" {
+		t.Fatalf("expected the job to fail once out of attempts, got %+v", failed)
+	}
+
+	// Jobs of types without a handler fail too.
+	unknown := applicationJob.Kind[greeting]{Type: "test.unknown", MaxAttempts: 1}
+	orphan, _ := unknown.Enqueue(ctx, env.C.JobService, greeting{})
+	work(t, env.C, "default", 1)
+	if failed := find(t, env.C, orphan.Id); failed.Status != domain.JobFailed || !strings.Contains(failed.LastError, "no handler") {
+		t.Fatalf("expected the job without handler to fail, got %+v", failed)
+	}
+
+	if status, _ := send(env, "GET", "/api/v1/admin/jobs", ""); status != 401 {
+		t.Fatalf("expected 401 without the token, got %d", status)
+	}
+	if status, _ := send(env, "GET", "/api/v1/admin/jobs", "wrong"); status != 401 {
+		t.Fatalf("expected 401 with a wrong token, got %d", status)
+	}
+
+	status, page := send(env, "GET", "/api/v1/admin/jobs?status=failed&queue=greetings", "admin-token")
+	data, _ := page["data"].([]any)
+	if status != 200 || len(data) != 1 {
+		t.Fatalf("expected the failed job of the queue to be listed, got %d %v", status, page)
+	}
+	if listed := data[0].(map[string]any); listed["lastError"] != "still failing" || listed["payload"].(map[string]any)["name"] != "Grace" {
+		t.Fatalf("expected the error and payload to be listed, got %v", listed)
+	}
+	if status, _ := send(env, "GET", "/api/v1/admin/jobs?status=lost", "admin-token"); status != 400 {
+		t.Fatalf("expected 400 for an unknown status, got %d", status)
+	}
+	if status, _ := send(env, "GET", "/api/v1/admin/jobs/99", "admin-token"); status != 404 {
+		t.Fatalf("expected 404 for a missing job, got %d", status)
+	}
+
+	target := fmt.Sprintf("/api/v1/admin/jobs/%d/retry", job.Id)
+	status, retried := send(env, "POST", target, "admin-token")
+	if status != 202 || retried["status"] != domain.JobPending || retried["attempts"] != float64(0) {
+		t.Fatalf("expected the job to be queued again, got %d %v", status, retried)
+	}
+	if status, _ := send(env, "POST", target, "admin-token"); status != 409 {
+		t.Fatalf("expected 409 to retry a pending job, got %d", status)
+	}
+
+	work(t, env.C, "greetings", 1)
+	if again := find(t, env.C, job.Id); again.Attempts != 1 || again.Status != domain.JobPending {
+		t.Fatalf("expected a fresh set of attempts, got %+v", again)
+	}
+}
+
+func TestJobAdminIsClosedWithoutAToken(t *testing.T) {
+	env := newEnv(t, nil)
+
+	for _, token := range []string{"", "guessed"} {
+		if status, _ := send(env, "GET", "/api/v1/admin/jobs", token); status != 401 {
+			t.Fatalf("expected 401 for %q without a token configured, got %d", token, status)
+		}
+	}
+}
+
+func TestExpiredLeasesAreTakenOver(t *testing.T) {
+	env := newEnv(t, func(cfg *config.Config) {
+		cfg.Jobs.Lease = 50 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	jobs.Handle(env.C.Jobs, greet, func(ctx context.Context, payload greeting) error { return nil })
+
+	// A worker claims the jobs, and is lost.
+	taken, _ := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Taken over"})
+	last := applicationJob.Kind[greeting]{Type: greet.Type, Queue: greet.Queue, MaxAttempts: 1}
+	lost, _ := last.Enqueue(ctx, env.C.JobService, greeting{Name: "Lost"})
+	claimed, err := env.C.JobService.ClaimDue(ctx, "greetings", 50*time.Millisecond, 10)
+	if err != nil || len(claimed) != 2 || claimed[0].Status != domain.JobRunning {
+		t.Fatalf("expected both jobs to be claimed, got %+v %v", claimed, err)
+	}
+	work(t, env.C, "greetings", 0)
+
+	time.Sleep(60 * time.Millisecond)
+	work(t, env.C, "greetings", 1)
+
+	if done := find(t, env.C, taken.Id); done.Status != domain.JobSucceeded || done.Attempts != 2 {
+		t.Fatalf("expected the job to be taken over, got %+v", done)
+	}
+	if failed := find(t, env.C, lost.Id); failed.Status != domain.JobFailed || !strings.Contains(failed.LastError, "lease expired") {
+		t.Fatalf("expected the job lost on its last attempt to fail, got %+v", failed)
+	}
+}
+
+func TestOutcomesOfLostLeasesAreDiscarded(t *testing.T) {
+	env := newEnv(t, nil)
+	ctx := context.Background()
+
+	job, _ := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Slow"})
+	stale, err := env.C.JobService.ClaimDue(ctx, "greetings", -time.Millisecond, 10)
+	if err != nil || len(stale) != 1 {
+		t.Fatalf("expected the job to be claimed, got %+v %v", stale, err)
+	}
+	taken, err := env.C.JobService.ClaimDue(ctx, "greetings", time.Hour, 10)
+	if err != nil || len(taken) != 1 {
+		t.Fatalf("expected the job to be taken over, got %+v %v", taken, err)
+	}
+
+	if err := env.C.JobService.RecordSuccess(ctx, &stale[0]); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected a conflict for the lost lease, got %v", err)
+	}
+	if running := find(t, env.C, job.Id); running.Status != domain.JobRunning || running.Attempts != 2 {
+		t.Fatalf("expected the job left to its new worker, got %+v", running)
+	}
+
+	if err := env.C.JobService.RecordSuccess(ctx, &taken[0]); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+	if done := find(t, env.C, job.Id); done.Status != domain.JobSucceeded {
+		t.Fatalf("expected the job done, got %+v", done)
+	}
+}
+
+func TestRunLimitsConcurrency(t *testing.T) {
+	env := newEnv(t, func(cfg *config.Config) {
+		cfg.Jobs.PollInterval = 10 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var running, peak, done int
+	jobs.Handle(env.C.Jobs, greet, func(ctx context.Context, payload greeting) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		done++
+		mu.Unlock()
+		return nil
+	})
+
+	for range 6 {
+		if _, err := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Many"}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	env.C.Workers.Go(env.C.Jobs.Run)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		finished := done
+		mu.Unlock()
+		if finished == 6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected every job to run, %d did", finished)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if peak != 2 {
+		t.Fatalf("expected 2 jobs of the queue at once, got %d", peak)
+	}
+}
+
+func TestEnqueueJoinsTheUnitOfWork(t *testing.T) {
+	env := newEnv(t, nil)
+	ctx := context.Background()
+
+	rolledBack := errors.New("rolled back")
+	err := env.C.UnitOfWork.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		if _, err := greet.Enqueue(ctx, env.C.JobService, greeting{Name: "Never"}); err != nil {
+			return err
+		}
+		return rolledBack
+	})
+	if !errors.Is(err, rolledBack) {
+		t.Fatalf("expected the unit of work to fail, got %v", err)
+	}
+
+	result, err := env.C.JobService.FindPaginatedJobs(ctx, domain.JobFilter{}, 1, 10)
+	if err != nil || result.TotalCount != 0 {
+		t.Fatalf("expected no job to be queued, got %+v %v", result, err)
+	}
+}
+
+func TestParseQueues(t *testing.T) {
+	queues, err := config.ParseQueues("default=4, mail=2")
+	if err != nil || queues["default"] != 4 || queues["mail"] != 2 || queues.String() != "default=4,mail=2" {
+		t.Fatalf("expected two queues, got %v %v", queues, err)
+	}
+
+	for _, invalid := range []string{"default", "default=0", "=2", "default=1,default=2"} {
+		if _, err := config.ParseQueues(invalid); err == nil {
+			t.Fatalf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestNotificationDigestsAreSentByJobs(t *testing.T) {
+	env := newEnv(t, func(cfg *config.Config) {
+		cfg.Mail.Transport = "memory"
+		cfg.Notifications.Enabled = true
+		cfg.Notifications.Delay = 0
+	})
+	ctx := context.Background()
+	mailer := env.C.Mailer.(*mail.MemoryTransport)
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"author@example.com"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Before confirming"}`)
+	work(t, env.C, "default", 1)
+	if messages := mailer.Messages(); len(messages) != 1 || !strings.Contains(messages[0].Subject, "Confirm") {
+		t.Fatalf("expected the confirmation request, got %v", messages)
+	}
+
+	preference, err := env.C.Notifications.PreferenceRepo.FindByEmail(ctx, "author@example.com")
+	if err != nil {
+		t.Fatalf("find preference: %v", err)
+	}
+	if _, err := env.C.Notifications.ConfirmPreference(ctx, preference.Token); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// The comments of a digest share its job.
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"One"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Two"}`)
+	work(t, env.C, "default", 1)
+	if messages := mailer.Messages(); len(messages) != 2 || messages[1].Subject != `2 new comments on "Followed"` {
+		t.Fatalf("expected the digest, got %v", messages)
+	}
+	work(t, env.C, "default", 0)
+
+	// A digest claimed by a worker that is lost is sent by the job queued again for it.
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Three"}`)
+	if _, err := env.C.Notifications.ClaimDue(ctx, 50*time.Millisecond, 10); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	work(t, env.C, "default", 1)
+	time.Sleep(60 * time.Millisecond)
+	work(t, env.C, "default", 1)
+	if messages := mailer.Messages(); len(messages) != 3 || !strings.Contains(messages[2].Text, "Three") {
+		t.Fatalf("expected the digest to be sent once the claim ran out, got %d emails", len(messages))
+	}
+
+	var digests int64
+	env.C.DB.Model(&domain.Job{}).Where("type = ? AND status = ?", "notification.digest", domain.JobSucceeded).Count(&digests)
+	if digests != 4 {
+		t.Fatalf("expected 4 digest jobs to succeed, got %d", digests)
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/logging"
+	"DDD/tests/testenv"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestAndQueryLogsShareTheRequest(t *testing.T) {
+	cfg := testenv.Config(func(cfg *config.Config) {
+		cfg.Log.Level = "debug"
+		cfg.Log.Format = "json"
+	})
+
+	var out bytes.Buffer
+	c, err := bootstrap.NewContainer(cfg, logging.New(cfg.Log, &out))
+	if err != nil {
+		t.Fatalf("container: %v", err)
+	}
+	t.Cleanup(func() { c.Close(context.Background()) })
+	if err := c.Migrator().Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	app := bootstrap.NewHttpApp(c)
+
+	out.Reset()
+	req := httptest.NewRequest("GET", "/api/v1/posts/1/comments", nil)
+	req.Header.Set("X-Request-Id", "logged-request")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+
+	const route = "/api/v1/posts/:postId/comments"
+	var requests, queries int
+	lines := bufio.NewScanner(&out)
+	for lines.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &line); err != nil {
+			t.Fatalf("expected JSON logs, got %q: %v", lines.Text(), err)
+		}
+
+		switch line["msg"] {
+		case "request":
+			requests++
+		case "query":
+			queries++
+		default:
+			continue
+		}
+		if line["request_id"] != "logged-request" || line["route"] != route {
+			t.Fatalf("expected the %s log to carry the request id and route, got %s", line["msg"], lines.Text())
+		}
+	}
+
+	if requests != 1 || queries == 0 {
+		t.Fatalf("expected the access log and the queries of the request, got %d and %d in %s", requests, queries, out.String())
+	}
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/tests/testenv"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.Metrics.Token = "scrape-token"
+	})
+
+	if status, _ := env.Send("POST", "/api/v1/posts", `{"title":"Metrics","content":"Counted"}`); status != 201 {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if status, _ := env.Send("POST", "/api/v1/posts", `{"title":"No","content":"Too short"}`); status != 400 {
+		t.Fatalf("expected 400, got %d", status)
+	}
+
+	if resp, _ := env.Do("GET", "/metrics", ""); resp.StatusCode != 401 {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	resp, out := env.Do("GET", "/metrics", "", "Authorization", "Bearer scrape-token")
+	body := string(out)
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	for _, want := range []string{
+		`ddd_http_requests_total{method="POST",route="/api/v1/posts/",status="201"} 1`,
+		`ddd_http_requests_total{method="POST",route="/api/v1/posts/",status="400"} 1`,
+		`ddd_http_request_duration_seconds_count{method="POST",route="/api/v1/posts/"} 2`,
+		`ddd_posts_created_total 1`,
+		`ddd_validation_failures_total{value_object="title"} 1`,
+		`go_sql_open_connections{db_name="main"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+}
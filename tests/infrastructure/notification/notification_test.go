@@ -0,0 +1,438 @@
+package notification
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/mail"
+	"DDD/src/infrastructure/notification"
+	"DDD/tests/testenv"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newEnv(t *testing.T) (*testenv.Env, *mail.MemoryTransport) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.Mail.Transport = "memory"
+		cfg.Notifications.Enabled = true
+		cfg.Notifications.Delay = 0
+		cfg.Notifications.MaxAttempts = 2
+		cfg.Notifications.Backoff = 0
+		cfg.Notifications.MaxBackoff = 0
+		cfg.Notifications.BaseUrl = "https://blog.example.com"
+	})
+
+	return env, env.C.Mailer.(*mail.MemoryTransport)
+}
+
+func notify(t *testing.T, c *bootstrap.Container, expected int) {
+	t.Helper()
+
+	n, err := c.Notifier.Notify(context.Background())
+	if err != nil || n != expected {
+		t.Fatalf("expected %d emails to be attempted, got %d %v", expected, n, err)
+	}
+}
+
+// preferencesOf reads the preferences link of an email, as its recipient would follow it.
+func preferencesOf(t *testing.T, message mail.Message) string {
+	t.Helper()
+
+	unsubscribe := strings.Trim(message.Headers["List-Unsubscribe"], "<>")
+	preferences, ok := strings.CutSuffix(unsubscribe, "/unsubscribe")
+	if !ok || !strings.Contains(message.Text, preferences) || !strings.Contains(message.Html, preferences) {
+		t.Fatalf("expected the email to link to the preferences, got %q", unsubscribe)
+	}
+
+	return strings.TrimPrefix(preferences, "https://blog.example.com")
+}
+
+// confirm has the author of a post confirm their address, from the request a
+// first comment on the post sends, and tells their preferences link.
+func confirm(t *testing.T, env *testenv.Env, mailer *mail.MemoryTransport, post string) string {
+	t.Helper()
+
+	env.Send("POST", "/api/v1/posts/"+post+"/comments", `{"text":"Before confirming"}`)
+	notify(t, env.C, 1)
+
+	messages := mailer.Messages()
+	request := messages[len(messages)-1]
+	preferences := preferencesOf(t, request)
+	if !strings.Contains(request.Subject, "Confirm") || !strings.Contains(request.Text, preferences+"/confirm") {
+		t.Fatalf("expected a confirmation request, got %q\n%s", request.Subject, request.Text)
+	}
+
+	if status, body := env.Send("POST", preferences+"/confirm", ""); status != 200 || body["confirmed"] != true {
+		t.Fatalf("expected the address to be confirmed, got %d %v", status, body)
+	}
+
+	return preferences
+}
+
+func TestBusyThreadsAreDigested(t *testing.T) {
+	env, mailer := newEnv(t)
+
+	if status, _ := env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"Author@Example.com"}`); status != 201 {
+		t.Fatalf("create post: %d", status)
+	}
+	env.Send("POST", "/api/v1/posts", `{"title":"Second","content":"Content","authorEmail":"author@example.com"}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Anonymous","content":"Content"}`)
+
+	// The author's address is never shown.
+	if _, post := env.Send("GET", "/api/v1/posts/1", ""); post["authorEmail"] != nil {
+		t.Fatalf("expected the author's address to be hidden, got %v", post)
+	}
+	if _, listing := env.Do("GET", "/api/v1/posts", ""); bytes.Contains(listing, []byte("authorEmail")) || bytes.Contains(bytes.ToLower(listing), []byte("author@example.com")) {
+		t.Fatalf("expected the author's address to be hidden from the listing, got %s", listing)
+	}
+
+	confirm(t, env, mailer, "1")
+
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"First <b>comment</b>"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Second comment"}`)
+	env.Send("POST", "/api/v1/posts/2/comments", `{"text":"Elsewhere"}`)
+	env.Send("POST", "/api/v1/posts/3/comments", `{"text":"Nobody to tell"}`)
+
+	notify(t, env.C, 1)
+	notify(t, env.C, 0)
+
+	messages := mailer.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected a single digest after the confirmation request, got %d emails", len(messages))
+	}
+	digest := messages[1]
+	if digest.To != "author@example.com" || digest.Subject != "3 new comments on your posts" {
+		t.Fatalf("unexpected digest %q to %q", digest.Subject, digest.To)
+	}
+	for _, part := range []string{digest.Text, digest.Html} {
+		for _, expected := range []string{"Followed", "Second", "Second comment", "Elsewhere", "https://blog.example.com/api/v1/posts/1"} {
+			if !strings.Contains(part, expected) {
+				t.Fatalf("expected the digest to mention %q, got\n%s", expected, part)
+			}
+		}
+		if strings.Contains(part, "Nobody to tell") || strings.Contains(part, "Before confirming") {
+			t.Fatalf("expected only the author's posts in the digest, got\n%s", part)
+		}
+	}
+	if !strings.Contains(digest.Text, "First <b>comment</b>") || !strings.Contains(digest.Html, "First &lt;b&gt;comment&lt;/b&gt;") {
+		t.Fatalf("expected the comment to be escaped in HTML only, got\n%s\n%s", digest.Text, digest.Html)
+	}
+	if digest.Headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Fatalf("expected one-click unsubscribe, got %v", digest.Headers)
+	}
+
+	content, err := digest.Bytes()
+	if err != nil || !strings.Contains(string(content), "Content-Type: multipart/alternative") {
+		t.Fatalf("expected a multipart email, got %v\n%s", err, content)
+	}
+
+	// A lone comment gets its own subject.
+	env.Send("POST", "/api/v1/posts/2/comments", `{"text":"Later"}`)
+	notify(t, env.C, 1)
+	if messages := mailer.Messages(); messages[2].Subject != `New comment on "Second"` {
+		t.Fatalf("unexpected subject %q", messages[2].Subject)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	env, mailer := newEnv(t)
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"author@example.com"}`)
+	preferences := confirm(t, env, mailer, "1")
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Hello there"}`)
+	notify(t, env.C, 1)
+	if preferencesOf(t, mailer.Messages()[1]) != preferences {
+		t.Fatal("expected the digest to link to the same preferences")
+	}
+
+	if status, body := env.Send("GET", preferences, ""); status != 200 || body["email"] != "author@example.com" ||
+		body["optedOut"] != false || body["digest"] != "batched" || body["confirmed"] != true {
+		t.Fatalf("expected the preferences, got %d %v", status, body)
+	}
+	if status, _ := env.Send("GET", "/api/v1/notifications/preferences/guessed", ""); status != 404 {
+		t.Fatalf("expected 404 for an unknown token, got %d", status)
+	}
+	if status, _ := env.Send("PATCH", preferences, `{"digest":"hourly"}`); status != 400 {
+		t.Fatalf("expected 400 for an unknown digest, got %d", status)
+	}
+
+	// A daily digest waits a day for more comments.
+	if status, body := env.Send("PATCH", preferences, `{"digest":"daily"}`); status != 200 || body["digest"] != "daily" {
+		t.Fatalf("expected a daily digest, got %d %v", status, body)
+	}
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Tomorrow"}`)
+	notify(t, env.C, 0)
+
+	// Opting out skips what is pending, and stops queuing.
+	if status, body := env.Send("POST", preferences+"/unsubscribe", "List-Unsubscribe=One-Click"); status != 200 || body["optedOut"] != true {
+		t.Fatalf("expected to be opted out, got %d %v", status, body)
+	}
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Unheard"}`)
+
+	var pending int64
+	env.C.DB.Table("notifications").Where("status = ?", "pending").Count(&pending)
+	if pending != 1 {
+		t.Fatalf("expected only the daily notification to be pending, got %d", pending)
+	}
+	env.C.DB.Exec("UPDATE notifications SET due_at = ?", time.Now())
+	notify(t, env.C, 0)
+
+	var skipped int64
+	env.C.DB.Table("notifications").Where("status = ? AND last_error = ?", "skipped", "recipient opted out").Count(&skipped)
+	if skipped != 1 || len(mailer.Messages()) != 2 {
+		t.Fatalf("expected the pending notification to be skipped, got %d skipped and %d emails", skipped, len(mailer.Messages()))
+	}
+
+	// Opting back in.
+	env.Send("PATCH", preferences, `{"optedOut":false,"digest":"batched"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Heard again"}`)
+	notify(t, env.C, 1)
+}
+
+func TestDeletedCommentsAreNotNotified(t *testing.T) {
+	env, mailer := newEnv(t)
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"author@example.com"}`)
+	confirm(t, env, mailer, "1")
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Regretted"}`)
+	env.Send("DELETE", "/api/v1/comments/2", "")
+
+	notify(t, env.C, 0)
+	if len(mailer.Messages()) != 1 {
+		t.Fatalf("expected no email but the confirmation request, got %v", mailer.Messages())
+	}
+}
+
+func TestUnconfirmedAddressesGetOneRequest(t *testing.T) {
+	env, mailer := newEnv(t)
+
+	// Anyone may give any address.
+	env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"someone@example.com"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Secret comment"}`)
+	notify(t, env.C, 1)
+
+	request := mailer.Messages()[0]
+	if request.To != "someone@example.com" || strings.Contains(request.Text, "Secret comment") || strings.Contains(request.Html, "Secret comment") {
+		t.Fatalf("expected a confirmation request telling nothing of the comments, got %q\n%s", request.Subject, request.Text)
+	}
+	var skipped int64
+	env.C.DB.Table("notifications").Where("status = ? AND last_error = ?", "skipped", "recipient not confirmed").Count(&skipped)
+	if skipped != 1 {
+		t.Fatalf("expected the comment not to be notified, got %d skipped", skipped)
+	}
+
+	// Until the address is confirmed, nothing else is sent, nor queued.
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Another"}`)
+	notify(t, env.C, 0)
+	var queued int64
+	env.C.DB.Table("notifications").Count(&queued)
+	if queued != 1 || len(mailer.Messages()) != 1 {
+		t.Fatalf("expected a single email and notification, got %d emails and %d notifications", len(mailer.Messages()), queued)
+	}
+
+	// Following the link of the request confirms the address.
+	preferences := preferencesOf(t, request)
+	if status, body := env.Send("GET", preferences, ""); status != 200 || body["confirmed"] != false {
+		t.Fatalf("expected the address to be unconfirmed, got %d %v", status, body)
+	}
+	if status, body := env.Send("GET", preferences+"/confirm", ""); status != 200 || body["confirmed"] != true {
+		t.Fatalf("expected the address to be confirmed, got %d %v", status, body)
+	}
+	if status, _ := env.Send("POST", "/api/v1/notifications/preferences/guessed/confirm", ""); status != 404 {
+		t.Fatalf("expected 404 for an unknown token, got %d", status)
+	}
+
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Heard"}`)
+	notify(t, env.C, 1)
+	if digest := mailer.Messages()[1]; !strings.Contains(digest.Text, "Heard") {
+		t.Fatalf("expected the digest of the comment, got\n%s", digest.Text)
+	}
+}
+
+func TestInvalidAuthorEmail(t *testing.T) {
+	env, _ := newEnv(t)
+
+	for _, email := range []string{"not-an-address", "Author <author@example.com>", strings.Repeat("a", 250) + "@example.com"} {
+		body, _ := json.Marshal(map[string]string{"title": "Followed", "content": "Content", "authorEmail": email})
+		if status, _ := env.Send("POST", "/api/v1/posts", string(body)); status != 400 {
+			t.Fatalf("expected 400 for %q, got %d", email, status)
+		}
+	}
+}
+
+// failing refuses every message.
+type failing struct{}
+
+func (failing) Send(context.Context, mail.Message) error {
+	return errors.New("relay unavailable")
+}
+
+func TestFailedEmailsAreRetried(t *testing.T) {
+	env, mailer := newEnv(t)
+	env.Send("POST", "/api/v1/posts", `{"title":"Followed","content":"Content","authorEmail":"author@example.com"}`)
+	confirm(t, env, mailer, "1")
+
+	env.C.Notifier = notification.NewNotifier(env.C.Notifications, failing{}, notification.Renderer{
+		AppName: "Blog",
+		From:    "Blog <noreply@example.com>",
+		BaseUrl: "https://blog.example.com",
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)), notification.Options{
+		Timeout:   time.Second,
+		BatchSize: 10,
+	})
+
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Undelivered"}`)
+
+	notify(t, env.C, 1)
+	notify(t, env.C, 1)
+	notify(t, env.C, 0)
+
+	var failed struct {
+		Status    string
+		Attempts  int
+		LastError string
+	}
+	env.C.DB.Table("notifications").Where("comment_id = ?", 2).Select("status", "attempts", "last_error").Take(&failed)
+	if failed.Status != "failed" || failed.Attempts != 2 || failed.LastError != "relay unavailable" {
+		t.Fatalf("expected the notification to fail after 2 attempts, got %+v", failed)
+	}
+}
+
+func TestFileTransport(t *testing.T) {
+	dir := t.TempDir()
+	transport := mail.NewFileTransport(filepath.Join(dir, "mail"))
+
+	err := transport.Send(context.Background(), mail.Message{
+		From:    "Blog <noreply@example.com>",
+		To:      "author@example.com",
+		Subject: "Nouveau commentaire sur « Déjà vu »",
+		Text:    "Bonjour",
+		Html:    "<p>Bonjour</p>",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "mail", "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+	content, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(content), "Subject: =?utf-8?q?") || !strings.Contains(string(content), "To: <author@example.com>") {
+		t.Fatalf("unexpected email\n%s", content)
+	}
+}
+
+func TestSMTPTransport(t *testing.T) {
+	relay := newRelay(t)
+
+	transport, err := mail.NewSMTPTransport("smtp://"+relay.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("transport: %v", err)
+	}
+
+	err = transport.Send(context.Background(), mail.Message{
+		From:    "Blog <noreply@example.com>",
+		To:      "author@example.com",
+		Subject: "New comment",
+		Text:    "Hello",
+		Html:    "<p>Hello</p>",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	conversation := relay.conversation()
+	for _, expected := range []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<author@example.com>", "Subject: New comment", "QUIT"} {
+		if !strings.Contains(conversation, expected) {
+			t.Fatalf("expected %q in the conversation, got\n%s", expected, conversation)
+		}
+	}
+
+	if _, err := mail.NewSMTPTransport("http://localhost", time.Second); err == nil {
+		t.Fatal("expected an error for a URL that is not smtp")
+	}
+}
+
+// relay is an SMTP server that accepts every message and records the conversation.
+type relay struct {
+	net.Listener
+	mu  sync.Mutex
+	log strings.Builder
+}
+
+func newRelay(t *testing.T) *relay {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	r := &relay{Listener: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	return r
+}
+
+func (r *relay) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 relay ready")
+	data := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.log.WriteString(line)
+		r.mu.Unlock()
+
+		command := strings.TrimRight(line, "\r\n")
+		switch {
+		case data && command == ".":
+			data = false
+			reply("250 queued")
+		case data:
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250 relay")
+		case command == "DATA":
+			data = true
+			reply("354 go ahead")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (r *relay) conversation() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.log.String()
+}
@@ -0,0 +1,259 @@
+package persistence
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/persistence/cache"
+	"DDD/src/infrastructure/persistence/memory"
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedRepositories(t *testing.T) {
+	runConformance(t, func(t *testing.T) repositories {
+		store := memory.NewStore()
+
+		return repositories{
+			posts:    &publishingPosts{newCachedPosts(t, memory.NewPostRepository(store))},
+			comments: memory.NewCommentRepository(store),
+			uow:      memory.NewUnitOfWork(store),
+		}
+	})
+}
+
+func TestCacheServesRepeatedReads(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingPosts{PostRepository: memory.NewPostRepository(memory.NewStore())}
+	posts := newCachedPosts(t, counting)
+
+	post := domain.Post{Title: "Cached", Content: "Once", AuthorEmail: "author@example.com"}
+	if err := posts.Create(ctx, &post); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for range 3 {
+		// The author's address is kept in the cache, though never marshalled for clients.
+		found, err := posts.FindById(ctx, int(post.Id))
+		if err != nil || found.AuthorEmail != post.AuthorEmail {
+			t.Fatalf("find: %+v %v", found, err)
+		}
+		page, _, err := posts.Paginate(ctx, 1, 10)
+		if err != nil || page[0].AuthorEmail != post.AuthorEmail {
+			t.Fatalf("paginate: %+v %v", page, err)
+		}
+		if _, _, err := posts.Paginate(ctx, 3, 10); err != nil {
+			t.Fatalf("paginate: %v", err)
+		}
+	}
+
+	if finds := counting.finds.Load(); finds != 1 {
+		t.Fatalf("expected 1 FindById on the repository, got %d", finds)
+	}
+	if pages := counting.pages.Load(); pages != 4 {
+		t.Fatalf("expected page 1 once and the uncached page 3 every time, got %d", pages)
+	}
+}
+
+func TestCacheInvalidatesOnEvents(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := newCachedPosts(t, memory.NewPostRepository(store))
+
+	post := domain.Post{Title: "Before", Content: "Content"}
+	if err := posts.Create(ctx, &post); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := posts.FindById(ctx, int(post.Id)); err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if _, _, err := posts.Paginate(ctx, 1, 10); err != nil {
+		t.Fatalf("paginate: %v", err)
+	}
+
+	// A unit of work writes past the decorator, the event tells it what changed.
+	err := memory.NewUnitOfWork(store).Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		post.Title = "After"
+		if err := repos.Posts().Update(ctx, &post); err != nil {
+			return err
+		}
+
+		return repos.Posts().Create(ctx, &domain.Post{Title: "Second", Content: "Content"})
+	})
+	if err != nil {
+		t.Fatalf("unit of work: %v", err)
+	}
+
+	if found, _ := posts.FindById(ctx, int(post.Id)); found.Title != "Before" {
+		t.Fatalf("expected the stale cached post before the event, got %q", found.Title)
+	}
+
+	posts.HandleEvent(ctx, domain.PostUpdated{Post: post})
+	posts.HandleEvent(ctx, domain.PostCreated{})
+
+	if found, _ := posts.FindById(ctx, int(post.Id)); found.Title != "After" {
+		t.Fatalf("expected the updated post, got %q", found.Title)
+	}
+
+	page, total, err := posts.Paginate(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("paginate: %v", err)
+	}
+	if total != 2 || page[1].Title != "After" {
+		t.Fatalf("expected the fresh listing, got %d %+v", total, page)
+	}
+}
+
+func TestCacheCollapsesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingPosts{PostRepository: memory.NewPostRepository(memory.NewStore()), delay: 50 * time.Millisecond}
+	posts := newCachedPosts(t, counting)
+
+	post := domain.Post{Title: "Popular", Content: "Content"}
+	if err := posts.Create(ctx, &post); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := posts.FindById(ctx, int(post.Id)); err != nil {
+				t.Errorf("find: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if finds := counting.finds.Load(); finds != 1 {
+		t.Fatalf("expected concurrent misses to share one load, got %d", finds)
+	}
+}
+
+func TestCacheNeverStoresAPostLoadedBeforeAChange(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	counting := &countingPosts{PostRepository: memory.NewPostRepository(store), delay: 50 * time.Millisecond}
+	posts := newCachedPosts(t, counting)
+
+	post := domain.Post{Title: "Before", Content: "Content"}
+	if err := counting.Create(ctx, &post); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	stale := post
+
+	// Both reads miss and load the post, then the change commits and is
+	// announced before they store what they loaded.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = posts.FindById(ctx, int(post.Id))
+	}()
+	go func() {
+		defer wg.Done()
+		_, _, _ = posts.Paginate(ctx, 1, 10)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	post.Title = "After"
+	if err := counting.PostRepository.Update(ctx, &post); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	posts.HandleEvent(ctx, domain.PostUpdated{Post: post})
+	wg.Wait()
+
+	if found, _ := posts.FindById(ctx, int(post.Id)); found.Title != "After" {
+		t.Fatalf("expected the updated post, got %q at version %d, loaded at version %d", found.Title, found.Version, stale.Version)
+	}
+	if page, _, _ := posts.Paginate(ctx, 1, 10); page[0].Title != "After" {
+		t.Fatalf("expected the updated listing, got %q", page[0].Title)
+	}
+
+	// A deleted post is never stored again.
+	go func() { _, _ = posts.FindById(ctx, int(post.Id)) }()
+	time.Sleep(10 * time.Millisecond)
+	if err := counting.PostRepository.Delete(ctx, int(post.Id)); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	posts.HandleEvent(ctx, domain.PostDeleted{PostId: post.Id})
+	time.Sleep(60 * time.Millisecond)
+	if _, err := posts.FindById(ctx, int(post.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected the deleted post not to be found, got %v", err)
+	}
+}
+
+func newCachedPosts(t *testing.T, next domain.PostRepository) *cache.PostRepository {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewPostRepository(next, client, cache.Options{
+		Prefix:  "test:",
+		PostTTL: time.Minute,
+		PageTTL: time.Minute,
+		Pages:   2,
+	})
+}
+
+// countingPosts counts the reads that reach the wrapped repository.
+type countingPosts struct {
+	domain.PostRepository
+	delay time.Duration
+	finds atomic.Int32
+	pages atomic.Int32
+}
+
+// The delay comes after the read, as a slow response would.
+func (r *countingPosts) FindById(ctx context.Context, id int) (*domain.Post, error) {
+	r.finds.Add(1)
+	post, err := r.PostRepository.FindById(ctx, id)
+	time.Sleep(r.delay)
+
+	return post, err
+}
+
+func (r *countingPosts) Paginate(ctx context.Context, page int, perPage int) ([]domain.Post, int64, error) {
+	r.pages.Add(1)
+	posts, total, err := r.PostRepository.Paginate(ctx, page, perPage)
+	time.Sleep(r.delay)
+
+	return posts, total, err
+}
+
+// publishingPosts announces its writes to the cache in front of it, as the
+// services do on the event bus.
+type publishingPosts struct {
+	*cache.PostRepository
+}
+
+func (r *publishingPosts) Create(ctx context.Context, post *domain.Post) error {
+	if err := r.PostRepository.Create(ctx, post); err != nil {
+		return err
+	}
+
+	r.HandleEvent(ctx, domain.PostCreated{Post: *post})
+	return nil
+}
+
+func (r *publishingPosts) Update(ctx context.Context, post *domain.Post) error {
+	if err := r.PostRepository.Update(ctx, post); err != nil {
+		return err
+	}
+
+	r.HandleEvent(ctx, domain.PostUpdated{Post: *post})
+	return nil
+}
+
+func (r *publishingPosts) Delete(ctx context.Context, id int) error {
+	if err := r.PostRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.HandleEvent(ctx, domain.PostDeleted{PostId: uint(id)})
+	return nil
+}
@@ -0,0 +1,531 @@
+package persistence
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// missingId never belongs to a stored record, even on databases that keep
+// their sequences between tests.
+const missingId = 999999999
+
+// repositories is a fresh, empty pair of adapters sharing one backing store.
+type repositories struct {
+	posts    domain.PostRepository
+	comments domain.PostCommentRepository
+	uow      domain.UnitOfWork
+}
+
+// runConformance runs the behaviour every PostRepository and
+// PostCommentRepository adapter must share.
+func runConformance(t *testing.T, newRepositories func(t *testing.T) repositories) {
+	tests := map[string]func(t *testing.T, repos repositories){
+		"find missing post":              testFindMissingPost,
+		"create and find post":           testCreateAndFindPost,
+		"duplicate title on create":      testDuplicateTitleOnCreate,
+		"duplicate title on update":      testDuplicateTitleOnUpdate,
+		"update keeps own title":         testUpdateKeepsOwnTitle,
+		"update missing post":            testUpdateMissingPost,
+		"paginate posts newest first":    testPaginatePosts,
+		"delete post cascades comments":  testDeleteCascades,
+		"delete missing post":            testDeleteMissingPost,
+		"comment on missing post":        testCommentOnMissingPost,
+		"find missing comment":           testFindMissingComment,
+		"paginate comments newest first": testPaginateComments,
+		"find comments by post":          testFindCommentsByPost,
+		"paginate comments by posts":     testPaginateCommentsByPosts,
+		"update comment":                 testUpdateComment,
+		"update missing comment":         testUpdateMissingComment,
+		"delete comment":                 testDeleteComment,
+		"delete missing comment":         testDeleteMissingComment,
+		"concurrent creates":             testConcurrentCreates,
+		"update bumps the revision":      testUpdateBumpsRevision,
+		"revision of missing post":       testRevisionOfMissingPost,
+		"paginate revisions":             testPaginateRevisions,
+		"unit of work commits":           testUnitOfWorkCommits,
+		"unit of work rolls back":        testUnitOfWorkRollsBack,
+		"nested unit of work":            testNestedUnitOfWork,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepositories(t))
+		})
+	}
+}
+
+func testFindMissingPost(t *testing.T, repos repositories) {
+	_, err := repos.posts.FindById(context.Background(), missingId)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testCreateAndFindPost(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	post := createPost(t, repos, "First post")
+
+	if post.Id == 0 {
+		t.Fatal("expected id to be assigned")
+	}
+	if post.CreatedAt.IsZero() || post.UpdatedAt.IsZero() {
+		t.Fatal("expected timestamps to be assigned")
+	}
+
+	found, err := repos.posts.FindById(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if found.Title != post.Title || found.Content != post.Content {
+		t.Fatalf("expected %+v, got %+v", post, found)
+	}
+}
+
+func testDuplicateTitleOnCreate(t *testing.T, repos repositories) {
+	createPost(t, repos, "Same title")
+
+	err := repos.posts.Create(context.Background(), newPost(t, "Same title"))
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func testDuplicateTitleOnUpdate(t *testing.T, repos repositories) {
+	createPost(t, repos, "Taken title")
+	post := createPost(t, repos, "Other title")
+
+	post.Title = value_object.Title("Taken title")
+	err := repos.posts.Update(context.Background(), post)
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func testUpdateKeepsOwnTitle(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	post := createPost(t, repos, "Own title")
+
+	post.Content = value_object.Content("Changed content")
+	if err := repos.posts.Update(ctx, post); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+
+	found, err := repos.posts.FindById(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if found.Content != "Changed content" {
+		t.Fatalf("expected updated content, got %q", found.Content)
+	}
+}
+
+func testUpdateBumpsRevision(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	post := createPost(t, repos, "Versioned")
+
+	created, err := repos.posts.FindRevision(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find revision: %v", err)
+	}
+	if created.Id != post.Id || created.Version != 1 {
+		t.Fatalf("expected version 1 of post %d, got %+v", post.Id, created)
+	}
+
+	post.Title = value_object.Title("Versioned again")
+	if err := repos.posts.Update(ctx, post); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+	if post.Version != 2 || post.CreatedAt.IsZero() {
+		t.Fatalf("expected the update to return the stored post at version 2, got %+v", post)
+	}
+
+	updated, err := repos.posts.FindRevision(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find revision: %v", err)
+	}
+	found, err := repos.posts.FindById(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if *updated != found.Revision() || updated.Version != 2 {
+		t.Fatalf("expected revision %+v to match the post %+v", updated, found.Revision())
+	}
+}
+
+func testRevisionOfMissingPost(t *testing.T, repos repositories) {
+	_, err := repos.posts.FindRevision(context.Background(), missingId)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testPaginateRevisions(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	post := createPost(t, repos, "With comments")
+	createPost(t, repos, "Without comments")
+	for i := 1; i <= 3; i++ {
+		createComment(t, repos, post.Id, fmt.Sprintf("Comment %d", i))
+	}
+
+	posts, total, err := repos.posts.Paginate(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("paginate posts: %v", err)
+	}
+	revisions, revisionsTotal, err := repos.posts.PaginateRevisions(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("paginate post revisions: %v", err)
+	}
+	if revisionsTotal != total || len(revisions) != 1 || revisions[0] != posts[0].Revision() {
+		t.Fatalf("expected revisions of %+v, got %d %+v", posts, revisionsTotal, revisions)
+	}
+
+	comments, total, err := repos.comments.Paginate(ctx, int(post.Id), 2, 2)
+	if err != nil {
+		t.Fatalf("paginate comments: %v", err)
+	}
+	revisions, revisionsTotal, err = repos.comments.PaginateRevisions(ctx, int(post.Id), 2, 2)
+	if err != nil {
+		t.Fatalf("paginate comment revisions: %v", err)
+	}
+	if revisionsTotal != total || total != 3 || len(revisions) != 1 || revisions[0] != comments[0].Revision() {
+		t.Fatalf("expected revisions of %+v, got %d %+v", comments, revisionsTotal, revisions)
+	}
+}
+
+func testUpdateMissingPost(t *testing.T, repos repositories) {
+	post := newPost(t, "Ghost post")
+	post.Id = missingId
+
+	err := repos.posts.Update(context.Background(), post)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testPaginatePosts(t *testing.T, repos repositories) {
+	for i := 1; i <= 5; i++ {
+		createPost(t, repos, fmt.Sprintf("Post %d", i))
+	}
+
+	posts, total, err := repos.posts.Paginate(context.Background(), 2, 2)
+	if err != nil {
+		t.Fatalf("paginate posts: %v", err)
+	}
+	if total != 5 {
+		t.Fatalf("expected total 5, got %d", total)
+	}
+	if len(posts) != 2 || posts[0].Title != "Post 3" || posts[1].Title != "Post 2" {
+		t.Fatalf("expected Post 3 and Post 2, got %+v", posts)
+	}
+}
+
+func testDeleteCascades(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	post := createPost(t, repos, "Doomed post")
+	comment := createComment(t, repos, post.Id, "Doomed comment")
+
+	if err := repos.posts.Delete(ctx, int(post.Id)); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+
+	if _, err := repos.posts.FindById(ctx, int(post.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected post to be gone, got %v", err)
+	}
+	if _, err := repos.comments.FindById(ctx, int(comment.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected comment to be gone, got %v", err)
+	}
+}
+
+func testDeleteMissingPost(t *testing.T, repos repositories) {
+	err := repos.posts.Delete(context.Background(), missingId)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testCommentOnMissingPost(t *testing.T, repos repositories) {
+	err := repos.comments.Create(context.Background(), &domain.PostComment{
+		PostId: missingId,
+		Text:   value_object.Text("Orphan comment"),
+	})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testFindMissingComment(t *testing.T, repos repositories) {
+	_, err := repos.comments.FindById(context.Background(), missingId)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testPaginateComments(t *testing.T, repos repositories) {
+	post := createPost(t, repos, "Busy post")
+	other := createPost(t, repos, "Quiet post")
+	for i := 1; i <= 3; i++ {
+		createComment(t, repos, post.Id, fmt.Sprintf("Comment %d", i))
+	}
+	createComment(t, repos, other.Id, "Elsewhere")
+
+	comments, total, err := repos.comments.Paginate(context.Background(), int(post.Id), 1, 2)
+	if err != nil {
+		t.Fatalf("paginate comments: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("expected total 3, got %d", total)
+	}
+	if len(comments) != 2 || comments[0].Text != "Comment 3" || comments[1].Text != "Comment 2" {
+		t.Fatalf("expected Comment 3 and Comment 2, got %+v", comments)
+	}
+}
+
+func testFindCommentsByPost(t *testing.T, repos repositories) {
+	post := createPost(t, repos, "Commented post")
+	first := createComment(t, repos, post.Id, "First comment")
+	second := createComment(t, repos, post.Id, "Second comment")
+
+	comments, err := repos.comments.FindByPostId(context.Background(), int(post.Id))
+	if err != nil {
+		t.Fatalf("find comments: %v", err)
+	}
+	if len(comments) != 2 || comments[0].Id != first.Id || comments[1].Id != second.Id {
+		t.Fatalf("expected both comments in order, got %+v", comments)
+	}
+}
+
+func testPaginateCommentsByPosts(t *testing.T, repos repositories) {
+	first := createPost(t, repos, "First paged post")
+	second := createPost(t, repos, "Second paged post")
+	other := createPost(t, repos, "Other paged post")
+	a := createComment(t, repos, first.Id, "Oldest on the first post")
+	createComment(t, repos, first.Id, "Newest on the first post")
+	createComment(t, repos, second.Id, "Newest on the second post")
+	createComment(t, repos, other.Id, "On another post")
+
+	comments, totals, err := repos.comments.PaginateByPostIds(context.Background(), []uint{second.Id, first.Id, missingId}, 2, 1)
+	if err != nil {
+		t.Fatalf("paginate comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Id != a.Id {
+		t.Fatalf("expected the second page of the first post only, got %+v", comments)
+	}
+	if len(totals) != 2 || totals[first.Id] != 2 || totals[second.Id] != 1 {
+		t.Fatalf("expected the totals of both commented posts, got %+v", totals)
+	}
+
+	comments, totals, err = repos.comments.PaginateByPostIds(context.Background(), nil, 1, 10)
+	if err != nil || len(comments) != 0 || len(totals) != 0 {
+		t.Fatalf("expected no comments for no posts, got %+v %+v %v", comments, totals, err)
+	}
+}
+
+func testUpdateComment(t *testing.T, repos repositories) {
+	post := createPost(t, repos, "Edited post")
+	comment := createComment(t, repos, post.Id, "Typo comment")
+
+	edited := &domain.PostComment{Id: comment.Id, Text: value_object.Text("Fixed comment")}
+	if err := repos.comments.Update(context.Background(), edited); err != nil {
+		t.Fatalf("update comment: %v", err)
+	}
+	if edited.PostId != post.Id || edited.Text != "Fixed comment" || edited.CreatedAt.IsZero() {
+		t.Fatalf("expected the whole edited comment back, got %+v", edited)
+	}
+	if comment.Version != 1 || edited.Version != 2 {
+		t.Fatalf("expected the edit to bump the version from 1 to 2, got %d and %d", comment.Version, edited.Version)
+	}
+
+	found, err := repos.comments.FindById(context.Background(), int(comment.Id))
+	if err != nil || found.Text != "Fixed comment" || found.Revision() != edited.Revision() {
+		t.Fatalf("expected the edit to be stored, got %+v %v", found, err)
+	}
+}
+
+func testUpdateMissingComment(t *testing.T, repos repositories) {
+	err := repos.comments.Update(context.Background(), &domain.PostComment{Id: missingId, Text: value_object.Text("Nowhere")})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testDeleteComment(t *testing.T, repos repositories) {
+	post := createPost(t, repos, "Moderated post")
+	comment := createComment(t, repos, post.Id, "Rude comment")
+	kept := createComment(t, repos, post.Id, "Kind comment")
+
+	if err := repos.comments.Delete(context.Background(), int(comment.Id)); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+
+	comments, err := repos.comments.FindByPostId(context.Background(), int(post.Id))
+	if err != nil || len(comments) != 1 || comments[0].Id != kept.Id {
+		t.Fatalf("expected only the kind comment to remain, got %+v %v", comments, err)
+	}
+}
+
+func testDeleteMissingComment(t *testing.T, repos repositories) {
+	err := repos.comments.Delete(context.Background(), missingId)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func testConcurrentCreates(t *testing.T, repos repositories) {
+	post := createPost(t, repos, "Popular post")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repos.comments.Create(context.Background(), &domain.PostComment{
+				PostId: post.Id,
+				Text:   value_object.Text(fmt.Sprintf("Comment %d", i)),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+	}
+
+	_, total, err := repos.comments.Paginate(context.Background(), int(post.Id), 1, 100)
+	if err != nil {
+		t.Fatalf("paginate comments: %v", err)
+	}
+	if total != 20 {
+		t.Fatalf("expected 20 comments, got %d", total)
+	}
+}
+
+func testUnitOfWorkCommits(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	var post *domain.Post
+
+	err := repos.uow.Do(ctx, func(ctx context.Context, tx domain.Repositories) error {
+		post = newPost(t, "Committed post")
+		if err := tx.Posts().Create(ctx, post); err != nil {
+			return err
+		}
+
+		return tx.Comments().Create(ctx, &domain.PostComment{PostId: post.Id, Text: "First comment"})
+	})
+	if err != nil {
+		t.Fatalf("unit of work: %v", err)
+	}
+
+	comments, err := repos.comments.FindByPostId(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find comments: %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("expected committed comment, got %+v", comments)
+	}
+}
+
+func testUnitOfWorkRollsBack(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	kept := createPost(t, repos, "Kept post")
+	var post *domain.Post
+
+	err := repos.uow.Do(ctx, func(ctx context.Context, tx domain.Repositories) error {
+		post = newPost(t, "Rolled back post")
+		if err := tx.Posts().Create(ctx, post); err != nil {
+			return err
+		}
+		if err := tx.Posts().Delete(ctx, int(kept.Id)); err != nil {
+			return err
+		}
+
+		return tx.Comments().Create(ctx, &domain.PostComment{PostId: missingId, Text: "Orphan comment"})
+	})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	if _, err := repos.posts.FindById(ctx, int(post.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected created post to be rolled back, got %v", err)
+	}
+	if _, err := repos.posts.FindById(ctx, int(kept.Id)); err != nil {
+		t.Fatalf("expected deleted post to be restored, got %v", err)
+	}
+}
+
+func testNestedUnitOfWork(t *testing.T, repos repositories) {
+	ctx := context.Background()
+	var outer, inner *domain.Post
+
+	err := repos.uow.Do(ctx, func(ctx context.Context, tx domain.Repositories) error {
+		outer = newPost(t, "Outer post")
+		if err := tx.Posts().Create(ctx, outer); err != nil {
+			return err
+		}
+
+		err := repos.uow.Do(ctx, func(ctx context.Context, tx domain.Repositories) error {
+			inner = newPost(t, "Inner post")
+			if err := tx.Posts().Create(ctx, inner); err != nil {
+				return err
+			}
+
+			return tx.Posts().Create(ctx, newPost(t, "Outer post"))
+		})
+		if !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("expected conflict from nested unit of work, got %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unit of work: %v", err)
+	}
+
+	if _, err := repos.posts.FindById(ctx, int(outer.Id)); err != nil {
+		t.Fatalf("expected outer post to be committed, got %v", err)
+	}
+	if _, err := repos.posts.FindById(ctx, int(inner.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected inner post to be rolled back, got %v", err)
+	}
+}
+
+func newPost(t *testing.T, title string) *domain.Post {
+	t.Helper()
+
+	postTitle, err := value_object.NewTitle(title)
+	if err != nil {
+		t.Fatalf("new title: %v", err)
+	}
+
+	return &domain.Post{Title: postTitle, Content: value_object.Content("Some content")}
+}
+
+func createPost(t *testing.T, repos repositories, title string) *domain.Post {
+	t.Helper()
+
+	post := newPost(t, title)
+	if err := repos.posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	return post
+}
+
+func createComment(t *testing.T, repos repositories, postId uint, text string) *domain.PostComment {
+	t.Helper()
+
+	comment := &domain.PostComment{PostId: postId, Text: value_object.Text(text)}
+	if err := repos.comments.Create(context.Background(), comment); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+
+	return comment
+}
@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/persistence/gorm/repository"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestEventSourcedRepositories(t *testing.T) {
+	db := open(t)
+	posts := func(tx *gorm.DB) domain.PostRepository {
+		return repository.NewEventSourcedPostRepository(tx, 3)
+	}
+
+	runConformance(t, func(t *testing.T) repositories {
+		wipe(t, db)
+
+		return repositories{
+			posts:    posts(db),
+			comments: repository.NewCommentRepository(db),
+			uow:      repository.NewUnitOfWorkWithPosts(db, posts),
+		}
+	})
+}
+
+func TestEventSourcedPostIsRebuiltFromItsEvents(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+	posts := repository.NewEventSourcedPostRepository(db, 2)
+
+	post := newPost(t, "Event sourced")
+	post.AuthorEmail = "author@example.com"
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	for _, content := range []string{"Second", "Third", "Fourth", "Fifth"} {
+		post.Content = value_object.Content(content)
+		if err := posts.Update(ctx, post); err != nil {
+			t.Fatalf("update post: %v", err)
+		}
+	}
+
+	history, err := posts.(*repository.EventSourcedPostRepository).History(ctx, int(post.Id))
+	if err != nil || len(history) != 5 {
+		t.Fatalf("expected 5 events, got %d %v", len(history), err)
+	}
+	var snapshot repository.Snapshot
+	if err := db.Where("stream_type = ? AND stream_id = ?", repository.PostStream, post.Id).First(&snapshot).Error; err != nil {
+		t.Fatalf("find snapshot: %v", err)
+	}
+	if snapshot.Version != 4 {
+		t.Fatalf("expected a snapshot every 2 events, the latest at version 4, got %d", snapshot.Version)
+	}
+
+	// The posts table is a projection, the events tell what the post is.
+	if err := db.Exec("UPDATE posts SET content = 'Tampered' WHERE id = ?", post.Id).Error; err != nil {
+		t.Fatalf("tamper with the row: %v", err)
+	}
+	found, err := posts.FindById(ctx, int(post.Id))
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if found.Content != "Fifth" || found.AuthorEmail != "author@example.com" || found.Version != 5 || found.Revision() != post.Revision() {
+		t.Fatalf("expected the post at version 5, got %+v", found)
+	}
+	// Revisions are read from the row, which follows every event.
+	if revision, err := posts.FindRevision(ctx, int(post.Id)); err != nil || *revision != found.Revision() {
+		t.Fatalf("expected the revision of the row to match the post, got %+v %v", revision, err)
+	}
+
+	// Without snapshots, every event is replayed to the same post.
+	if err := db.Where("stream_id = ?", post.Id).Delete(&repository.Snapshot{}).Error; err != nil {
+		t.Fatalf("delete snapshot: %v", err)
+	}
+	replayed, err := posts.FindById(ctx, int(post.Id))
+	if err != nil || replayed.Content != found.Content || replayed.AuthorEmail != found.AuthorEmail || replayed.Revision() != found.Revision() || !replayed.CreatedAt.Equal(found.CreatedAt) {
+		t.Fatalf("expected the replayed post %+v to match %+v, %v", replayed, found, err)
+	}
+
+	if err := posts.Delete(ctx, int(post.Id)); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+	if _, err := posts.FindById(ctx, int(post.Id)); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected a deleted post not to be found, got %v", err)
+	}
+	if history, _ := posts.(*repository.EventSourcedPostRepository).History(ctx, int(post.Id)); len(history) != 6 {
+		t.Fatalf("expected the deletion to be kept in the history, got %d events", len(history))
+	}
+}
+
+func TestEventStoreRejectsConcurrentAppends(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+	posts := repository.NewEventSourcedPostRepository(db, 50)
+
+	post := newPost(t, "Concurrently changed")
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	// Another writer read the post before it was created, at version 0.
+	stale := repository.StoredEvent{Type: repository.PostUpdatedEvent, Data: `{"content":"Stale"}`, RecordedAt: post.UpdatedAt}
+	err := repository.NewEventStore(db).Append(ctx, repository.PostStream, post.Id, 0, stale)
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	found, err := posts.FindById(ctx, int(post.Id))
+	if err != nil || found.Content != post.Content || found.Version != 1 {
+		t.Fatalf("expected the post to be unchanged, got %+v %v", found, err)
+	}
+
+	if err := db.Exec("DELETE FROM stored_events WHERE stream_id = ?", post.Id).Error; err == nil {
+		t.Fatal("expected the events to be append-only")
+	}
+}
+
+func TestEventSourcedPostAdoptsExistingRows(t *testing.T) {
+	db := open(t)
+	ctx := context.Background()
+	posts := repository.NewEventSourcedPostRepository(db, 50)
+
+	// Written while the posts table was the store.
+	post := newPost(t, "Written before")
+	if err := repository.NewPostRepository(db).Create(ctx, post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	if _, err := posts.FindById(ctx, int(post.Id)); err != nil {
+		t.Fatalf("find post without events: %v", err)
+	}
+
+	post.Content = value_object.Content("Changed since")
+	if err := posts.Update(ctx, post); err != nil {
+		t.Fatalf("update post: %v", err)
+	}
+
+	found, err := posts.FindById(ctx, int(post.Id))
+	if err != nil || found.Title != "Written before" || found.Content != "Changed since" || found.Version != 2 {
+		t.Fatalf("expected the change to apply to the adopted post, got %+v %v", found, err)
+	}
+}
@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"DDD/src/domain"
+	"DDD/src/domain/value_object"
+	"DDD/src/infrastructure/config"
+	persistence "DDD/src/infrastructure/persistence/gorm"
+	"DDD/src/infrastructure/persistence/gorm/migrations"
+	"DDD/src/infrastructure/persistence/gorm/repository"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestGormRepositories runs against the database in TEST_DB_CONNECTION,
+// or an in-memory SQLite database when it is not set.
+// The database is wiped before every test.
+func TestGormRepositories(t *testing.T) {
+	db := open(t)
+
+	runConformance(t, func(t *testing.T) repositories {
+		wipe(t, db)
+
+		return repositories{
+			posts:    repository.NewPostRepository(db),
+			comments: repository.NewCommentRepository(db),
+			uow:      repository.NewUnitOfWork(db),
+		}
+	})
+}
+
+// open connects to the test database and migrates it.
+func open(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	connString := os.Getenv("TEST_DB_CONNECTION")
+	if connString == "" {
+		connString = "sqlite://:memory:"
+	}
+
+	dbConfig := config.Default().Database
+	dbConfig.Connection = config.Secret(connString)
+
+	db, err := persistence.NewGormConnection(dbConfig, logger.Discard)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	if err := migrations.NewGormMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func wipe(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	session := db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if err := session.Delete(&domain.PostComment{}).Error; err != nil {
+		t.Fatalf("wipe comments: %v", err)
+	}
+	if err := session.Delete(&domain.Post{}).Error; err != nil {
+		t.Fatalf("wipe posts: %v", err)
+	}
+}
+
+// TestTitlesTakenMeanwhileConflict takes a title after the repository checked
+// it and before it writes, as a concurrent request would.
+func TestTitlesTakenMeanwhileConflict(t *testing.T) {
+	db := open(t)
+	wipe(t, db)
+	ctx := context.Background()
+
+	taking := ""
+	take := func(tx *gorm.DB) {
+		if taking == "" || tx.Statement.Table != "posts" {
+			return
+		}
+
+		now := time.Now()
+		err := tx.Session(&gorm.Session{NewDB: true}).
+			Exec("INSERT INTO posts (title, content, version, created_at, updated_at) VALUES (?, '', 1, ?, ?)", taking, now, now).Error
+		if err != nil {
+			t.Errorf("take %s: %v", taking, err)
+		}
+		taking = ""
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("test:take_title", take); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("test:take_title", take); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	for name, posts := range map[string]domain.PostRepository{
+		"rows":   repository.NewPostRepository(db),
+		"events": repository.NewEventSourcedPostRepository(db, 10),
+	} {
+		t.Run(name, func(t *testing.T) {
+			taking = name + " created"
+			err := posts.Create(ctx, &domain.Post{Title: value_object.Title(name + " created"), Content: "Content"})
+			if !errors.Is(err, domain.ErrConflict) {
+				t.Fatalf("expected a conflict on create, got %v", err)
+			}
+
+			post := &domain.Post{Title: value_object.Title(name + " kept"), Content: "Content"}
+			if err := posts.Create(ctx, post); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			taking = name + " renamed"
+			post.Title = value_object.Title(name + " renamed")
+			if err := posts.Update(ctx, post); !errors.Is(err, domain.ErrConflict) {
+				t.Fatalf("expected a conflict on update, got %v", err)
+			}
+		})
+	}
+}
+
+// An in-memory SQLite database lives as long as its connection, whatever the pool settings say.
+func TestSqliteKeepsItsConnection(t *testing.T) {
+	dbConfig := config.Default().Database
+	dbConfig.Connection = "sqlite://:memory:"
+	dbConfig.MaxIdleConns = 0
+	dbConfig.ConnMaxLifetime = time.Millisecond
+	dbConfig.ConnMaxIdleTime = time.Millisecond
+
+	db, err := persistence.NewGormConnection(dbConfig, logger.Discard)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := migrations.NewGormMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	var count int64
+	if err := db.Model(&domain.Post{}).Count(&count).Error; err != nil {
+		t.Fatalf("expected the migrated database to outlive the pool settings: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package persistence
+
+import (
+	"DDD/src/infrastructure/persistence/memory"
+	"testing"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	runConformance(t, func(t *testing.T) repositories {
+		store := memory.NewStore()
+
+		return repositories{
+			posts:    memory.NewPostRepository(store),
+			comments: memory.NewCommentRepository(store),
+			uow:      memory.NewUnitOfWork(store),
+		}
+	})
+}
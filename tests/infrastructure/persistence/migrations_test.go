@@ -0,0 +1,233 @@
+package persistence
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/config"
+	persistence "DDD/src/infrastructure/persistence/gorm"
+	"DDD/src/infrastructure/persistence/gorm/migrations"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+// unmigrated connects to an empty in-memory SQLite database.
+func unmigrated(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbConfig := config.Default().Database
+	dbConfig.Connection = "sqlite://:memory:"
+
+	db, err := persistence.NewGormConnection(dbConfig, logger.Discard)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	return db
+}
+
+// applied lists the versions Status reports as applied.
+func applied(t *testing.T, migrator *migrations.GormMigrator) []int {
+	t.Helper()
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+
+	versions := []int{}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			versions = append(versions, status.Version)
+		}
+	}
+
+	return versions
+}
+
+func pending(t *testing.T, migrator *migrations.GormMigrator) int {
+	t.Helper()
+
+	n, err := migrator.Pending(context.Background())
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+
+	return n
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	postgres, err := migrations.Load("postgres")
+	if err != nil {
+		t.Fatalf("load postgres: %v", err)
+	}
+	sqlite, err := migrations.Load("sqlite")
+	if err != nil {
+		t.Fatalf("load sqlite: %v", err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("expected as many migrations for both dialects, got %d and %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != i+1 || postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Fatalf("expected migration %d to match, got %04d_%s and %04d_%s",
+				i+1, postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestMigratorStatusAndPending(t *testing.T) {
+	ctx := context.Background()
+	migrator := migrations.NewGormMigrator(unmigrated(t))
+	known, _ := migrations.Load("sqlite")
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if len(statuses) != len(known) || statuses[0].Version != 1 || statuses[0].Name != "create_posts_and_comments" {
+		t.Fatalf("expected every migration in order, got %+v", statuses)
+	}
+	if n := pending(t, migrator); n != len(known) || len(applied(t, migrator)) != 0 {
+		t.Fatalf("expected all %d migrations pending on an empty database, got %d", len(known), n)
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if n := pending(t, migrator); n != 0 || len(applied(t, migrator)) != len(known) {
+		t.Fatalf("expected nothing pending after up, got %d", n)
+	}
+
+	// Up is a no-op once everything is applied.
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	ctx := context.Background()
+	db := unmigrated(t)
+	migrator := migrations.NewGormMigrator(db)
+	known, _ := migrations.Load("sqlite")
+	last := known[len(known)-1].Version
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	if err := migrator.Down(ctx, 2); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	versions := applied(t, migrator)
+	if pending(t, migrator) != 2 || versions[len(versions)-1] != last-2 {
+		t.Fatalf("expected the last two migrations reverted, got %v applied", versions)
+	}
+	if db.Migrator().HasColumn(&domain.PostComment{}, "version") {
+		t.Fatal("expected the comment version column to be dropped")
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+	if pending(t, migrator) != 0 || !db.Migrator().HasColumn(&domain.PostComment{}, "version") {
+		t.Fatal("expected the reverted migrations applied again")
+	}
+
+	// Every down file runs, beyond the applied migrations too, and the
+	// schema is built again from scratch.
+	if err := migrator.Down(ctx, len(known)+1); err != nil {
+		t.Fatalf("down all: %v", err)
+	}
+	if pending(t, migrator) != len(known) || db.Migrator().HasTable("posts") || db.Migrator().HasTable("jobs") {
+		t.Fatal("expected every table dropped")
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up from scratch: %v", err)
+	}
+}
+
+func TestMigratorRedo(t *testing.T) {
+	ctx := context.Background()
+	db := unmigrated(t)
+	migrator := migrations.NewGormMigrator(db)
+
+	// Redo without any applied migration does nothing.
+	if err := migrator.Redo(ctx); err != nil {
+		t.Fatalf("redo on an empty database: %v", err)
+	}
+	if len(applied(t, migrator)) != 0 {
+		t.Fatal("expected nothing applied by redo")
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	post := &domain.Post{Title: "Kept", Content: "Content"}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if err := db.Create(&domain.PostComment{PostId: post.Id, Text: "Kept too"}).Error; err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	before := applied(t, migrator)
+
+	if err := migrator.Redo(ctx); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if after := applied(t, migrator); len(after) != len(before) || pending(t, migrator) != 0 {
+		t.Fatalf("expected the same migrations applied after redo, got %v", after)
+	}
+
+	var comment domain.PostComment
+	if err := db.First(&comment).Error; err != nil || comment.Text != "Kept too" || comment.Version != 1 {
+		t.Fatalf("expected the data to survive the redo, got %+v %v", comment, err)
+	}
+}
+
+func TestUniqueTitlesRenameDuplicates(t *testing.T) {
+	ctx := context.Background()
+	db := unmigrated(t)
+	migrator := migrations.NewGormMigrator(db)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+
+	for id, title := range map[int]string{1: "Same title", 2: "Other title", 3: "Same title", 4: "Same title"} {
+		if err := db.Exec(`INSERT INTO posts (id, title, content, version, created_at, updated_at) VALUES (?, ?, 'Content', 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`, id, title).Error; err != nil {
+			t.Fatalf("insert post: %v", err)
+		}
+		if err := db.Exec(`INSERT INTO post_summaries (post_id, title, excerpt, version, last_activity_at) VALUES (?, ?, 'Content', 1, CURRENT_TIMESTAMP)`, id, title).Error; err != nil {
+			t.Fatalf("insert summary: %v", err)
+		}
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("expected the duplicate titles renamed, got %v", err)
+	}
+
+	var posts []domain.Post
+	if err := db.Order("id").Find(&posts).Error; err != nil {
+		t.Fatalf("find posts: %v", err)
+	}
+	expected := []string{"Same title", "Other title", "Same title (3)", "Same title (4)"}
+	versions := []uint{1, 1, 2, 2}
+	for i, post := range posts {
+		if post.Title.String() != expected[i] || post.Version != versions[i] {
+			t.Fatalf("expected %q version %d, got %q version %d", expected[i], versions[i], post.Title, post.Version)
+		}
+	}
+
+	var summaries []string
+	if err := db.Table("post_summaries").Order("post_id").Pluck("title", &summaries).Error; err != nil {
+		t.Fatalf("find summaries: %v", err)
+	}
+	if len(summaries) != 4 || summaries[2] != expected[2] || summaries[3] != expected[3] {
+		t.Fatalf("expected the summaries renamed too, got %v", summaries)
+	}
+}
@@ -0,0 +1,159 @@
+package projection
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/cli"
+	"DDD/src/infrastructure/config"
+	"DDD/tests/testenv"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type summary struct {
+	Id             uint   `json:"id"`
+	Title          string `json:"title"`
+	Excerpt        string `json:"excerpt"`
+	CommentCount   int    `json:"commentCount"`
+	LastActivityAt string `json:"lastActivityAt"`
+	UpdatedAt      string `json:"updatedAt"`
+}
+
+func list(t *testing.T, env *testenv.Env, order string) []summary {
+	t.Helper()
+
+	status, page := env.Send("GET", "/api/v1/posts/summaries?order="+order, "")
+	if status != 200 {
+		t.Fatalf("list summaries: %d %v", status, page)
+	}
+
+	data, _ := json.Marshal(page["data"])
+	var summaries []summary
+	_ = json.Unmarshal(data, &summaries)
+
+	return summaries
+}
+
+func ids(summaries []summary) string {
+	var ids []string
+	for _, s := range summaries {
+		ids = append(ids, fmt.Sprint(s.Id))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func TestSummariesFollowTheEvents(t *testing.T) {
+	env := testenv.New(t, nil)
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Quiet","content":"`+strings.Repeat("a", domain.ExcerptLength+50)+`"}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Busy","content":"Content"}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Latest","content":"Content"}`)
+	env.Send("POST", "/api/v1/posts/2/comments", `{"text":"One"}`)
+	env.Send("POST", "/api/v1/posts/2/comments", `{"text":"Two"}`)
+	_, comment := env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Recent"}`)
+
+	summaries := list(t, env, domain.SummariesByLatest)
+	if ids(summaries) != "3,2,1" {
+		t.Fatalf("expected the latest posts first, got %s", ids(summaries))
+	}
+	if summaries[1].CommentCount != 2 || summaries[2].CommentCount != 1 || summaries[0].CommentCount != 0 {
+		t.Fatalf("expected the comments to be counted, got %+v", summaries)
+	}
+	if len(summaries[2].Excerpt) != domain.ExcerptLength {
+		t.Fatalf("expected the content to be cut to an excerpt, got %d characters", len(summaries[2].Excerpt))
+	}
+	if got := ids(list(t, env, domain.SummariesByActivity)); got != "1,2,3" {
+		t.Fatalf("expected the latest activity first, got %s", got)
+	}
+	if got := ids(list(t, env, domain.SummariesByComments)); got != "2,1,3" {
+		t.Fatalf("expected the most comments first, got %s", got)
+	}
+
+	if status, _ := env.Send("PATCH", "/api/v1/posts/3", `{"title":"Renamed"}`); status != 200 {
+		t.Fatalf("update post: %d", status)
+	}
+	if latest := list(t, env, domain.SummariesByActivity)[0]; latest.Id != 3 || latest.Title != "Renamed" {
+		t.Fatalf("expected the update to be projected, got %+v", latest)
+	}
+
+	// Without its comment, the post is as active as when it was written.
+	if status, _ := env.Send("DELETE", fmt.Sprintf("/api/v1/comments/%v", comment["id"]), ""); status != 204 {
+		t.Fatalf("delete comment: %d", status)
+	}
+	quiet := list(t, env, domain.SummariesByLatest)[2]
+	if quiet.CommentCount != 0 || quiet.LastActivityAt != quiet.UpdatedAt {
+		t.Fatalf("expected the deleted comment to be forgotten, got %+v", quiet)
+	}
+
+	env.Send("DELETE", "/api/v1/posts/2", "")
+	if got := ids(list(t, env, domain.SummariesByLatest)); got != "3,1" {
+		t.Fatalf("expected the deleted post to be dropped, got %s", got)
+	}
+
+	if status, _ := env.Send("GET", "/api/v1/posts/summaries?order=random", ""); status != 400 {
+		t.Fatalf("expected 400 for an unknown order, got %d", status)
+	}
+}
+
+func TestRebuildMatchesTheProjection(t *testing.T) {
+	env := testenv.New(t, nil)
+	ctx := context.Background()
+
+	env.Send("POST", "/api/v1/posts", `{"title":"First","content":"Content"}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Second","content":"Content"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"One"}`)
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Two"}`)
+	env.Send("PATCH", "/api/v1/posts/2", `{"content":"Edited"}`)
+	env.Send("PATCH", "/api/v1/comments/1", `{"text":"Edited"}`)
+	projected := list(t, env, domain.SummariesByActivity)
+
+	// The read model misses writes made past the services.
+	if err := env.C.DB.Exec("DELETE FROM post_summaries WHERE post_id = 1").Error; err != nil {
+		t.Fatalf("drop summary: %v", err)
+	}
+	if err := env.C.DB.Exec("UPDATE post_summaries SET comment_count = 7").Error; err != nil {
+		t.Fatalf("corrupt summary: %v", err)
+	}
+
+	summarized, err := env.C.PostQueries.Rebuild(ctx)
+	if err != nil || summarized != 2 {
+		t.Fatalf("expected 2 posts to be summarized, got %d %v", summarized, err)
+	}
+
+	rebuilt := list(t, env, domain.SummariesByActivity)
+	if fmt.Sprint(rebuilt) != fmt.Sprint(projected) {
+		t.Fatalf("expected the rebuild to match the projection\nprojected %+v\nrebuilt   %+v", projected, rebuilt)
+	}
+}
+
+func TestImportedPostsAreProjected(t *testing.T) {
+	dir := t.TempDir()
+	db := "sqlite://" + filepath.Join(dir, "blog.db")
+	export := filepath.Join(dir, "export.json")
+	document := `{"posts":[
+		{"title":"Imported","content":"Content","createdAt":"2024-01-01T00:00:00Z","comments":[{"text":"One","createdAt":"2024-01-02T00:00:00Z"}]},
+		{"title":"Also imported","content":"Content","createdAt":"2024-01-03T00:00:00Z","comments":[]}
+	]}`
+	if err := os.WriteFile(export, []byte(document), 0o600); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+
+	for _, args := range [][]string{{"migrate", "up"}, {"import", "-file", export}} {
+		if code := cli.Run(context.Background(), append([]string{"-db", db}, args...)); code != 0 {
+			t.Fatalf("%v exited with %d", args, code)
+		}
+	}
+
+	env := testenv.NewUnmigrated(t, func(cfg *config.Config) {
+		cfg.Database.Connection = config.Secret(db)
+	})
+	summaries := list(t, env, domain.SummariesByComments)
+	if ids(summaries) != "1,2" || summaries[0].CommentCount != 1 {
+		t.Fatalf("expected the import to be projected, got %+v", summaries)
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/ratelimit"
+	"DDD/tests/testenv"
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) ratelimit.Store{
+		"memory": func(t *testing.T) ratelimit.Store {
+			return ratelimit.NewMemoryStore()
+		},
+		"redis": func(t *testing.T) ratelimit.Store {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			return ratelimit.NewRedisStore(client, "test:")
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+			rate := config.Rate{Requests: 3, Period: time.Hour}
+
+			for i := range 3 {
+				result, err := store.Take(ctx, "client", rate)
+				if err != nil {
+					t.Fatalf("take: %v", err)
+				}
+				if !result.Allowed || result.Remaining != 2-i || result.Limit != 3 {
+					t.Fatalf("expected request %d allowed with %d remaining, got %+v", i, 2-i, result)
+				}
+			}
+
+			result, err := store.Take(ctx, "client", rate)
+			if err != nil {
+				t.Fatalf("take: %v", err)
+			}
+			if result.Allowed || result.RetryAfter <= 19*time.Minute || result.RetryAfter > 20*time.Minute {
+				t.Fatalf("expected denial until the next token in 20m, got %+v", result)
+			}
+
+			if result, _ := store.Take(ctx, "other", rate); !result.Allowed {
+				t.Fatalf("expected another client to have its own bucket, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestCommentLimit(t *testing.T) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.RateLimit.Comments = config.Rate{Requests: 1, Period: time.Minute}
+	})
+
+	if resp, _ := env.Do("POST", "/api/v1/posts", `{"title":"Limited","content":"Content"}`); resp.StatusCode != 201 {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	resp, _ := env.Do("POST", "/api/v1/posts/1/comments", `{"text":"First comment"}`)
+	if resp.StatusCode != 201 || resp.Header.Get("RateLimit-Limit") != "1" || resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected the comment with rate limit headers, got %d %q %q", resp.StatusCode, resp.Header.Get("RateLimit-Limit"), resp.Header.Get("RateLimit-Remaining"))
+	}
+
+	resp, _ = env.Do("POST", "/api/v1/posts/1/comments", `{"text":"Second comment"}`)
+	if resp.StatusCode != 429 || resp.Header.Get("Retry-After") != "60" {
+		t.Fatalf("expected 429 retrying after 60s, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	resp, _ = env.Do("GET", "/api/v1/posts/1/comments", "")
+	if resp.StatusCode != 200 || resp.Header.Get("RateLimit-Limit") != "300" {
+		t.Fatalf("expected reads to have their own limit, got %d %q", resp.StatusCode, resp.Header.Get("RateLimit-Limit"))
+	}
+}
+
+func TestProxyHeaderIsOnlyTrustedFromProxies(t *testing.T) {
+	clients := func(proxy string) *testenv.Env {
+		return testenv.New(t, func(cfg *config.Config) {
+			cfg.RateLimit.Reads = config.Rate{Requests: 1, Period: time.Minute}
+			cfg.Server.ProxyHeader = "X-Real-IP"
+			cfg.Server.TrustedProxies = config.List{proxy}
+		})
+	}
+
+	// Test requests come from 0.0.0.0.
+	env := clients("0.0.0.0/32")
+	if resp, _ := env.Do("GET", "/api/v1/posts", "", "X-Real-IP", "203.0.113.1"); resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if resp, _ := env.Do("GET", "/api/v1/posts", "", "X-Real-IP", "203.0.113.2"); resp.StatusCode != 200 {
+		t.Fatalf("expected each client behind the proxy to have its own bucket, got %d", resp.StatusCode)
+	}
+	if resp, _ := env.Do("GET", "/api/v1/posts", "", "X-Real-IP", "203.0.113.1"); resp.StatusCode != 429 {
+		t.Fatalf("expected the first client to be limited, got %d", resp.StatusCode)
+	}
+
+	env = clients("192.0.2.1")
+	if resp, _ := env.Do("GET", "/api/v1/posts", "", "X-Real-IP", "203.0.113.1"); resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if resp, _ := env.Do("GET", "/api/v1/posts", "", "X-Real-IP", "203.0.113.2"); resp.StatusCode != 429 {
+		t.Fatalf("expected the header of an untrusted client to be ignored, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,385 @@
+package stream
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/stream"
+	"DDD/tests/testenv"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fasthttp/websocket"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type server struct {
+	c    *bootstrap.Container
+	base string
+}
+
+// newServer listens on a real port, since streams never end for app.Test to read them.
+func newServer(t *testing.T) *server {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.Stream.Heartbeat = 50 * time.Millisecond
+	})
+	c, app := env.C, env.App
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go app.Listener(ln)
+	t.Cleanup(func() {
+		c.Streams.Close()
+		_ = app.Shutdown()
+	})
+
+	return &server{c: c, base: "http://" + ln.Addr().String()}
+}
+
+func (s *server) send(t *testing.T, method, target, body string) (int, map[string]any) {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, s.base+target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	return resp.StatusCode, result
+}
+
+type event struct {
+	Id   string
+	Type string
+	Data map[string]any
+}
+
+// follower reads a Server-Sent Events stream, event by event.
+type follower struct {
+	// Id is the latest id received, with or without an event.
+	Id     string
+	body   io.Closer
+	events chan event
+}
+
+func (s *server) follow(t *testing.T, postId string, lastEventId string) *follower {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", s.base+"/api/v1/posts/"+postId+"/comments/stream", nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	f := &follower{body: resp.Body, events: make(chan event, 16)}
+	ids := make(chan string, 1)
+	go func() {
+		defer close(f.events)
+
+		scanner := bufio.NewScanner(resp.Body)
+		current := event{}
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				current.Id = value
+			case "event":
+				current.Type = value
+			case "data":
+				_ = json.Unmarshal([]byte(value), &current.Data)
+			case "":
+				if current.Type != "" {
+					f.events <- current
+				} else if current.Id != "" {
+					select {
+					case ids <- current.Id:
+					default:
+					}
+				}
+				current = event{}
+			}
+		}
+	}()
+
+	if lastEventId == "" {
+		select {
+		case f.Id = <-ids:
+		case <-time.After(time.Second):
+			t.Fatal("expected the stream to start with an id")
+		}
+	}
+
+	return f
+}
+
+func (f *follower) next(t *testing.T) event {
+	t.Helper()
+
+	select {
+	case e, ok := <-f.events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		f.Id = e.Id
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+
+	return event{}
+}
+
+func (f *follower) ended(t *testing.T) {
+	t.Helper()
+
+	select {
+	case e, ok := <-f.events:
+		if ok {
+			t.Fatalf("expected the stream to end, got %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the stream to end")
+	}
+}
+
+func TestServerSentEvents(t *testing.T) {
+	s := newServer(t)
+
+	if status, _ := s.send(t, "POST", "/api/v1/posts", `{"title":"Followed","content":"Content"}`); status != 201 {
+		t.Fatalf("create post: %d", status)
+	}
+
+	f := s.follow(t, "1", "")
+	start := f.Id
+
+	_, comment := s.send(t, "POST", "/api/v1/posts/1/comments", `{"text":"First"}`)
+	id, _ := json.Marshal(comment["id"])
+	if e := f.next(t); e.Type != "comment.created" || e.Data["text"] != "First" {
+		t.Fatalf("expected comment.created, got %+v", e)
+	}
+	created := f.Id
+
+	if status, _ := s.send(t, "PATCH", "/api/v1/comments/"+string(id), `{"text":"Edited"}`); status != 200 {
+		t.Fatalf("update comment: %d", status)
+	}
+	if e := f.next(t); e.Type != "comment.updated" || e.Data["text"] != "Edited" {
+		t.Fatalf("expected comment.updated, got %+v", e)
+	}
+
+	if status, _ := s.send(t, "DELETE", "/api/v1/comments/"+string(id), ""); status != 204 {
+		t.Fatalf("delete comment: %d", status)
+	}
+	if e := f.next(t); e.Type != "comment.deleted" {
+		t.Fatalf("expected comment.deleted, got %+v", e)
+	}
+
+	// Comments of other posts are not followed.
+	s.send(t, "POST", "/api/v1/posts", `{"title":"Other","content":"Content"}`)
+	s.send(t, "POST", "/api/v1/posts/2/comments", `{"text":"Elsewhere"}`)
+
+	// Resuming replays what was missed, from the start or from an event.
+	resumed := s.follow(t, "1", start)
+	for _, expected := range []string{"comment.created", "comment.updated", "comment.deleted"} {
+		if e := resumed.next(t); e.Type != expected {
+			t.Fatalf("expected %s to be replayed, got %+v", expected, e)
+		}
+	}
+	if e := s.follow(t, "1", created).next(t); e.Type != "comment.updated" {
+		t.Fatalf("expected the replay to start after the event, got %+v", e)
+	}
+
+	// Ids that are no longer known ask the client to reload.
+	if e := s.follow(t, "1", "1").next(t); e.Type != stream.EventReset {
+		t.Fatalf("expected a reset, got %+v", e)
+	}
+
+	if status, _ := s.send(t, "DELETE", "/api/v1/posts/1", ""); status != 204 {
+		t.Fatalf("delete post: %d", status)
+	}
+	if e := f.next(t); e.Type != "post.deleted" {
+		t.Fatalf("expected post.deleted, got %+v", e)
+	}
+	f.ended(t)
+}
+
+func TestStreamRequests(t *testing.T) {
+	s := newServer(t)
+
+	if status, _ := s.send(t, "GET", "/api/v1/posts/1/comments/stream", ""); status != 404 {
+		t.Fatalf("expected 404 for a missing post, got %d", status)
+	}
+
+	s.send(t, "POST", "/api/v1/posts", `{"title":"Followed","content":"Content"}`)
+	if status, _ := s.send(t, "GET", "/api/v1/posts/1/comments/stream?lastEventId=latest", ""); status != 400 {
+		t.Fatalf("expected 400 for an invalid last event id, got %d", status)
+	}
+	if status, _ := s.send(t, "PATCH", "/api/v1/comments/1", `{"text":"Edited"}`); status != 404 {
+		t.Fatalf("expected 404 to update a missing comment, got %d", status)
+	}
+	if status, _ := s.send(t, "DELETE", "/api/v1/comments/1", ""); status != 404 {
+		t.Fatalf("expected 404 to delete a missing comment, got %d", status)
+	}
+
+	// Shutting down ends the streams.
+	f := s.follow(t, "1", "")
+	s.c.Streams.Close()
+	f.ended(t)
+}
+
+func TestWebSocket(t *testing.T) {
+	s := newServer(t)
+	s.send(t, "POST", "/api/v1/posts", `{"title":"Followed","content":"Content"}`)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.base, "http")+"/api/v1/posts/1/comments/stream", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var ready struct{ Id, Type string }
+	if err := conn.ReadJSON(&ready); err != nil || ready.Type != "ready" || ready.Id == "" {
+		t.Fatalf("expected a ready message, got %+v %v", ready, err)
+	}
+
+	s.send(t, "POST", "/api/v1/posts/1/comments", `{"text":"Live"}`)
+
+	var message struct {
+		Id   string
+		Type string
+		Data map[string]any
+	}
+	if err := conn.ReadJSON(&message); err != nil || message.Type != "comment.created" || message.Data["text"] != "Live" {
+		t.Fatalf("expected comment.created, got %+v %v", message, err)
+	}
+
+	s.send(t, "DELETE", "/api/v1/posts/1", "")
+	if err := conn.ReadJSON(&message); err != nil || message.Type != "post.deleted" {
+		t.Fatalf("expected post.deleted, got %+v %v", message, err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected a normal closure, got %v", err)
+	}
+}
+
+func TestSlowSubscribersAreDropped(t *testing.T) {
+	hub := stream.NewHub(stream.Options{Buffer: 2, History: 10, MaxSubscribers: 2})
+
+	slow, _ := hub.Subscribe("topic", 0)
+	for range 3 {
+		hub.Publish("topic", "tick", []byte(`{}`))
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	if received != 2 || !errors.Is(slow.Err(), stream.ErrSlowSubscriber) {
+		t.Fatalf("expected the buffered events then ErrSlowSubscriber, got %d %v", received, slow.Err())
+	}
+
+	// The dropped subscriber resumes from the last event it received.
+	resumed, _ := hub.Subscribe("topic", slow.Position()+2)
+	if e := <-resumed.Events(); e.Id != slow.Position()+3 {
+		t.Fatalf("expected the missed event to be replayed, got %+v", e)
+	}
+
+	hub.Subscribe("topic", 0)
+	if _, err := hub.Subscribe("topic", 0); !errors.Is(err, stream.ErrTooManySubscribers) {
+		t.Fatalf("expected ErrTooManySubscribers, got %v", err)
+	}
+
+	resumed.Close()
+	if _, ok := <-resumed.Events(); ok || resumed.Err() != nil {
+		t.Fatalf("expected a closed subscription without error, got %v", resumed.Err())
+	}
+}
+
+func TestRedisRelaySharesEventsBetweenReplicas(t *testing.T) {
+	addr := miniredis.RunT(t).Addr()
+	ctx := context.Background()
+
+	replica := func() (*stream.Hub, *stream.RedisRelay, *stream.Subscription) {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { client.Close() })
+
+		hub := stream.NewHub(stream.Options{Buffer: 8, History: 10})
+		relay := stream.NewRedisRelay(hub, client, "test:stream:")
+		following, _ := hub.Subscribe(stream.CommentsTopic(1), 0)
+
+		running, stop := context.WithCancel(ctx)
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			relay.Run(running)
+		}()
+		t.Cleanup(func() {
+			stop()
+			<-stopped
+		})
+
+		// Subscribers are reset once the relay follows the channel.
+		if e := <-following.Events(); e.Type != stream.EventReset {
+			t.Fatalf("expected a reset on subscribing to the channel, got %+v", e)
+		}
+
+		return hub, relay, following
+	}
+
+	first, relay, _ := replica()
+	_, _, following := replica()
+
+	for id := range uint(2) {
+		relay.HandleEvent(ctx, domain.CommentCreated{Comment: domain.PostComment{Id: id + 1, PostId: 1}})
+	}
+	relay.HandleEvent(ctx, domain.PostCreated{})
+
+	var events []stream.Event
+	for range 2 {
+		select {
+		case e := <-following.Events():
+			events = append(events, e)
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected the events of the other replica")
+		}
+	}
+	if events[0].Id != 1 || events[1].Id != 2 || events[1].Type != "comment.created" || !strings.Contains(string(events[1].Data), `"id":2`) {
+		t.Fatalf("expected both comments numbered by the relay, got %+v", events)
+	}
+
+	// A client resumes on the replica that published, with the ids of the other.
+	resumed, _ := first.Subscribe(stream.CommentsTopic(1), events[0].Id)
+	select {
+	case e := <-resumed.Events():
+		if e.Id != events[1].Id {
+			t.Fatalf("expected the second comment replayed, got %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the missed event to be replayed")
+	}
+}
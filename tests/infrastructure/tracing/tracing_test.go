@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"DDD/src/infrastructure/tracing"
+	"DDD/tests/testenv"
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	env := testenv.New(t, nil)
+	resp, _ := env.Do("GET", "/api/v1/posts/1", "", "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if resp.StatusCode != 404 {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	server, ok := spans["GET /api/v1/posts/:id"]
+	if !ok {
+		t.Fatalf("expected server span, got %v", names(exporter.GetSpans()))
+	}
+	if server.SpanKind != trace.SpanKindServer || server.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected server span continuing the incoming trace, got %+v", server)
+	}
+
+	service, ok := spans["PostService.FindRevision"]
+	if !ok || service.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("expected service span under the server span, got %v", names(exporter.GetSpans()))
+	}
+
+	query, ok := spans["ROW posts"]
+	if !ok || query.Parent.SpanID() != service.SpanContext.SpanID() {
+		t.Fatalf("expected query span under the service span, got %v", names(exporter.GetSpans()))
+	}
+}
+
+func names(spans tracetest.SpanStubs) []string {
+	result := make([]string, 0, len(spans))
+	for _, span := range spans {
+		result = append(result, span.Name)
+	}
+
+	return result
+}
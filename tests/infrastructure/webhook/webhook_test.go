@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"DDD/src/domain"
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"DDD/src/infrastructure/webhook"
+	"DDD/tests/testenv"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type received struct {
+	Event string
+	Valid bool
+	Body  struct {
+		Id   string         `json:"id"`
+		Type string         `json:"type"`
+		Data map[string]any `json:"data"`
+	}
+}
+
+// receiver records the deliveries it gets, and answers with status.
+type receiver struct {
+	*httptest.Server
+	secret string
+	status atomic.Int32
+
+	mu         sync.Mutex
+	deliveries []received
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{}
+	r.status.Store(http.StatusNoContent)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		delivery := received{
+			Event: req.Header.Get(webhook.HeaderEvent),
+			Valid: webhook.Verify(r.secret, req.Header.Get(webhook.HeaderTimestamp), req.Header.Get(webhook.HeaderSignature), body),
+		}
+		_ = json.Unmarshal(body, &delivery.Body)
+
+		r.mu.Lock()
+		r.deliveries = append(r.deliveries, delivery)
+		r.mu.Unlock()
+
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *receiver) received() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]received(nil), r.deliveries...)
+}
+
+// admin sends a request to the webhook endpoints as their administrator.
+func admin(env *testenv.Env, method, target, body string) (int, map[string]any) {
+	return env.Send(method, target, body, "Authorization", "Bearer admin-token")
+}
+
+func newEnv(t *testing.T, allowPrivate bool) *testenv.Env {
+	return testenv.New(t, func(cfg *config.Config) {
+		cfg.Webhooks.AdminToken = "admin-token"
+		cfg.Webhooks.AllowPrivateNetworks = allowPrivate
+		cfg.Webhooks.MaxAttempts = 2
+		cfg.Webhooks.Backoff = 0
+		cfg.Webhooks.MaxBackoff = 0
+	})
+}
+
+func dispatch(t *testing.T, c *bootstrap.Container, expected int) {
+	t.Helper()
+
+	n, err := c.Dispatcher.Dispatch(context.Background())
+	if err != nil || n != expected {
+		t.Fatalf("expected %d deliveries to be attempted, got %d %v", expected, n, err)
+	}
+}
+
+func TestSignedDeliveriesWithRetries(t *testing.T) {
+	env := newEnv(t, true)
+	r := newReceiver(t)
+
+	status, created := admin(env, "POST", "/api/v1/webhooks", `{"url":"`+r.URL+`","events":["post.created"]}`)
+	if status != 201 || len(created["secret"].(string)) != 64 {
+		t.Fatalf("expected a webhook with a generated secret, got %d %v", status, created)
+	}
+	r.secret = created["secret"].(string)
+	hooks := "/api/v1/webhooks/" + jsonId(created)
+
+	if status, _ := admin(env, "GET", hooks, ""); status != 200 {
+		t.Fatalf("expected the webhook, got %d", status)
+	}
+
+	if status, _ := env.Send("POST", "/api/v1/posts", `{"title":"Announced","content":"Content"}`); status != 201 {
+		t.Fatalf("create post: %d", status)
+	}
+	dispatch(t, env.C, 1)
+	dispatch(t, env.C, 0)
+
+	deliveries := r.received()
+	if len(deliveries) != 1 || !deliveries[0].Valid || deliveries[0].Event != "post.created" ||
+		deliveries[0].Body.Type != "post.created" || deliveries[0].Body.Data["title"] != "Announced" {
+		t.Fatalf("expected a signed post.created delivery, got %+v", deliveries)
+	}
+
+	// The receiver fails: the delivery is retried, then dead.
+	r.status.Store(http.StatusInternalServerError)
+	if status, _ := env.Send("POST", "/api/v1/posts", `{"title":"Failing","content":"Content"}`); status != 201 {
+		t.Fatalf("create post: %d", status)
+	}
+	dispatch(t, env.C, 1)
+	dispatch(t, env.C, 1)
+	dispatch(t, env.C, 0)
+
+	_, log := admin(env, "GET", hooks+"/deliveries", "")
+	entries := log["data"].([]any)
+	latest := entries[0].(map[string]any)
+	if len(entries) != 2 || latest["status"] != "dead" || latest["attempts"] != float64(2) || latest["responseStatus"] != float64(500) {
+		t.Fatalf("expected the latest delivery to be dead after 2 attempts, got %v", entries)
+	}
+	if first := entries[1].(map[string]any); first["status"] != "succeeded" || first["deliveredAt"] == nil {
+		t.Fatalf("expected the first delivery to have succeeded, got %v", first)
+	}
+
+	// Redelivered by hand once the receiver is fixed.
+	r.status.Store(http.StatusOK)
+	redeliver := hooks + "/deliveries/" + jsonId(latest) + "/redeliver"
+	if status, body := admin(env, "POST", redeliver, ""); status != 202 || body["status"] != "pending" || body["attempts"] != float64(0) {
+		t.Fatalf("expected the delivery to be pending again, got %d %v", status, body)
+	}
+	if status, _ := admin(env, "POST", redeliver, ""); status != 409 {
+		t.Fatalf("expected 409 for a pending delivery, got %d", status)
+	}
+	dispatch(t, env.C, 1)
+
+	deliveries = r.received()
+	if len(deliveries) != 4 || deliveries[3].Body.Id != deliveries[2].Body.Id || deliveries[3].Body.Data["title"] != "Failing" {
+		t.Fatalf("expected the same event to be delivered again, got %+v", deliveries)
+	}
+	if _, log := admin(env, "GET", hooks+"/deliveries", ""); log["data"].([]any)[0].(map[string]any)["status"] != "succeeded" {
+		t.Fatalf("expected the redelivery to have succeeded, got %v", log)
+	}
+
+	if status, _ := admin(env, "DELETE", hooks, ""); status != 204 {
+		t.Fatalf("delete webhook: %d", status)
+	}
+	if status, _ := admin(env, "GET", hooks+"/deliveries", ""); status != 404 {
+		t.Fatalf("expected 404 for the deliveries of a deleted webhook, got %d", status)
+	}
+}
+
+func TestBatchesAreAttemptedAtOnce(t *testing.T) {
+	env := testenv.New(t, func(cfg *config.Config) {
+		cfg.Webhooks.AdminToken = "admin-token"
+		cfg.Webhooks.AllowPrivateNetworks = true
+		cfg.Webhooks.Concurrency = 2
+	})
+	r := newReceiver(t)
+
+	admin(env, "POST", "/api/v1/webhooks", `{"url":"`+r.URL+`","events":["post.created"]}`)
+	for i := range 3 {
+		env.Send("POST", "/api/v1/posts", fmt.Sprintf(`{"title":"Post %d","content":"Content"}`, i))
+	}
+
+	// No more deliveries are claimed than run at once, so none waits for a
+	// slot while its lease runs out.
+	dispatch(t, env.C, 2)
+	dispatch(t, env.C, 1)
+	dispatch(t, env.C, 0)
+}
+
+func TestOutcomesOfLostLeasesAreDiscarded(t *testing.T) {
+	env := newEnv(t, true)
+	r := newReceiver(t)
+	ctx := context.Background()
+	service := env.C.WebhookService
+
+	admin(env, "POST", "/api/v1/webhooks", `{"url":"`+r.URL+`","events":["post.created"]}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Slow receiver","content":"Content"}`)
+
+	// The first claim runs out at once, and another worker takes the delivery.
+	stale, err := service.ClaimDue(ctx, -time.Millisecond, 1)
+	if err != nil || len(stale) != 1 {
+		t.Fatalf("claim: %v %v", stale, err)
+	}
+	current, err := service.ClaimDue(ctx, time.Hour, 1)
+	if err != nil || len(current) != 1 {
+		t.Fatalf("claim again: %v %v", current, err)
+	}
+
+	if err := service.RecordFailure(ctx, &current[0].Delivery, 500, "receiver answered 500"); err != nil {
+		t.Fatalf("record the current attempt: %v", err)
+	}
+	if err := service.RecordSuccess(ctx, &stale[0].Delivery, 204); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected the stale attempt to conflict, got %v", err)
+	}
+
+	_, log := admin(env, "GET", "/api/v1/webhooks/1/deliveries", "")
+	if delivery := log["data"].([]any)[0].(map[string]any); delivery["status"] != "pending" || delivery["attempts"] != float64(1) {
+		t.Fatalf("expected the outcome of the current attempt, got %v", delivery)
+	}
+}
+
+func TestOnlySubscribedEventsAreDelivered(t *testing.T) {
+	env := newEnv(t, true)
+	r := newReceiver(t)
+
+	status, created := admin(env, "POST", "/api/v1/webhooks", `{"url":"`+r.URL+`","events":["comment.created"],"secret":"a-secret-of-our-own"}`)
+	if status != 201 || created["secret"] != "a-secret-of-our-own" {
+		t.Fatalf("expected the given secret to be kept, got %d %v", status, created)
+	}
+	r.secret = "a-secret-of-our-own"
+
+	env.Send("POST", "/api/v1/posts", `{"title":"Quiet","content":"Content"}`)
+	dispatch(t, env.C, 0)
+
+	env.Send("POST", "/api/v1/posts/1/comments", `{"text":"Loud"}`)
+	dispatch(t, env.C, 1)
+
+	if deliveries := r.received(); len(deliveries) != 1 || !deliveries[0].Valid || deliveries[0].Body.Data["text"] != "Loud" {
+		t.Fatalf("expected only the comment to be delivered, got %+v", deliveries)
+	}
+}
+
+func TestInvalidSubscriptions(t *testing.T) {
+	env := newEnv(t, true)
+
+	for _, body := range []string{
+		`{"url":"ftp://example.com","events":["post.created"]}`,
+		`{"url":"/relative","events":["post.created"]}`,
+		`{"url":"https://example.com","events":[]}`,
+		`{"url":"https://example.com","events":["post.published"]}`,
+		`{"url":"https://example.com","events":["post.created"],"secret":"short"}`,
+	} {
+		if status, _ := admin(env, "POST", "/api/v1/webhooks", body); status != 400 {
+			t.Fatalf("expected 400 for %s, got %d", body, status)
+		}
+	}
+}
+
+func TestPrivateNetworksAreRefused(t *testing.T) {
+	env := newEnv(t, false)
+	r := newReceiver(t)
+
+	_, created := admin(env, "POST", "/api/v1/webhooks", `{"url":"`+r.URL+`","events":["post.created"]}`)
+	env.Send("POST", "/api/v1/posts", `{"title":"Internal","content":"Content"}`)
+	dispatch(t, env.C, 1)
+
+	_, log := admin(env, "GET", "/api/v1/webhooks/"+jsonId(created)+"/deliveries", "")
+	delivery := log["data"].([]any)[0].(map[string]any)
+	if len(r.received()) != 0 || delivery["responseStatus"] != float64(0) || !strings.Contains(delivery["lastError"].(string), "private address") {
+		t.Fatalf("expected the loopback receiver to be refused, got %v", delivery)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	signature := webhook.Sign("secret", 1700000000, []byte(`{"id":"1"}`))
+	if !strings.HasPrefix(signature, "sha256=") || len(signature) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %q", signature)
+	}
+
+	if !webhook.Verify("secret", "1700000000", signature, []byte(`{"id":"1"}`)) {
+		t.Fatal("expected the signature to verify")
+	}
+	if webhook.Verify("secret", "1700000001", signature, []byte(`{"id":"1"}`)) {
+		t.Fatal("expected another timestamp to fail")
+	}
+	if webhook.Verify("other", "1700000000", signature, []byte(`{"id":"1"}`)) {
+		t.Fatal("expected another secret to fail")
+	}
+}
+
+func TestBackoffDoublesUpToTheMaximum(t *testing.T) {
+	policy := domain.RetryPolicy{MaxAttempts: 8, Backoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+	expected := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for attempt, delay := range expected {
+		if got := policy.Delay(attempt + 1); got != delay {
+			t.Fatalf("expected %s after attempt %d, got %s", delay, attempt+1, got)
+		}
+	}
+}
+
+func jsonId(resource map[string]any) string {
+	id, _ := json.Marshal(resource["id"])
+	return string(id)
+}
+
+func TestWebhooksRequireTheAdminToken(t *testing.T) {
+	env := newEnv(t, true)
+
+	for _, authorization := range []string{"", "Bearer guessed"} {
+		resp, _ := env.Do("GET", "/api/v1/webhooks", "", "Authorization", authorization)
+		if resp.StatusCode != 401 || resp.Header.Get("WWW-Authenticate") != `Bearer realm="webhooks"` {
+			t.Fatalf("expected 401 for %q, got %d", authorization, resp.StatusCode)
+		}
+	}
+	if status, _ := env.Send("POST", "/api/v1/webhooks", `{"url":"https://example.com","events":["post.created"]}`); status != 401 {
+		t.Fatalf("expected an anonymous subscription to be refused, got %d", status)
+	}
+
+	// Without a token configured, nobody is let in.
+	closed := testenv.New(t, nil)
+	if resp, _ := closed.Do("GET", "/api/v1/webhooks", "", "Authorization", "Bearer "); resp.StatusCode != 401 {
+		t.Fatalf("expected 401 without a token configured, got %d", resp.StatusCode)
+	}
+}
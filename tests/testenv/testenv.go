@@ -0,0 +1,101 @@
+// Package testenv wires the application the way the tests of every
+// infrastructure package need it: against an in-memory SQLite database,
+// with logs discarded, and closed when the test ends.
+package testenv
+
+import (
+	"DDD/src/infrastructure/bootstrap"
+	"DDD/src/infrastructure/config"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Env is a migrated container and the HTTP app serving it.
+type Env struct {
+	C   *bootstrap.Container
+	App *fiber.App
+	t   *testing.T
+}
+
+// Config is the default configuration on an in-memory SQLite database,
+// adjusted by mutate when it is set.
+func Config(mutate func(cfg *config.Config)) *config.Config {
+	cfg := config.Default()
+	cfg.Database.Connection = "sqlite://:memory:"
+	if mutate != nil {
+		mutate(&cfg)
+	}
+
+	return &cfg
+}
+
+// New wires the container on a migrated database and builds the HTTP app.
+func New(t *testing.T, mutate func(cfg *config.Config)) *Env {
+	t.Helper()
+
+	env := NewUnmigrated(t, mutate)
+	if err := env.C.Migrator().Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return env
+}
+
+// NewUnmigrated wires the container and builds the HTTP app, leaving every
+// migration pending.
+func NewUnmigrated(t *testing.T, mutate func(cfg *config.Config)) *Env {
+	t.Helper()
+
+	c, err := bootstrap.NewContainer(Config(mutate), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("container: %v", err)
+	}
+	t.Cleanup(func() { c.Close(context.Background()) })
+
+	return &Env{C: c, App: bootstrap.NewHttpApp(c), t: t}
+}
+
+// Do sends a JSON request through the app, with headers given as name and
+// value pairs, and reads the whole response.
+func (e *Env) Do(method, target, body string, headers ...string) (*http.Response, []byte) {
+	e.t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := e.App.Test(req, -1)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		e.t.Fatalf("read %s %s: %v", method, target, err)
+	}
+
+	return resp, out
+}
+
+// Send sends a JSON request and decodes the JSON object it answers, nil
+// when the body is not one.
+func (e *Env) Send(method, target, body string, headers ...string) (int, map[string]any) {
+	e.t.Helper()
+
+	resp, out := e.Do(method, target, body, headers...)
+
+	var result map[string]any
+	_ = json.Unmarshal(out, &result)
+
+	return resp.StatusCode, result
+}